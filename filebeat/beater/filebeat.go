@@ -35,6 +35,7 @@ import (
 	"github.com/elastic/beats/libbeat/kibana"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/management"
+	"github.com/elastic/beats/libbeat/management/status"
 	"github.com/elastic/beats/libbeat/monitoring"
 	"github.com/elastic/beats/libbeat/outputs/elasticsearch"
 
@@ -64,6 +65,7 @@ type Filebeat struct {
 	config         *cfg.Config
 	moduleRegistry *fileset.ModuleRegistry
 	done           chan struct{}
+	status         status.Reporter
 }
 
 // New creates a new Filebeat pointer instance.
@@ -405,6 +407,12 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 	}
 	adiscover.Start()
 
+	// The inputs are started, the status of each input is reported by its
+	// own runner.
+	if fb.status != nil {
+		fb.status.UpdateStatus(status.Running, "")
+	}
+
 	// Add done channel to wait for shutdown signal
 	waitFinished.AddChan(fb.done)
 	waitFinished.Wait()
@@ -438,6 +446,13 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 	return nil
 }
 
+// SetStatusReporter sets the reporter used to report the status of Filebeat.
+// Filebeat is reported as running once the configured inputs have been
+// started.
+func (fb *Filebeat) SetStatusReporter(reporter status.Reporter) {
+	fb.status = reporter
+}
+
 // Stop is called on exit to stop the crawling, spooling and registration processes.
 func (fb *Filebeat) Stop() {
 	logp.Info("Stopping filebeat")
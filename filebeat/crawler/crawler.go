@@ -30,12 +30,14 @@ import (
 	"github.com/elastic/beats/libbeat/cfgfile"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/management/status"
 
 	_ "github.com/elastic/beats/filebeat/include"
 )
 
 type Crawler struct {
 	inputs          map[uint64]*input.Runner
+	inputStatuses   map[uint64]*status.Runner
 	inputConfigs    []*common.Config
 	out             channel.Factory
 	wg              sync.WaitGroup
@@ -50,12 +52,13 @@ type Crawler struct {
 
 func New(out channel.Factory, inputConfigs []*common.Config, beatVersion string, beatDone chan struct{}, once bool) (*Crawler, error) {
 	return &Crawler{
-		out:          out,
-		inputs:       map[uint64]*input.Runner{},
-		inputConfigs: inputConfigs,
-		once:         once,
-		beatVersion:  beatVersion,
-		beatDone:     beatDone,
+		out:           out,
+		inputs:        map[uint64]*input.Runner{},
+		inputStatuses: map[uint64]*status.Runner{},
+		inputConfigs:  inputConfigs,
+		once:          once,
+		beatVersion:   beatVersion,
+		beatDone:      beatDone,
 	}, nil
 }
 
@@ -117,20 +120,28 @@ func (c *Crawler) startInput(
 		return nil
 	}
 
-	connector := c.out(pipeline)
+	st, err := cfgfile.RegisterStatus(config)
+	if err != nil {
+		return fmt.Errorf("Error while initializing input: %s", err)
+	}
+
+	connector := c.out(st.Pipeline(pipeline))
 	p, err := input.New(config, connector, c.beatDone, states, nil)
 	if err != nil {
+		st.Unregister()
 		return fmt.Errorf("Error while initializing input: %s", err)
 	}
 	p.Once = c.once
 
 	if _, ok := c.inputs[p.ID]; ok {
+		st.Unregister()
 		return fmt.Errorf("Input with same ID already exists: %d", p.ID)
 	}
 
 	c.inputs[p.ID] = p
+	c.inputStatuses[p.ID] = st
 
-	p.Start()
+	cfgfile.StartRunner(p, st)
 
 	return nil
 }
@@ -147,9 +158,11 @@ func (c *Crawler) Stop() {
 	}
 
 	logp.Info("Stopping %v inputs", len(c.inputs))
-	for _, p := range c.inputs {
+	for id, p := range c.inputs {
+		p, st := p, c.inputStatuses[id]
+
 		// Stop inputs in parallel
-		asyncWaitStop(p.Stop)
+		asyncWaitStop(func() { cfgfile.StopRunner(p, st) })
 	}
 
 	if c.inputReloader != nil {
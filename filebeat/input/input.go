@@ -28,6 +28,7 @@ import (
 	"github.com/elastic/beats/filebeat/input/file"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/management/status"
 	"github.com/elastic/beats/libbeat/monitoring"
 )
 
@@ -55,6 +56,7 @@ type Runner struct {
 	ID       uint64
 	Once     bool
 	beatDone chan struct{}
+	status   status.Reporter
 }
 
 // New instantiates a new Runner
@@ -108,6 +110,15 @@ func New(
 	return input, nil
 }
 
+// SetStatusReporter sets the reporter used to report the status of the input.
+// The reporter is passed on to inputs reporting their own status.
+func (p *Runner) SetStatusReporter(reporter status.Reporter) {
+	p.status = reporter
+	if r, ok := p.input.(status.WithReporter); ok {
+		r.SetStatusReporter(reporter)
+	}
+}
+
 // Start starts the input
 func (p *Runner) Start() {
 	p.wg.Add(1)
@@ -121,11 +132,13 @@ func (p *Runner) Start() {
 
 	onceWg.Add(1)
 	inputList.Add(p.config.Type)
+	p.updateStatus(status.Running, "")
 	// Add waitgroup to make sure input is finished
 	go func() {
 		defer func() {
 			onceWg.Done()
 			p.stop()
+			p.updateStatus(status.Stopped, "")
 			p.wg.Done()
 		}()
 
@@ -174,6 +187,12 @@ func (p *Runner) stop() {
 	}
 }
 
+func (p *Runner) updateStatus(s status.Status, msg string) {
+	if p.status != nil {
+		p.status.UpdateStatus(s, msg)
+	}
+}
+
 func (p *Runner) String() string {
 	return fmt.Sprintf("input [type=%s, ID=%d]", p.config.Type, p.ID)
 }
@@ -21,9 +21,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/management/status"
 	"github.com/elastic/beats/libbeat/monitoring"
 )
 
@@ -38,6 +40,10 @@ func NewWithDefaultRoutes(log *logp.Logger, config *common.Config, ns lookupFunc
 	mux.HandleFunc("/state", makeAPIHandler(ns("state")))
 	mux.HandleFunc("/stats", makeAPIHandler(ns("stats")))
 	mux.HandleFunc("/dataset", makeAPIHandler(ns("dataset")))
+	mux.HandleFunc("/metrics", makePrometheusHandler(ns("info"), ns("stats"), ns("dataset")))
+	mux.HandleFunc("/healthz", makeHealthHandler(status.Default))
+	mux.HandleFunc("/readyz", makeReadyHandler(status.Default))
+	mux.HandleFunc("/inputs", makeInputsHandler(status.Default))
 	return New(log, mux, config)
 }
 
@@ -65,11 +71,95 @@ func makeAPIHandler(ns *monitoring.Namespace) handlerFunc {
 	}
 }
 
+// makePrometheusHandler reports the metrics of the stats and dataset
+// namespaces in the Prometheus text format. Metrics are labeled with the beat
+// type and name, per output metrics with the output name and per input
+// metrics with the input ID.
+func makePrometheusHandler(info, stats, dataset *monitoring.Namespace) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		beatInfo := monitoring.CollectFlatSnapshot(info.GetRegistry(), monitoring.Full, false)
+		vs := monitoring.NewPrometheusVisitor("beat", map[string]string{
+			"beat": beatInfo.Strings["beat"],
+			"name": beatInfo.Strings["name"],
+		})
+		// Per output metrics of multiple outputs get a name of their own, the
+		// name of a route can be equal to the type of a single output.
+		vs.LabelKey("libbeat.pipeline.outputs", "output", "libbeat.pipeline.output")
+		vs.LabelValue("libbeat.output", "output", "type")
+		vs.LabelKey("dataset", "input", "input")
+
+		stats.GetRegistry().Visit(monitoring.Full, vs)
+		monitoring.ReportVar(vs, "dataset", monitoring.Full, dataset.GetRegistry())
+
+		vs.WriteTo(w)
+	}
+}
+
+// makeHealthHandler reports the status of the beat. The beat is considered
+// healthy unless it failed.
+func makeHealthHandler(reg *status.Registry) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		beatStatus, msg := reg.Status()
+		data := common.MapStr{"status": beatStatus.String()}
+		if msg != "" {
+			data["message"] = msg
+		}
+
+		if beatStatus == status.Failed {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		prettyPrint(w, data, r.URL)
+	}
+}
+
+// makeReadyHandler reports if the beat is running and all its inputs have
+// been started successfully.
+func makeReadyHandler(reg *status.Registry) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		data := common.MapStr{"ready": true}
+		if err := reg.Ready(); err != nil {
+			data = common.MapStr{"ready": false, "message": err.Error()}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		prettyPrint(w, data, r.URL)
+	}
+}
+
+// makeInputsHandler reports the status of all running inputs and modules.
+func makeInputsHandler(reg *status.Registry) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		states := reg.Runners()
+		inputs := make([]common.MapStr, len(states))
+		for i, state := range states {
+			inputs[i] = common.MapStr{
+				"id":          state.ID,
+				"type":        state.Type,
+				"config_hash": strconv.FormatUint(state.ConfigHash, 10),
+				"state":       state.Status.String(),
+				"last_error":  state.LastError,
+				"events": common.MapStr{
+					"published": state.Published,
+				},
+			}
+		}
+
+		prettyPrint(w, common.MapStr{"inputs": inputs}, r.URL)
+	}
+}
+
 func prettyPrint(w http.ResponseWriter, data common.MapStr, u *url.URL) {
 	query := u.Query()
 	if _, ok := query["pretty"]; ok {
-		fmt.Fprintf(w, data.StringToPrint())
+		fmt.Fprint(w, data.StringToPrint())
 	} else {
-		fmt.Fprintf(w, data.String())
+		fmt.Fprint(w, data.String())
 	}
 }
@@ -0,0 +1,134 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/management/status"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	namespaces := monitoring.NewNamespaces()
+
+	info := namespaces.Get("info").GetRegistry()
+	monitoring.NewString(info, "beat").Set("testbeat")
+	monitoring.NewString(info, "name").Set("host-1")
+
+	stats := namespaces.Get("stats").GetRegistry()
+	monitoring.NewUint(stats, "libbeat.pipeline.outputs.es.events.acked").Set(3)
+
+	dataset := namespaces.Get("dataset").GetRegistry()
+	monitoring.NewUint(dataset, "input-1.events").Set(7)
+
+	handler := makePrometheusHandler(namespaces.Get("info"), namespaces.Get("stats"), namespaces.Get("dataset"))
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := ioutil.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `# TYPE beat_input_events untyped
+beat_input_events{beat="testbeat",input="input-1",name="host-1"} 7
+# TYPE beat_libbeat_pipeline_output_events_acked untyped
+beat_libbeat_pipeline_output_events_acked{beat="testbeat",name="host-1",output="es"} 3
+`, string(body))
+}
+
+func TestPrometheusHandlerOutputNameEqualsType(t *testing.T) {
+	namespaces := monitoring.NewNamespaces()
+
+	stats := namespaces.Get("stats").GetRegistry()
+	monitoring.NewString(stats, "libbeat.output.type").Set("kafka")
+	monitoring.NewUint(stats, "libbeat.output.events.acked").Set(1)
+	monitoring.NewString(stats, "libbeat.pipeline.outputs.kafka.type").Set("kafka")
+	monitoring.NewUint(stats, "libbeat.pipeline.outputs.kafka.events.acked").Set(2)
+	monitoring.NewUint(stats, "libbeat.outputs.kafka.bytes_read").Set(3)
+
+	handler := makePrometheusHandler(namespaces.Get("info"), namespaces.Get("stats"), namespaces.Get("dataset"))
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := ioutil.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, `# TYPE beat_libbeat_output_events_acked untyped
+beat_libbeat_output_events_acked{beat="",name="",output="kafka"} 1
+# TYPE beat_libbeat_outputs_kafka_bytes_read untyped
+beat_libbeat_outputs_kafka_bytes_read{beat="",name=""} 3
+# TYPE beat_libbeat_pipeline_output_events_acked untyped
+beat_libbeat_pipeline_output_events_acked{beat="",name="",output="kafka"} 2
+`, string(body))
+}
+
+func TestStatusHandlers(t *testing.T) {
+	reg := status.NewRegistry()
+
+	get := func(handler handlerFunc, path string) (int, string) {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", path, nil))
+		body, err := ioutil.ReadAll(w.Result().Body)
+		require.NoError(t, err)
+		return w.Code, string(body)
+	}
+
+	code, body := get(makeHealthHandler(reg), "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"status": "starting"}`, body)
+
+	code, body = get(makeReadyHandler(reg), "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.JSONEq(t, `{"ready": false, "message": "beat is starting"}`, body)
+
+	reg.UpdateStatus(status.Running, "")
+	runner := reg.Register("my-input", "log", 42)
+	runner.UpdateStatus(status.Failed, "cannot open file")
+
+	code, body = get(makeReadyHandler(reg), "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.JSONEq(t, `{"ready": false, "message": "inputs not ready: my-input is failed"}`, body)
+
+	code, body = get(makeInputsHandler(reg), "/inputs")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"inputs": [{
+		"id": "my-input",
+		"type": "log",
+		"config_hash": "42",
+		"state": "failed",
+		"last_error": "cannot open file",
+		"events": {"published": 0}
+	}]}`, body)
+
+	runner.UpdateStatus(status.Running, "")
+	code, body = get(makeReadyHandler(reg), "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"ready": true}`, body)
+
+	reg.UpdateStatus(status.Failed, "output failure")
+	code, body = get(makeHealthHandler(reg), "/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.JSONEq(t, `{"status": "failed", "message": "output failure"}`, body)
+}
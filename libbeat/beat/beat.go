@@ -73,6 +73,9 @@ type Beat struct {
 type BeatConfig struct {
 	// output/publishing related configurations
 	Output common.ConfigNamespace `config:"output"`
+
+	// Outputs configures multiple outputs to publish to at the same time.
+	Outputs []common.ConfigNamespace `config:"outputs"`
 }
 
 // SetupMLCallback can be used by the Beat to register MachineLearning configurations
@@ -28,11 +28,13 @@ import (
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/reload"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/management/status"
 )
 
 // RunnerList implements a reloadable.List of Runners
 type RunnerList struct {
 	runners  map[uint64]Runner
+	statuses map[uint64]*status.Runner
 	mutex    sync.RWMutex
 	factory  RunnerFactory
 	pipeline beat.Pipeline
@@ -43,6 +45,7 @@ type RunnerList struct {
 func NewRunnerList(name string, factory RunnerFactory, pipeline beat.Pipeline) *RunnerList {
 	return &RunnerList{
 		runners:  map[uint64]Runner{},
+		statuses: map[uint64]*status.Runner{},
 		factory:  factory,
 		pipeline: pipeline,
 		logger:   logp.NewLogger(name),
@@ -61,6 +64,10 @@ func (r *RunnerList) Reload(configs []*reload.ConfigWithMeta) error {
 
 	r.logger.Debugf("Starting reload procedure, current runners: %d", len(stopList))
 
+	// forget about runners that failed to be created, they are retried below
+	// if their config is still present
+	r.removeFailedStatuses()
+
 	// diff current & desired state, create action lists
 	for _, config := range configs {
 		hash, err := HashConfig(config.Config)
@@ -82,8 +89,10 @@ func (r *RunnerList) Reload(configs []*reload.ConfigWithMeta) error {
 	// Stop removed runners
 	for hash, runner := range stopList {
 		r.logger.Debugf("Stopping runner: %s", runner)
+		st := r.statuses[hash]
 		delete(r.runners, hash)
-		go runner.Stop()
+		delete(r.statuses, hash)
+		go StopRunner(runner, st)
 	}
 
 	// Start new runners
@@ -91,16 +100,20 @@ func (r *RunnerList) Reload(configs []*reload.ConfigWithMeta) error {
 		// Pass a copy of the config to the factory, this way if the factory modifies it,
 		// that doesn't affect the hash of the original one.
 		c, _ := common.NewConfigFrom(config.Config)
-		runner, err := r.factory.Create(r.pipeline, c, config.Meta)
+		st := registerStatus(config.Config, hash)
+		r.statuses[hash] = st
+
+		runner, err := r.factory.Create(st.Pipeline(r.pipeline), c, config.Meta)
 		if err != nil {
 			r.logger.Errorf("Error creating runner from config: %s", err)
 			errs = append(errs, errors.Wrap(err, "Error creating runner from config"))
+			st.UpdateStatus(status.Failed, err.Error())
 			continue
 		}
 
 		r.logger.Debugf("Starting runner: %s", runner)
 		r.runners[hash] = runner
-		runner.Start()
+		StartRunner(runner, st)
 	}
 
 	return errs.Err()
@@ -111,6 +124,7 @@ func (r *RunnerList) Stop() {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	r.removeFailedStatuses()
 	if len(r.runners) == 0 {
 		return
 	}
@@ -121,15 +135,17 @@ func (r *RunnerList) Stop() {
 	for hash, runner := range r.copyRunnerList() {
 		wg.Add(1)
 
+		st := r.statuses[hash]
 		delete(r.runners, hash)
+		delete(r.statuses, hash)
 
 		// Stop modules in parallel
-		go func(h uint64, run Runner) {
+		go func(h uint64, run Runner, st *status.Runner) {
 			defer wg.Done()
 			r.logger.Debugf("Stopping runner: %s", run)
-			run.Stop()
+			StopRunner(run, st)
 			r.logger.Debugf("Stopped runner: %s", run)
-		}(hash, runner)
+		}(hash, runner, st)
 	}
 
 	wg.Wait()
@@ -150,6 +166,17 @@ func HashConfig(c *common.Config) (uint64, error) {
 	return hashstructure.Hash(config, nil)
 }
 
+// removeFailedStatuses removes the statuses of the runners that could not be
+// created from the status registry.
+func (r *RunnerList) removeFailedStatuses() {
+	for hash, st := range r.statuses {
+		if _, running := r.runners[hash]; !running {
+			st.Unregister()
+			delete(r.statuses, hash)
+		}
+	}
+}
+
 func (r *RunnerList) copyRunnerList() map[uint64]Runner {
 	list := make(map[uint64]Runner, len(r.runners))
 	for k, v := range r.runners {
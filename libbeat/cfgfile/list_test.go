@@ -26,6 +26,7 @@ import (
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/reload"
+	"github.com/elastic/beats/libbeat/management/status"
 )
 
 type runner struct {
@@ -183,6 +184,45 @@ func TestHas(t *testing.T) {
 	assert.False(t, list.Has(0))
 }
 
+func TestStatuses(t *testing.T) {
+	factory := &runnerFactory{}
+	list := NewRunnerList("", factory, nil)
+
+	list.Reload([]*reload.ConfigWithMeta{
+		createConfig(100),
+		createConfig(-100),
+	})
+
+	states := runnerStates("100", "-100")
+	if assert.Len(t, states, 2) {
+		assert.Equal(t, status.Failed, states["-100"].Status)
+		assert.Equal(t, "Invalid config", states["-100"].LastError)
+		assert.Equal(t, status.Running, states["100"].Status)
+	}
+
+	// failed runners are retried and reported only once
+	list.Reload([]*reload.ConfigWithMeta{
+		createConfig(100),
+		createConfig(-100),
+	})
+	assert.Len(t, runnerStates("100", "-100"), 2)
+
+	list.Stop()
+	assert.Len(t, runnerStates("100", "-100"), 0)
+}
+
+func runnerStates(ids ...string) map[string]status.RunnerState {
+	states := map[string]status.RunnerState{}
+	for _, state := range status.Default.Runners() {
+		for _, id := range ids {
+			if state.ID == id {
+				states[id] = state
+			}
+		}
+	}
+	return states
+}
+
 func createConfig(id int64) *reload.ConfigWithMeta {
 	c := common.NewConfig()
 	c.SetInt("id", -1, id)
@@ -0,0 +1,74 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cfgfile
+
+import (
+	"strconv"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/management/status"
+)
+
+// RegisterStatus registers the runner created from config in the default
+// status registry. The runner is identified by the `id` setting, or by the
+// config hash if no ID is configured. The runner type is read from the `type`
+// or `module` setting.
+func RegisterStatus(config *common.Config) (*status.Runner, error) {
+	hash, err := HashConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return registerStatus(config, hash), nil
+}
+
+func registerStatus(config *common.Config, hash uint64) *status.Runner {
+	var info struct {
+		ID     string `config:"id"`
+		Type   string `config:"type"`
+		Module string `config:"module"`
+	}
+	config.Unpack(&info)
+
+	if info.ID == "" {
+		info.ID = strconv.FormatUint(hash, 10)
+	}
+	if info.Type == "" {
+		info.Type = info.Module
+	}
+	return status.Default.Register(info.ID, info.Type, hash)
+}
+
+// StartRunner starts runner, reporting its status to st. Runners not
+// reporting their own status are considered running once started.
+func StartRunner(runner Runner, st *status.Runner) {
+	if r, ok := runner.(status.WithReporter); ok {
+		r.SetStatusReporter(st)
+		runner.Start()
+		return
+	}
+
+	runner.Start()
+	st.UpdateStatus(status.Running, "")
+}
+
+// StopRunner stops runner and removes it from the status registry.
+func StopRunner(runner Runner, st *status.Runner) {
+	st.UpdateStatus(status.Stopping, "")
+	runner.Stop()
+	st.Unregister()
+}
@@ -58,6 +58,7 @@ import (
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/logp/configure"
 	"github.com/elastic/beats/libbeat/management"
+	"github.com/elastic/beats/libbeat/management/status"
 	"github.com/elastic/beats/libbeat/metric/system/host"
 	"github.com/elastic/beats/libbeat/monitoring"
 	"github.com/elastic/beats/libbeat/monitoring/report"
@@ -110,6 +111,9 @@ type beatConfig struct {
 	// central management settings
 	Management *common.Config `config:"management"`
 
+	// reloading of the outputs and processors from the main configuration
+	ConfigReload *common.Config `config:"config.reload"`
+
 	// elastic stack 'setup' configurations
 	Dashboards *common.Config `config:"setup.dashboards"`
 	Kibana     *common.Config `config:"setup.kibana"`
@@ -322,7 +326,11 @@ func (b *Beat) createBeater(bt beat.Creator) (beat.Beater, error) {
 
 	debugf("Initializing output plugins")
 	outputEnabled := b.Config.Output.IsSet() && b.Config.Output.Config().Enabled()
-	if !outputEnabled {
+	multipleOutputs := len(b.Config.Outputs) > 0
+	if outputEnabled && multipleOutputs {
+		return nil, errors.New("output and outputs can not be configured at the same time")
+	}
+	if !outputEnabled && !multipleOutputs {
 		if b.ConfigManager.Enabled() {
 			logp.Info("Output is configured through Central Management")
 		} else {
@@ -332,28 +340,44 @@ func (b *Beat) createBeater(bt beat.Creator) (beat.Beater, error) {
 		}
 	}
 
-	pipeline, err := pipeline.Load(b.Info,
-		pipeline.Monitors{
-			Metrics:   reg,
-			Telemetry: monitoring.GetNamespace("state").GetRegistry(),
-			Logger:    logp.L().Named("publisher"),
-		},
-		b.Config.Pipeline,
-		b.processing,
-		b.makeOutputFactory(b.Config.Output),
-	)
+	monitors := pipeline.Monitors{
+		Metrics:   reg,
+		Telemetry: monitoring.GetNamespace("state").GetRegistry(),
+		Logger:    logp.L().Named("publisher"),
+	}
+
+	var publisher *pipeline.Pipeline
+	if multipleOutputs {
+		publisher, err = pipeline.LoadRouted(b.Info,
+			monitors,
+			b.Config.Pipeline,
+			b.processing,
+			b.Config.Outputs,
+			b.createOutput,
+		)
+	} else {
+		publisher, err = pipeline.Load(b.Info,
+			monitors,
+			b.Config.Pipeline,
+			b.processing,
+			b.makeOutputFactory(b.Config.Output),
+		)
+	}
 
 	if err != nil {
 		return nil, fmt.Errorf("error initializing publisher: %+v", err)
 	}
 
-	reload.Register.MustRegister("output", b.makeOutputReloader(pipeline.OutputReloader()))
+	reload.Register.MustRegister("output", b.makeOutputReloader(publisher.OutputReloader()))
+	if supporter, ok := b.processing.(processing.ReloadableSupporter); ok {
+		reload.Register.MustRegister("processors", makeProcessorsReloader(supporter))
+	}
 
 	// TODO: some beats race on shutdown with publisher.Stop -> do not call Stop yet,
 	//       but refine publisher to disconnect clients on stop automatically
 	// defer pipeline.Close()
 
-	b.Publisher = pipeline
+	b.Publisher = publisher
 	beater, err := bt(&b.Beat, sub)
 	if err != nil {
 		return nil, err
@@ -446,7 +470,10 @@ func (b *Beat) launch(settings Settings, bt beat.Creator) error {
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	svc.HandleSignals(beater.Stop, cancel)
+	svc.HandleSignals(func() {
+		status.Default.UpdateStatus(status.Stopping, "")
+		beater.Stop()
+	}, cancel)
 
 	err = b.loadDashboards(ctx, false)
 	if err != nil {
@@ -459,6 +486,51 @@ func (b *Beat) launch(settings Settings, bt beat.Creator) error {
 	b.ConfigManager.Start()
 	defer b.ConfigManager.Stop()
 
+	if b.ConfigManager.Enabled() {
+		if b.Config.ConfigReload.Enabled() {
+			logp.Warn("Reloading of the main configuration is disabled when using Central Management")
+		}
+	} else {
+		configReloader, err := newMainConfigReloader(b.Config.ConfigReload, b.RawConfig, settings.ConfigOverrides)
+		if err != nil {
+			return errw.Wrap(err, "invalid config.reload settings")
+		}
+		if configReloader != nil {
+			configReloader.Start()
+			defer configReloader.Stop()
+		}
+	}
+
+	setStatusReporter(beater, status.Default)
+	if err := b.runBeater(beater); err != nil {
+		status.Default.UpdateStatus(status.Failed, err.Error())
+		return err
+	}
+	status.Default.UpdateStatus(status.Stopped, "")
+	return nil
+}
+
+// setStatusReporter passes the reporter to beaters reporting their own status,
+// so the beat is only reported as running once its inputs have been started.
+// Other beaters are reported as running right away.
+func setStatusReporter(beater beat.Beater, reporter status.Reporter) {
+	if r, ok := beater.(status.WithReporter); ok {
+		r.SetStatusReporter(reporter)
+		return
+	}
+	reporter.UpdateStatus(status.Running, "")
+}
+
+// runBeater runs the beater until it stops. The asynchronous global processors
+// are stopped afterwards, while the pipeline is still running, so the events
+// they hold back, like the last window of the aggregate processor, are
+// published. The pipeline itself is never closed by the beat.
+func (b *Beat) runBeater(beater beat.Beater) error {
+	defer func() {
+		if s, ok := b.processing.(processing.AsyncSupporter); ok {
+			s.Stop()
+		}
+	}()
 	return beater.Run(&b.Beat)
 }
 
@@ -838,6 +910,16 @@ func (b *Beat) makeOutputReloader(outReloader pipeline.OutputReloader) reload.Re
 	})
 }
 
+func makeProcessorsReloader(supporter processing.ReloadableSupporter) reload.Reloadable {
+	return reload.ReloadableFunc(func(config *reload.ConfigWithMeta) error {
+		var cfg *common.Config
+		if config != nil {
+			cfg = config.Config
+		}
+		return supporter.Reload(cfg)
+	})
+}
+
 func (b *Beat) makeOutputFactory(
 	cfg common.ConfigNamespace,
 ) func(outputs.Observer) (string, outputs.Group, error) {
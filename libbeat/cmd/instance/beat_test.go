@@ -23,11 +23,23 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/management/status"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/publisher"
+	"github.com/elastic/beats/libbeat/publisher/pipeline"
+	"github.com/elastic/beats/libbeat/publisher/processing"
+	"github.com/elastic/beats/libbeat/publisher/queue"
+	"github.com/elastic/beats/libbeat/publisher/queue/memqueue"
 
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewInstance(t *testing.T) {
@@ -115,3 +127,109 @@ func TestEmptyMetaJson(t *testing.T) {
 	assert.Equal(t, nil, err, "Unable to load meta file properly")
 	assert.NotEqual(t, uuid.Nil, b.Info.ID, "Beats UUID is not set")
 }
+
+type publishingBeater struct {
+	events []common.MapStr
+}
+
+func (bt *publishingBeater) Run(b *beat.Beat) error {
+	client, err := b.Publisher.Connect()
+	if err != nil {
+		return err
+	}
+	for _, fields := range bt.events {
+		client.Publish(beat.Event{Timestamp: time.Now(), Fields: fields})
+	}
+	return client.Close()
+}
+
+func (bt *publishingBeater) Stop() {}
+
+type collectingClient struct {
+	published chan common.MapStr
+}
+
+func (c *collectingClient) Close() error   { return nil }
+func (c *collectingClient) String() string { return "collecting" }
+
+func (c *collectingClient) Publish(batch publisher.Batch) error {
+	for _, e := range batch.Events() {
+		c.published <- e.Content.Fields
+	}
+	batch.ACK()
+	return nil
+}
+
+func TestRunBeaterStopsAsyncProcessors(t *testing.T) {
+	info := beat.Info{Beat: "testbeat"}
+	support, err := processing.MakeDefaultSupport(false)(info, logp.L(), common.MustNewConfigFrom(map[string]interface{}{
+		"processors": []map[string]interface{}{
+			{"aggregate": map[string]interface{}{
+				"period":      "1h",
+				"fields":      []string{"value"},
+				"metrics":     []string{"sum"},
+				"drop_events": true,
+			}},
+		},
+	}))
+	require.NoError(t, err)
+
+	out := &collectingClient{published: make(chan common.MapStr, 10)}
+	p, err := pipeline.New(info, pipeline.Monitors{}, func(e queue.Eventer) (queue.Queue, error) {
+		return memqueue.NewBroker(nil, memqueue.Settings{Eventer: e, Events: 64, FlushMinEvents: 1}), nil
+	}, outputs.Group{Clients: []outputs.Client{out}, BatchSize: 10}, pipeline.Settings{Processors: support})
+	require.NoError(t, err)
+	defer p.Close()
+
+	b := &Beat{Beat: beat.Beat{Info: info, Publisher: p}, processing: support}
+	require.NoError(t, b.runBeater(&publishingBeater{events: []common.MapStr{
+		{"value": 1},
+		{"value": 2},
+	}}))
+
+	// The window is still open when the beater returns, its summary must be
+	// published when the beat stops.
+	select {
+	case fields := <-out.published:
+		count, _ := fields.GetValue("aggregate.count")
+		assert.EqualValues(t, 2, count)
+		sum, _ := fields.GetValue("aggregate.value.sum")
+		assert.EqualValues(t, 3, sum)
+	case <-time.After(5 * time.Second):
+		t.Fatal("summary of the last window was not published")
+	}
+}
+
+type reportingBeater struct {
+	publishingBeater
+	reporter status.Reporter
+}
+
+func (bt *reportingBeater) SetStatusReporter(reporter status.Reporter) {
+	bt.reporter = reporter
+}
+
+func TestSetStatusReporter(t *testing.T) {
+	t.Run("beater reporting its status", func(t *testing.T) {
+		registry := status.NewRegistry()
+		beater := &reportingBeater{}
+		setStatusReporter(beater, registry)
+
+		// Not ready until the beater reports its inputs have been started.
+		st, _ := registry.Status()
+		assert.Equal(t, status.Starting, st)
+		assert.Error(t, registry.Ready())
+		require.NotNil(t, beater.reporter)
+
+		beater.reporter.UpdateStatus(status.Running, "")
+		assert.NoError(t, registry.Ready())
+	})
+
+	t.Run("other beaters", func(t *testing.T) {
+		registry := status.NewRegistry()
+		setStatusReporter(&publishingBeater{}, registry)
+
+		st, _ := registry.Status()
+		assert.Equal(t, status.Running, st)
+	})
+}
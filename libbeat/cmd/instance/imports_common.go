@@ -27,11 +27,20 @@ import (
 	_ "github.com/elastic/beats/libbeat/processors/add_locale"
 	_ "github.com/elastic/beats/libbeat/processors/add_observer_metadata"
 	_ "github.com/elastic/beats/libbeat/processors/add_process_metadata"
+	_ "github.com/elastic/beats/libbeat/processors/aggregate"
 	_ "github.com/elastic/beats/libbeat/processors/communityid"
 	_ "github.com/elastic/beats/libbeat/processors/convert"
+	_ "github.com/elastic/beats/libbeat/processors/decode_kv"
+	_ "github.com/elastic/beats/libbeat/processors/deduplicate"
 	_ "github.com/elastic/beats/libbeat/processors/dissect"
 	_ "github.com/elastic/beats/libbeat/processors/dns"
 	_ "github.com/elastic/beats/libbeat/processors/extract_array"
+	_ "github.com/elastic/beats/libbeat/processors/geoip"
+	_ "github.com/elastic/beats/libbeat/processors/grok"
+	_ "github.com/elastic/beats/libbeat/processors/lookup"
+	_ "github.com/elastic/beats/libbeat/processors/rate_limit"
+	_ "github.com/elastic/beats/libbeat/processors/redact"
 	_ "github.com/elastic/beats/libbeat/processors/registered_domain"
+	_ "github.com/elastic/beats/libbeat/processors/user_agent"
 	_ "github.com/elastic/beats/libbeat/publisher/includes" // Register publisher pipeline modules
 )
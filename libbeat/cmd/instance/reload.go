@@ -0,0 +1,222 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package instance
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/hashstructure"
+
+	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/cloudid"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/reload"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// mainConfigReloader periodically reads the main configuration files of the
+// beat, and reloads the outputs and the global processors when their
+// settings have changed.
+type mainConfigReloader struct {
+	log      *logp.Logger
+	period   time.Duration
+	registry *reload.Registry
+	load     func() (*common.Config, error)
+
+	// multipleOutputs is set if the beat has been started with the
+	// `outputs` setting. Switching between `output` and `outputs` requires
+	// a restart.
+	multipleOutputs bool
+
+	outputHash     uint64
+	processorsHash uint64
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// mainConfigSections holds the reloadable settings of the main configuration.
+type mainConfigSections struct {
+	Output     map[string]interface{}   `config:"output"`
+	Outputs    []map[string]interface{} `config:"outputs"`
+	Processors []map[string]interface{} `config:"processors"`
+}
+
+// newMainConfigReloader creates a reloader for the main configuration if
+// enabled in the `config.reload` settings. It returns nil if reloading is
+// disabled.
+func newMainConfigReloader(
+	cfg *common.Config,
+	rawConfig *common.Config,
+	overrides []cfgfile.ConditionalOverride,
+) (*mainConfigReloader, error) {
+	config := cfgfile.DefaultDynamicConfig.Reload
+	if cfg != nil {
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+	}
+	if !config.Enabled {
+		return nil, nil
+	}
+	if config.Period <= 0 {
+		return nil, errors.New("config.reload.period must be greater than 0")
+	}
+
+	r := &mainConfigReloader{
+		log:      logp.NewLogger("reload"),
+		period:   config.Period,
+		registry: reload.Register,
+		load: func() (*common.Config, error) {
+			cfg, err := cfgfile.Load("", overrides)
+			if err != nil {
+				return nil, err
+			}
+			if err := cloudid.OverwriteSettings(cfg); err != nil {
+				return nil, err
+			}
+			return cfg, nil
+		},
+		multipleOutputs: rawConfig.HasField("outputs"),
+		done:            make(chan struct{}),
+	}
+
+	var err error
+	r.outputHash, r.processorsHash, err = hashMainConfig(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Start starts watching the main configuration.
+func (r *mainConfigReloader) Start() {
+	r.log.Infof("Reloading of the output and processors enabled, checking the configuration every %v", r.period)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.period)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				r.check()
+			}
+		}
+	}()
+}
+
+// Stop stops watching the main configuration.
+func (r *mainConfigReloader) Stop() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+// check reads the main configuration, reloading the outputs and processors
+// if their settings have changed. If reloading fails, the previous outputs or
+// processors are kept until the settings change again.
+func (r *mainConfigReloader) check() {
+	cfg, err := r.load()
+	if err != nil {
+		r.log.Errorf("Failed to read the configuration: %v", err)
+		return
+	}
+
+	outputHash, processorsHash, err := hashMainConfig(cfg)
+	if err != nil {
+		r.log.Errorf("Failed to read the configuration: %v", err)
+		return
+	}
+
+	if outputHash != r.outputHash {
+		r.outputHash = outputHash
+		r.log.Info("Output configuration changed, reloading the output")
+		if err := r.reloadOutput(cfg); err != nil {
+			r.log.Errorf("Failed to reload the output, keeping the previous output: %v", err)
+		}
+	}
+
+	if processorsHash != r.processorsHash {
+		r.processorsHash = processorsHash
+		r.log.Info("Processors configuration changed, reloading the processors")
+		if err := r.reloadProcessors(cfg); err != nil {
+			r.log.Errorf("Failed to reload the processors, keeping the previous processors: %v", err)
+		}
+	}
+}
+
+func (r *mainConfigReloader) reloadOutput(cfg *common.Config) error {
+	multiple := cfg.HasField("outputs")
+	if multiple != r.multipleOutputs {
+		return errors.New("switching between output and outputs requires a restart")
+	}
+
+	reloadable := r.registry.GetReloadable("output")
+	if reloadable == nil {
+		return errors.New("output reloading is not supported")
+	}
+
+	// With multiple outputs, the pipeline reads the `outputs` setting from
+	// the configuration. Otherwise it expects the output namespace.
+	if multiple {
+		return reloadable.Reload(&reload.ConfigWithMeta{Config: cfg})
+	}
+
+	if !cfg.HasField("output") {
+		return reloadable.Reload(nil)
+	}
+	outCfg, err := cfg.Child("output", -1)
+	if err != nil {
+		return err
+	}
+	return reloadable.Reload(&reload.ConfigWithMeta{Config: outCfg})
+}
+
+func (r *mainConfigReloader) reloadProcessors(cfg *common.Config) error {
+	reloadable := r.registry.GetReloadable("processors")
+	if reloadable == nil {
+		return errors.New("processors reloading is not supported")
+	}
+	return reloadable.Reload(&reload.ConfigWithMeta{Config: cfg})
+}
+
+// hashMainConfig computes the hashes of the output and processors settings.
+func hashMainConfig(cfg *common.Config) (outputHash, processorsHash uint64, err error) {
+	sections := mainConfigSections{}
+	if err := cfg.Unpack(&sections); err != nil {
+		return 0, 0, err
+	}
+
+	outputHash, err = hashstructure.Hash([]interface{}{sections.Output, sections.Outputs}, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	processorsHash, err = hashstructure.Hash(sections.Processors, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	return outputHash, processorsHash, nil
+}
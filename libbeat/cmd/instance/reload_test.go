@@ -0,0 +1,123 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/reload"
+)
+
+type recordingReloadable struct {
+	configs []*reload.ConfigWithMeta
+}
+
+func (r *recordingReloadable) Reload(config *reload.ConfigWithMeta) error {
+	r.configs = append(r.configs, config)
+	return nil
+}
+
+func TestMainConfigReloader(t *testing.T) {
+	initial := map[string]interface{}{
+		"output.console.enabled": true,
+		"processors": []map[string]interface{}{
+			{"add_tags.tags": []string{"a"}},
+		},
+	}
+
+	reloader, err := newMainConfigReloader(
+		common.MustNewConfigFrom(map[string]interface{}{"enabled": true}),
+		common.MustNewConfigFrom(initial),
+		nil,
+	)
+	require.NoError(t, err)
+	require.NotNil(t, reloader)
+
+	output := &recordingReloadable{}
+	processors := &recordingReloadable{}
+	reloader.registry = reload.NewRegistry()
+	require.NoError(t, reloader.registry.Register("output", output))
+	require.NoError(t, reloader.registry.Register("processors", processors))
+
+	current := initial
+	reloader.load = func() (*common.Config, error) {
+		return common.MustNewConfigFrom(current), nil
+	}
+
+	// unchanged configuration
+	reloader.check()
+	assert.Len(t, output.configs, 0)
+	assert.Len(t, processors.configs, 0)
+
+	// changed processors
+	current = map[string]interface{}{
+		"output.console.enabled": true,
+		"processors": []map[string]interface{}{
+			{"add_tags.tags": []string{"b"}},
+		},
+	}
+	reloader.check()
+	assert.Len(t, output.configs, 0)
+	require.Len(t, processors.configs, 1)
+	assert.True(t, processors.configs[0].Config.HasField("processors"))
+
+	// changed output, the output namespace is passed to the reloadable
+	current = map[string]interface{}{
+		"output.file.path": "/tmp",
+		"processors": []map[string]interface{}{
+			{"add_tags.tags": []string{"b"}},
+		},
+	}
+	reloader.check()
+	assert.Len(t, processors.configs, 1)
+	require.Len(t, output.configs, 1)
+	ns := common.ConfigNamespace{}
+	require.NoError(t, output.configs[0].Config.Unpack(&ns))
+	assert.Equal(t, "file", ns.Name())
+
+	// switching to multiple outputs is not supported
+	current = map[string]interface{}{
+		"outputs": []map[string]interface{}{
+			{"file.path": "/tmp"},
+		},
+		"processors": []map[string]interface{}{
+			{"add_tags.tags": []string{"b"}},
+		},
+	}
+	reloader.check()
+	assert.Len(t, output.configs, 1)
+}
+
+func TestMainConfigReloaderDisabled(t *testing.T) {
+	reloader, err := newMainConfigReloader(nil, common.NewConfig(), nil)
+	require.NoError(t, err)
+	assert.Nil(t, reloader)
+
+	_, err = newMainConfigReloader(
+		common.MustNewConfigFrom(map[string]interface{}{"enabled": true, "period": 0}),
+		common.NewConfig(),
+		nil,
+	)
+	assert.Error(t, err)
+}
@@ -0,0 +1,238 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/beats/libbeat/cmd/instance"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/cli"
+	"github.com/elastic/beats/libbeat/common/terminal"
+	"github.com/elastic/beats/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/libbeat/publisher"
+	"github.com/elastic/beats/libbeat/publisher/queue/spool"
+)
+
+// genQueueCmd initializes the queue command to work on the spool queue of a
+// stopped beat, with the following subcommands:
+//  - inspect
+//  - dump
+//  - drain
+//  - reset
+func genQueueCmd(settings instance.Settings) *cobra.Command {
+	var flagPath string
+	queueCmd := cobra.Command{
+		Use:   "queue",
+		Short: "Inspect and manage the spool queue of a stopped beat",
+	}
+	queueCmd.PersistentFlags().StringVar(&flagPath, "spool-path", "", "Path of the spool file, defaults to the configured spool file")
+
+	queueCmd.AddCommand(genInspectQueueCmd(settings, &flagPath))
+	queueCmd.AddCommand(genDumpQueueCmd(settings, &flagPath))
+	queueCmd.AddCommand(genDrainQueueCmd(settings, &flagPath))
+	queueCmd.AddCommand(genResetQueueCmd(settings, &flagPath))
+
+	return &queueCmd
+}
+
+func genInspectQueueCmd(settings instance.Settings, path *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "inspect",
+		Short: "Show the state of the spool queue",
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			_, inspector, err := openSpool(settings, *path, true)
+			if err != nil {
+				return err
+			}
+			defer inspector.Close()
+
+			return inspectSpool(os.Stdout, inspector)
+		}),
+	}
+}
+
+func genDumpQueueCmd(settings instance.Settings, path *string) *cobra.Command {
+	var flagCount uint
+	command := &cobra.Command{
+		Use:   "dump",
+		Short: "Print the events in the spool queue as JSON lines",
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			b, inspector, err := openSpool(settings, *path, true)
+			if err != nil {
+				return err
+			}
+			defer inspector.Close()
+
+			_, err = dumpSpool(os.Stdout, b, inspector, flagCount)
+			return err
+		}),
+	}
+	command.Flags().UintVar(&flagCount, "count", 0, "Maximum number of events to print, all events are printed if 0")
+	return command
+}
+
+func genDrainQueueCmd(settings instance.Settings, path *string) *cobra.Command {
+	var flagCount uint
+	command := &cobra.Command{
+		Use:   "drain",
+		Short: "Print the events in the spool queue as JSON lines and remove them from the queue",
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			b, inspector, err := openSpool(settings, *path, false)
+			if err != nil {
+				return err
+			}
+			defer inspector.Close()
+
+			// Only remove the events once all of them have been written.
+			count, err := dumpSpool(os.Stdout, b, inspector, flagCount)
+			if err != nil {
+				return err
+			}
+			if count == 0 {
+				return nil
+			}
+			if err := inspector.Remove(count); err != nil {
+				return fmt.Errorf("failed to remove the events from the spool queue: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "Removed %d events from the spool queue\n", count)
+			return nil
+		}),
+	}
+	command.Flags().UintVar(&flagCount, "count", 0, "Maximum number of events to drain, all events are drained if 0")
+	return command
+}
+
+func genResetQueueCmd(settings instance.Settings, path *string) *cobra.Command {
+	var flagForce bool
+	command := &cobra.Command{
+		Use:   "reset",
+		Short: "Remove all events from the spool queue",
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			_, inspector, err := openSpool(settings, *path, false)
+			if err != nil {
+				return err
+			}
+			defer inspector.Close()
+
+			if !flagForce {
+				msg := fmt.Sprintf("All events in %s will be lost. Continue?", inspector.Path())
+				if !terminal.PromptYesNo(msg, false) {
+					fmt.Println("Exiting without modifying the spool queue.")
+					return nil
+				}
+			}
+
+			if err := inspector.Remove(0); err != nil {
+				return fmt.Errorf("failed to reset the spool queue: %v", err)
+			}
+			fmt.Println("Removed all events from the spool queue")
+			return nil
+		}),
+	}
+	command.Flags().BoolVar(&flagForce, "force", false, "Reset the queue without asking for confirmation")
+	return command
+}
+
+// openSpool opens the spool file of the beat. The file configured in the
+// `queue.spool` settings is used, unless path is set.
+func openSpool(settings instance.Settings, path string, readonly bool) (*instance.Beat, *spool.Inspector, error) {
+	b, err := instance.NewInitializedBeat(settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error initializing beat: %s", err)
+	}
+
+	var cfg *common.Config
+	if queue := b.Config.Pipeline.Queue; queue.Name() == "spool" {
+		cfg = queue.Config()
+	} else if path == "" {
+		return nil, nil, errors.New("the spool queue is not configured, use --spool-path to select the spool file")
+	}
+
+	if path != "" {
+		if cfg == nil {
+			cfg = common.NewConfig()
+		}
+		if err := cfg.SetString("file.path", -1, path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	inspector, err := spool.OpenInspector(cfg, readonly)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, inspector, nil
+}
+
+func inspectSpool(w io.Writer, inspector *spool.Inspector) error {
+	stats, err := inspector.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to read the spool queue: %v", err)
+	}
+
+	formatTime := func(ts time.Time) string {
+		if ts.IsZero() {
+			return "-"
+		}
+		return ts.UTC().Format(time.RFC3339Nano)
+	}
+
+	fmt.Fprintf(w, "Path: %s\n", stats.Path)
+	fmt.Fprintf(w, "File size: %s (max %s)\n", humanize.IBytes(stats.Size), humanize.IBytes(stats.MaxSize))
+	fmt.Fprintf(w, "Page size: %s\n", humanize.IBytes(uint64(stats.PageSize)))
+	fmt.Fprintf(w, "Pages in use: %d\n", stats.Pages)
+	fmt.Fprintf(w, "Pending events: %d\n", stats.Events)
+	fmt.Fprintf(w, "Oldest event: %s\n", formatTime(stats.Oldest))
+	fmt.Fprintf(w, "Newest event: %s\n", formatTime(stats.Newest))
+	return nil
+}
+
+// dumpSpool writes up to n events of the spool as JSON lines, returning the
+// number of events written.
+func dumpSpool(w io.Writer, b *instance.Beat, inspector *spool.Inspector, n uint) (uint, error) {
+	out := bufio.NewWriter(w)
+	codec := json.New(b.Info.Version, json.Config{})
+
+	count, err := inspector.Read(n, func(event publisher.Event) error {
+		serialized, err := codec.Encode(b.Info.IndexPrefix, &event.Content)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(serialized); err != nil {
+			return err
+		}
+		return out.WriteByte('\n')
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to read the spool queue: %v", err)
+	}
+
+	if err := out.Flush(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
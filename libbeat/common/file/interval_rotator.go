@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -147,8 +148,10 @@ func OrderIntervalLogs(filename string) string {
 	return ""
 }
 
-// IntervalLogIndex returns n as int given a log filename in the form [prefix]-[formattedDate]-n
+// IntervalLogIndex returns n as int given a log filename in the form [prefix]-[formattedDate]-n.
+// The extension of compressed files is ignored.
 func IntervalLogIndex(filename string) (uint64, int, error) {
+	filename = strings.TrimSuffix(filename, compressedExt)
 	i := len(filename) - 1
 	for ; i >= 0; i-- {
 		if '0' > filename[i] || filename[i] > '9' {
@@ -18,6 +18,8 @@
 package file
 
 import (
+	"compress/gzip"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -31,6 +33,10 @@ import (
 // greater will result in an error.
 const MaxBackupsLimit = 1024
 
+// compressedExt is the extension added to rotated files when compression is
+// enabled.
+const compressedExt = ".gz"
+
 // rotateReason is the reason why file rotation occurred.
 type rotateReason uint32
 
@@ -70,6 +76,7 @@ type Rotator struct {
 	rotateOnStartup bool
 	intervalRotator *intervalRotator // Optional, may be nil
 	redirectStderr  bool
+	compress        bool
 
 	file  *os.File
 	size  uint
@@ -141,6 +148,14 @@ func RedirectStderr(redirect bool) RotatorOption {
 	}
 }
 
+// Compress configures the Rotator to gzip compress files after rotating them.
+// Compressed backups get a .gz extension. The default is false.
+func Compress(b bool) RotatorOption {
+	return func(r *Rotator) {
+		r.compress = b
+	}
+}
+
 // NewFileRotator returns a new Rotator.
 func NewFileRotator(filename string, options ...RotatorOption) (*Rotator, error) {
 	r := &Rotator{
@@ -178,6 +193,7 @@ func NewFileRotator(filename string, options ...RotatorOption) (*Rotator, error)
 			"max_backups", r.maxBackups,
 			"permissions", r.permissions,
 			"interval", r.interval,
+			"compress", r.compress,
 		)
 	}
 
@@ -252,6 +268,9 @@ func (r *Rotator) backupName(n uint) string {
 	if n == 0 {
 		return r.filename
 	}
+	if r.compress {
+		return r.filename + "." + strconv.Itoa(int(n)) + compressedExt
+	}
 	return r.filename + "." + strconv.Itoa(int(n))
 }
 
@@ -438,7 +457,12 @@ func (r *Rotator) rotateByInterval(reason rotateReason) error {
 		targetFilename = logPrefix + strconv.Itoa(int(lastLogIndex)+1)
 	}
 
-	if err := os.Rename(r.filename, targetFilename); err != nil {
+	if r.compress {
+		err = compressFile(r.filename, targetFilename+compressedExt, r.permissions)
+	} else {
+		err = os.Rename(r.filename, targetFilename)
+	}
+	if err != nil {
 		return errors.Wrap(err, "failed to rotate backups")
 	}
 
@@ -465,7 +489,14 @@ func (r *Rotator) rotateBySize(reason rotateReason) error {
 		if err := os.Remove(older); err != nil && !os.IsNotExist(err) {
 			return errors.Wrap(err, "failed to rotate backups")
 		}
-		if err := os.Rename(old, older); err != nil {
+
+		var err error
+		if i == 1 && r.compress {
+			err = compressFile(old, older, r.permissions)
+		} else {
+			err = os.Rename(old, older)
+		}
+		if err != nil {
 			return errors.Wrap(err, "failed to rotate backups")
 		} else if i == 1 {
 			// Log when rotation of the main file occurs.
@@ -476,3 +507,33 @@ func (r *Rotator) rotateBySize(reason rotateReason) error {
 	}
 	return nil
 }
+
+// compressFile writes a gzip compressed copy of src to dst and removes src
+// once the copy has been written successfully.
+func compressFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	w := gzip.NewWriter(out)
+	if _, err = io.Copy(w, in); err == nil {
+		err = w.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return errors.Wrapf(err, "failed to compress %v", src)
+	}
+
+	in.Close()
+	return os.Remove(src)
+}
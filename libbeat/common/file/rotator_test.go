@@ -18,6 +18,7 @@
 package file_test
 
 import (
+	"compress/gzip"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -75,6 +76,68 @@ func TestFileRotator(t *testing.T) {
 	AssertDirContents(t, dir, "sample.log.2")
 }
 
+func TestFileRotatorCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file_rotator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "sample.log")
+	r, err := file.NewFileRotator(filename,
+		file.MaxBackups(2),
+		file.Compress(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	WriteMsg(t, r)
+	Rotate(t, r)
+	AssertDirContents(t, dir, "sample.log.1.gz")
+	AssertGzipContents(t, filepath.Join(dir, "sample.log.1.gz"), logMessage)
+
+	WriteMsg(t, r)
+	WriteMsg(t, r)
+	Rotate(t, r)
+	AssertDirContents(t, dir, "sample.log.1.gz", "sample.log.2.gz")
+	AssertGzipContents(t, filepath.Join(dir, "sample.log.1.gz"), logMessage+logMessage)
+	AssertGzipContents(t, filepath.Join(dir, "sample.log.2.gz"), logMessage)
+
+	WriteMsg(t, r)
+	Rotate(t, r)
+	AssertDirContents(t, dir, "sample.log.1.gz", "sample.log.2.gz")
+}
+
+func TestIntervalRotationCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "interval_file_rotator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "daily")
+	r, err := file.NewFileRotator(filename,
+		file.MaxBackups(2),
+		file.Interval(24*time.Hour),
+		file.Compress(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	WriteMsg(t, r)
+	Rotate(t, r)
+	WriteMsg(t, r)
+	Rotate(t, r)
+
+	today := time.Now().Format("2006-01-02")
+	AssertDirContents(t, dir, "daily-"+today+"-1.gz", "daily-"+today+"-2.gz")
+	AssertGzipContents(t, filepath.Join(dir, "daily-"+today+"-2.gz"), logMessage)
+}
+
 func TestFileRotatorConcurrently(t *testing.T) {
 	dir, err := ioutil.TempDir("", "file_rotator")
 	if err != nil {
@@ -244,6 +307,27 @@ func AssertDirContents(t *testing.T, dir string, files ...string) {
 	assert.EqualValues(t, files, names)
 }
 
+func AssertGzipContents(t *testing.T, filename string, expected string) {
+	t.Helper()
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expected, string(contents))
+}
+
 func WriteMsg(t *testing.T, r *file.Rotator) {
 	t.Helper()
 
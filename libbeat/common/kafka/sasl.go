@@ -0,0 +1,304 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg/scram"
+)
+
+// SASLConfig contains the SASL authentication settings shared by the Kafka
+// output and the Kafka input. The credentials for the PLAIN and SCRAM
+// mechanisms are read from the `username` and `password` settings.
+type SASLConfig struct {
+	Mechanism string      `config:"mechanism"`
+	OAuth     OAuthConfig `config:"oauth"`
+}
+
+// OAuthConfig configures how tokens for the OAUTHBEARER mechanism are obtained.
+// Exactly one of token, token_file or token_url must be set.
+type OAuthConfig struct {
+	Token        string            `config:"token"`
+	TokenFile    string            `config:"token_file"`
+	TokenURL     string            `config:"token_url"`
+	ClientID     string            `config:"client_id"`
+	ClientSecret string            `config:"client_secret"`
+	Scopes       []string          `config:"scopes"`
+	Extensions   map[string]string `config:"extensions"`
+	Timeout      time.Duration     `config:"timeout" validate:"min=0"`
+}
+
+const (
+	saslPlain       = sarama.SASLTypePlaintext
+	saslSCRAMSHA256 = sarama.SASLTypeSCRAMSHA256
+	saslSCRAMSHA512 = sarama.SASLTypeSCRAMSHA512
+	saslOAuthBearer = sarama.SASLTypeOAuth
+
+	defaultTokenTimeout = 10 * time.Second
+)
+
+func (c *SASLConfig) mechanism(username string) string {
+	m := strings.ToUpper(c.Mechanism)
+	if m == "" && username != "" {
+		// Keep the previous behavior of enabling SASL/PLAIN if only
+		// username and password are configured.
+		return saslPlain
+	}
+	return m
+}
+
+// Validate checks the SASL settings against the configured credentials.
+func (c *SASLConfig) Validate(username, password string) error {
+	switch c.mechanism(username) {
+	case "":
+	case saslPlain, saslSCRAMSHA256, saslSCRAMSHA512:
+		if username == "" || password == "" {
+			return fmt.Errorf("username and password must be set for sasl mechanism %v", c.Mechanism)
+		}
+	case saslOAuthBearer:
+		return c.OAuth.validate()
+	default:
+		return fmt.Errorf("sasl mechanism '%v' not supported", c.Mechanism)
+	}
+	return nil
+}
+
+func (c *OAuthConfig) validate() error {
+	set := 0
+	for _, s := range []string{c.Token, c.TokenFile, c.TokenURL} {
+		if s != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of oauth.token, oauth.token_file or oauth.token_url must be set for sasl mechanism OAUTHBEARER")
+	}
+	if c.TokenURL != "" && c.ClientID == "" {
+		return errors.New("oauth.client_id must be set when oauth.token_url is configured")
+	}
+	return nil
+}
+
+// ConfigureSarama enables SASL authentication in the sarama configuration if a
+// SASL mechanism or a username is configured.
+func (c *SASLConfig) ConfigureSarama(k *sarama.Config, username, password string) error {
+	mechanism := c.mechanism(username)
+	if mechanism == "" {
+		return nil
+	}
+
+	k.Net.SASL.Enable = true
+	k.Net.SASL.Mechanism = sarama.SASLMechanism(mechanism)
+
+	switch mechanism {
+	case saslPlain:
+		k.Net.SASL.User = username
+		k.Net.SASL.Password = password
+	case saslSCRAMSHA256:
+		k.Net.SASL.User = username
+		k.Net.SASL.Password = password
+		k.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: scramSHA256}
+		}
+	case saslSCRAMSHA512:
+		k.Net.SASL.User = username
+		k.Net.SASL.Password = password
+		k.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: scramSHA512}
+		}
+	case saslOAuthBearer:
+		provider, err := newTokenProvider(c.OAuth)
+		if err != nil {
+			return err
+		}
+		k.Net.SASL.TokenProvider = provider
+	default:
+		return fmt.Errorf("sasl mechanism '%v' not supported", c.Mechanism)
+	}
+	return nil
+}
+
+var (
+	scramSHA256 scram.HashGeneratorFcn = func() hash.Hash { return sha256.New() }
+	scramSHA512 scram.HashGeneratorFcn = func() hash.Hash { return sha512.New() }
+)
+
+// scramClient implements sarama.SCRAMClient on top of the xdg/scram package.
+type scramClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	scram.HashGeneratorFcn
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.HashGeneratorFcn.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.Client = client
+	c.ClientConversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.ClientConversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.ClientConversation.Done()
+}
+
+func newTokenProvider(c OAuthConfig) (sarama.AccessTokenProvider, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c.Token != "":
+		return &staticTokenProvider{token: c.Token, extensions: c.Extensions}, nil
+	case c.TokenFile != "":
+		return &fileTokenProvider{path: c.TokenFile, extensions: c.Extensions}, nil
+	default:
+		timeout := c.Timeout
+		if timeout == 0 {
+			timeout = defaultTokenTimeout
+		}
+		return &clientCredentialsTokenProvider{
+			config: c,
+			http:   &http.Client{Timeout: timeout},
+		}, nil
+	}
+}
+
+// staticTokenProvider returns the configured token.
+type staticTokenProvider struct {
+	token      string
+	extensions map[string]string
+}
+
+func (p *staticTokenProvider) Token() (*sarama.AccessToken, error) {
+	return &sarama.AccessToken{Token: p.token, Extensions: p.extensions}, nil
+}
+
+// fileTokenProvider reads the token from a file on every connection attempt,
+// so tokens rotated by an external process are picked up.
+type fileTokenProvider struct {
+	path       string
+	extensions map[string]string
+}
+
+func (p *fileTokenProvider) Token() (*sarama.AccessToken, error) {
+	raw, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read oauth token file: %v", err)
+	}
+
+	token := strings.TrimSpace(string(raw))
+	if token == "" {
+		return nil, fmt.Errorf("oauth token file %v is empty", p.path)
+	}
+	return &sarama.AccessToken{Token: token, Extensions: p.extensions}, nil
+}
+
+// clientCredentialsTokenProvider requests tokens from an OAuth 2.0 token
+// endpoint using the client credentials grant. Tokens are reused until
+// shortly before they expire.
+type clientCredentialsTokenProvider struct {
+	config OAuthConfig
+	http   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// tokenExpiryDelta is subtracted from the token lifetime, so a token is
+// refreshed before it expires while a connection is being established.
+const tokenExpiryDelta = 10 * time.Second
+
+func (p *clientCredentialsTokenProvider) Token() (*sarama.AccessToken, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == "" || (!p.expires.IsZero() && time.Now().After(p.expires)) {
+		if err := p.refresh(); err != nil {
+			return nil, err
+		}
+	}
+	return &sarama.AccessToken{Token: p.token, Extensions: p.config.Extensions}, nil
+}
+
+func (p *clientCredentialsTokenProvider) refresh() error {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(p.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequest("POST", p.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request oauth token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read oauth token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oauth token request failed with %v: %s", resp.Status, body)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("failed to parse oauth token response: %v", err)
+	}
+	if token.AccessToken == "" {
+		return errors.New("oauth token response does not contain an access_token")
+	}
+
+	p.token = token.AccessToken
+	p.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		p.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryDelta)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package kafka
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xdg/scram"
+)
+
+func TestSASLConfigureSarama(t *testing.T) {
+	tests := map[string]struct {
+		config    SASLConfig
+		username  string
+		enabled   bool
+		mechanism sarama.SASLMechanism
+	}{
+		"disabled": {},
+		"plain by default": {
+			username:  "user",
+			enabled:   true,
+			mechanism: sarama.SASLTypePlaintext,
+		},
+		"scram-sha-256": {
+			config:    SASLConfig{Mechanism: "scram-sha-256"},
+			username:  "user",
+			enabled:   true,
+			mechanism: sarama.SASLTypeSCRAMSHA256,
+		},
+		"scram-sha-512": {
+			config:    SASLConfig{Mechanism: "SCRAM-SHA-512"},
+			username:  "user",
+			enabled:   true,
+			mechanism: sarama.SASLTypeSCRAMSHA512,
+		},
+		"oauthbearer": {
+			config:    SASLConfig{Mechanism: "OAUTHBEARER", OAuth: OAuthConfig{Token: "abc"}},
+			enabled:   true,
+			mechanism: sarama.SASLTypeOAuth,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, test.config.Validate(test.username, "secret"))
+
+			k := sarama.NewConfig()
+			err := test.config.ConfigureSarama(k, test.username, "secret")
+			require.NoError(t, err)
+
+			assert.Equal(t, test.enabled, k.Net.SASL.Enable)
+			if test.enabled {
+				assert.Equal(t, test.mechanism, k.Net.SASL.Mechanism)
+			}
+		})
+	}
+}
+
+func TestSASLValidate(t *testing.T) {
+	tests := map[string]struct {
+		config   SASLConfig
+		username string
+		password string
+	}{
+		"unknown mechanism": {
+			config:   SASLConfig{Mechanism: "GSSAPI"},
+			username: "user",
+			password: "secret",
+		},
+		"scram without password": {
+			config:   SASLConfig{Mechanism: "SCRAM-SHA-256"},
+			username: "user",
+		},
+		"oauthbearer without token": {
+			config: SASLConfig{Mechanism: "OAUTHBEARER"},
+		},
+		"oauthbearer with multiple token sources": {
+			config: SASLConfig{Mechanism: "OAUTHBEARER", OAuth: OAuthConfig{Token: "abc", TokenFile: "/tmp/token"}},
+		},
+		"oauthbearer token url without client id": {
+			config: SASLConfig{Mechanism: "OAUTHBEARER", OAuth: OAuthConfig{TokenURL: "http://localhost/token"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, test.config.Validate(test.username, test.password))
+		})
+	}
+}
+
+func TestSCRAMClient(t *testing.T) {
+	for name, hash := range map[string]scram.HashGeneratorFcn{
+		"sha256": scramSHA256,
+		"sha512": scramSHA512,
+	} {
+		t.Run(name, func(t *testing.T) {
+			credClient, err := hash.NewClient("user", "secret", "")
+			require.NoError(t, err)
+			creds := credClient.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+
+			server, err := hash.NewServer(func(string) (scram.StoredCredentials, error) {
+				return creds, nil
+			})
+			require.NoError(t, err)
+			serverConv := server.NewConversation()
+
+			client := &scramClient{HashGeneratorFcn: hash}
+			require.NoError(t, client.Begin("user", "secret", ""))
+
+			challenge := ""
+			for !client.Done() {
+				msg, err := client.Step(challenge)
+				require.NoError(t, err)
+				if client.Done() {
+					break
+				}
+				challenge, err = serverConv.Step(msg)
+				require.NoError(t, err)
+			}
+			assert.True(t, serverConv.Valid())
+		})
+	}
+}
+
+func TestFileTokenProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kafka-token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(path, []byte("first\n"), 0600))
+
+	provider, err := newTokenProvider(OAuthConfig{TokenFile: path})
+	require.NoError(t, err)
+
+	token, err := provider.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "first", token.Token)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("second"), 0600))
+	token, err = provider.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "second", token.Token)
+}
+
+func TestClientCredentialsTokenProvider(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "beats", user)
+		assert.Equal(t, "secret", pass)
+		assert.Equal(t, "client_credentials", r.FormValue("grant_type"))
+		assert.Equal(t, "kafka produce", r.FormValue("scope"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "token-from-server", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	provider, err := newTokenProvider(OAuthConfig{
+		TokenURL:     server.URL,
+		ClientID:     "beats",
+		ClientSecret: "secret",
+		Scopes:       []string{"kafka", "produce"},
+		Extensions:   map[string]string{"logicalCluster": "lkc-1"},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		token, err := provider.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "token-from-server", token.Token)
+		assert.Equal(t, map[string]string{"logicalCluster": "lkc-1"}, token.Extensions)
+	}
+
+	// token is cached until it expires
+	assert.Equal(t, 1, requests)
+}
+
+func TestClientCredentialsTokenProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	provider, err := newTokenProvider(OAuthConfig{TokenURL: server.URL, ClientID: "beats"})
+	require.NoError(t, err)
+
+	_, err = provider.Token()
+	assert.Error(t, err)
+}
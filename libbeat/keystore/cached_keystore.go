@@ -0,0 +1,140 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// secretsLoader reads all secrets from an external secret store.
+type secretsLoader func() (map[string]string, error)
+
+// cachedKeystore is a read-only keystore serving the secrets of an external
+// secret store from memory. The secrets are read again on access once
+// they are older than the refresh interval. If reading the secrets fails the
+// previously read secrets are kept.
+type cachedKeystore struct {
+	sync.Mutex
+	name    string
+	load    secretsLoader
+	refresh time.Duration
+	log     *logp.Logger
+
+	secrets map[string]string
+	loaded  time.Time
+
+	// for testing
+	now func() time.Time
+}
+
+// newCachedKeystore creates a new cached keystore, reading the secrets for
+// the first time. Errors reading the secrets are returned.
+func newCachedKeystore(name string, refresh time.Duration, load secretsLoader) (*cachedKeystore, error) {
+	k := &cachedKeystore{
+		name:    name,
+		load:    load,
+		refresh: refresh,
+		log:     logp.NewLogger("keystore"),
+		now:     time.Now,
+	}
+
+	secrets, err := load()
+	if err != nil {
+		return nil, err
+	}
+	k.secrets = secrets
+	k.loaded = k.now()
+	return k, nil
+}
+
+// update reads the secrets again if the refresh interval did elapse.
+func (k *cachedKeystore) update() {
+	if k.refresh <= 0 || k.now().Sub(k.loaded) < k.refresh {
+		return
+	}
+
+	// Do not retry until the next refresh interval on failure.
+	k.loaded = k.now()
+
+	secrets, err := k.load()
+	if err != nil {
+		k.log.Warnf("Failed to refresh the secrets from the %v keystore, using cached secrets: %v", k.name, err)
+		return
+	}
+	k.secrets = secrets
+}
+
+// Retrieve returns a SecureString instance of the searched key.
+func (k *cachedKeystore) Retrieve(key string) (*SecureString, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	k.update()
+	secret, ok := k.secrets[key]
+	if !ok {
+		return nil, ErrKeyDoesntExists
+	}
+	return NewSecureString([]byte(secret)), nil
+}
+
+// List returns the available keys.
+func (k *cachedKeystore) List() ([]string, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	k.update()
+	keys := make([]string, 0, len(k.secrets))
+	for key := range k.secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// GetConfig returns common.Config representation of the key / secret pair.
+func (k *cachedKeystore) GetConfig() (*common.Config, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	k.update()
+	configHash := make(map[string]interface{}, len(k.secrets))
+	for key, secret := range k.secrets {
+		configHash[key] = secret
+	}
+	return common.NewConfigFrom(configHash)
+}
+
+// IsPersisted always returns true, the secrets are managed externally.
+func (k *cachedKeystore) IsPersisted() bool { return true }
+
+// Store returns ErrReadOnly.
+func (k *cachedKeystore) Store(key string, secret []byte) error { return ErrReadOnly }
+
+// Delete returns ErrReadOnly.
+func (k *cachedKeystore) Delete(key string) error { return ErrReadOnly }
+
+// Create returns ErrReadOnly.
+func (k *cachedKeystore) Create(override bool) error { return ErrReadOnly }
+
+// Save returns ErrReadOnly.
+func (k *cachedKeystore) Save() error { return ErrReadOnly }
@@ -0,0 +1,112 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedKeystoreRefresh(t *testing.T) {
+	secrets := map[string]string{"password": "first"}
+	var loadErr error
+	loads := 0
+	load := func() (map[string]string, error) {
+		loads++
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		copy := map[string]string{}
+		for k, v := range secrets {
+			copy[k] = v
+		}
+		return copy, nil
+	}
+
+	k, err := newCachedKeystore("test", time.Minute, load)
+	require.NoError(t, err)
+
+	now := time.Now()
+	k.now = func() time.Time { return now }
+	k.loaded = now
+
+	assertSecret := func(expected string) {
+		t.Helper()
+		s, err := k.Retrieve("password")
+		require.NoError(t, err)
+		v, _ := s.Get()
+		assert.Equal(t, expected, string(v))
+	}
+
+	// served from cache
+	secrets["password"] = "second"
+	assertSecret("first")
+	assert.Equal(t, 1, loads)
+
+	// refreshed after the refresh interval
+	now = now.Add(time.Minute)
+	assertSecret("second")
+	assert.Equal(t, 2, loads)
+
+	// keeps the cached secrets if refreshing fails
+	loadErr = errors.New("unavailable")
+	now = now.Add(time.Minute)
+	assertSecret("second")
+	assert.Equal(t, 3, loads)
+
+	// does not retry before the next refresh interval
+	assertSecret("second")
+	assert.Equal(t, 3, loads)
+
+	_, err = k.Retrieve("missing")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+}
+
+func TestCachedKeystoreIsReadOnly(t *testing.T) {
+	k, err := newCachedKeystore("test", 0, func() (map[string]string, error) {
+		return map[string]string{"b": "2", "a": "1"}, nil
+	})
+	require.NoError(t, err)
+
+	assert.True(t, k.IsPersisted())
+	assert.Equal(t, ErrReadOnly, k.Store("c", []byte("3")))
+	assert.Equal(t, ErrReadOnly, k.Delete("a"))
+	assert.Equal(t, ErrReadOnly, k.Create(true))
+	assert.Equal(t, ErrReadOnly, k.Save())
+
+	keys, err := k.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	cfg, err := k.GetConfig()
+	require.NoError(t, err)
+	v, err := cfg.String("b", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "2", v)
+}
+
+func TestCachedKeystoreInitialLoadFails(t *testing.T) {
+	_, err := newCachedKeystore("test", 0, func() (map[string]string, error) {
+		return nil, errors.New("unavailable")
+	})
+	assert.Error(t, err)
+}
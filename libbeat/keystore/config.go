@@ -17,11 +17,21 @@
 
 package keystore
 
+import "time"
+
 // Config Define keystore configurable options
 type Config struct {
+	// Type selects the keystore backend, defaults to the local file keystore.
+	Type string `config:"type"`
 	Path string `config:"path"`
+
+	// RefreshInterval defines how long secrets read from external backends are
+	// cached before being read again.
+	RefreshInterval time.Duration `config:"refresh_interval" validate:"positive"`
 }
 
 var defaultConfig = Config{
-	Path: "",
+	Type:            "file",
+	Path:            "",
+	RefreshInterval: 5 * time.Minute,
 }
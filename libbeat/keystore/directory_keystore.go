@@ -0,0 +1,81 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func init() {
+	RegisterBackend("directory", newDirectoryBackend)
+}
+
+func newDirectoryBackend(config Config, _ *common.Config) (Keystore, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("path is required by the directory keystore")
+	}
+	return NewDirectoryKeystore(config.Path, config)
+}
+
+// NewDirectoryKeystore returns a read-only keystore reading secrets from a
+// directory containing one file per secret, as mounted by Kubernetes or
+// Docker secrets. The file name is the key and the file content the secret,
+// without trailing newlines. Hidden files and sub-directories are ignored.
+func NewDirectoryKeystore(path string, config Config) (Keystore, error) {
+	return newCachedKeystore("directory", config.RefreshInterval, func() (map[string]string, error) {
+		return readSecretsDirectory(path)
+	})
+}
+
+func readSecretsDirectory(path string) (map[string]string, error) {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets directory: %v", err)
+	}
+
+	secrets := map[string]string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		// Stat the file, following symlinks as created by Kubernetes
+		file := filepath.Join(path, name)
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret '%v': %v", name, err)
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret '%v': %v", name, err)
+		}
+		secrets[name] = strings.TrimRight(string(content), "\r\n")
+	}
+	return secrets, nil
+}
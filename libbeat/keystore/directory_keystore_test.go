@@ -0,0 +1,78 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestDirectoryKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	write("ES_PASSWORD", "changeme\n")
+	write("API_KEY", "abc")
+	write(".hidden", "ignored")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0700))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0700))
+
+	resolver, err := ResolverFromConfig(common.MustNewConfigFrom(map[string]interface{}{
+		"type": "directory",
+		"path": dir,
+	}), "")
+	require.NoError(t, err)
+
+	v, err := resolver("ES_PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "changeme", v)
+
+	v, err = resolver("API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", v)
+
+	for _, key := range []string{".hidden", "subdir", "missing"} {
+		_, err = resolver(key)
+		assert.Error(t, err, key)
+	}
+}
+
+func TestDirectoryKeystoreRequiresPath(t *testing.T) {
+	_, err := Factory(common.MustNewConfigFrom(map[string]interface{}{
+		"type": "directory",
+	}), "/tmp/default.keystore")
+	assert.Error(t, err)
+}
+
+func TestFactoryUnknownType(t *testing.T) {
+	_, err := Factory(common.MustNewConfigFrom(map[string]interface{}{
+		"type": "unknown",
+	}), "")
+	assert.EqualError(t, err, "unknown keystore type 'unknown'")
+}
@@ -0,0 +1,104 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func init() {
+	RegisterBackend("env_file", newEnvFileBackend)
+}
+
+func newEnvFileBackend(config Config, _ *common.Config) (Keystore, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("path is required by the env_file keystore")
+	}
+	return NewEnvFileKeystore(config.Path, config)
+}
+
+// NewEnvFileKeystore returns a read-only keystore reading secrets from an
+// env file. Each line of the file contains a KEY=value pair, optionally
+// prefixed with 'export'. Values can be quoted, double quoted values support
+// Go escape sequences. Empty lines and lines starting with '#' are ignored.
+func NewEnvFileKeystore(path string, config Config) (Keystore, error) {
+	return newCachedKeystore("env_file", config.RefreshInterval, func() (map[string]string, error) {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file: %v", err)
+		}
+		return parseEnvFile(content)
+	})
+}
+
+func parseEnvFile(content []byte) (map[string]string, error) {
+	secrets := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		idx := strings.IndexByte(line, '=')
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid line %v in env file: missing key", lineNo)
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		value, err := parseEnvValue(strings.TrimSpace(line[idx+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for '%v' in env file line %v: %v", key, lineNo, err)
+		}
+		secrets[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func parseEnvValue(value string) (string, error) {
+	if len(value) < 2 {
+		return value, nil
+	}
+
+	switch quote := value[0]; {
+	case quote == '"' && value[len(value)-1] == '"':
+		return strconv.Unquote(value)
+	case quote == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1], nil
+	case quote == '"' || quote == '\'':
+		return "", fmt.Errorf("missing closing quote")
+	}
+
+	// strip comments following unquoted values
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = strings.TrimSpace(value[:idx])
+	}
+	return value, nil
+}
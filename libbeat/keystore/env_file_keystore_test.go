@@ -0,0 +1,81 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	content := `
+# credentials
+ES_USERNAME=elastic
+export ES_PASSWORD = "s3cr3t\"quoted\""
+SINGLE='raw \n value'
+WITH_COMMENT=value # comment
+EMPTY=
+URL=https://example.com/?a=b
+`
+	secrets, err := parseEnvFile([]byte(content))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"ES_USERNAME":  "elastic",
+		"ES_PASSWORD":  `s3cr3t"quoted"`,
+		"SINGLE":       `raw \n value`,
+		"WITH_COMMENT": "value",
+		"EMPTY":        "",
+		"URL":          "https://example.com/?a=b",
+	}, secrets)
+}
+
+func TestParseEnvFileErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"missing key":   "=value",
+		"missing =":     "KEY",
+		"missing quote": `KEY="value`,
+	} {
+		_, err := parseEnvFile([]byte(content))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestEnvFileKeystore(t *testing.T) {
+	f, err := ioutil.TempFile("", "secrets.env")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("ES_PASSWORD=changeme\n")
+	require.NoError(t, err)
+	f.Close()
+
+	store, err := Factory(common.MustNewConfigFrom(map[string]interface{}{
+		"type": "env_file",
+		"path": f.Name(),
+	}), "")
+	require.NoError(t, err)
+
+	v, err := ResolverWrap(store)("ES_PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "changeme", v)
+}
@@ -32,8 +32,32 @@ var (
 
 	// ErrKeyDoesntExists is returned when the key doesn't exist in the store
 	ErrKeyDoesntExists = errors.New("cannot retrieve the key")
+
+	// ErrReadOnly is returned when trying to modify a keystore backed by an
+	// external secret store.
+	ErrReadOnly = errors.New("the keystore is read-only")
 )
 
+// BackendFactory creates a keystore from the keystore configuration. cfg
+// contains the complete keystore configuration, including the backend
+// specific settings.
+type BackendFactory func(config Config, cfg *common.Config) (Keystore, error)
+
+var backends = map[string]BackendFactory{}
+
+// RegisterBackend registers a new keystore backend, selectable using the
+// keystore `type` setting.
+func RegisterBackend(name string, factory BackendFactory) {
+	if _, exists := backends[name]; exists {
+		panic(fmt.Sprintf("keystore backend '%v' already registered", name))
+	}
+	backends[name] = factory
+}
+
+func init() {
+	RegisterBackend("file", newFileBackend)
+}
+
 // Keystore implement a way to securely saves and retrieves secrets to be used in the configuration
 // Currently all credentials are loaded upfront and are not lazy retrieved, we will eventually move
 // to that concept, so we can deal with tokens that has a limited duration or can be revoked by a
@@ -83,13 +107,20 @@ func Factory(cfg *common.Config, defaultPath string) (Keystore, error) {
 		return nil, fmt.Errorf("could not read keystore configuration, err: %v", err)
 	}
 
-	if config.Path == "" {
+	factory, ok := backends[config.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown keystore type '%v'", config.Type)
+	}
+
+	if config.Type == "file" && config.Path == "" {
 		config.Path = defaultPath
 	}
+	return factory(config, cfg)
+}
 
+func newFileBackend(config Config, _ *common.Config) (Keystore, error) {
 	logp.Debug("keystore", "Loading file keystore from %s", config.Path)
-	keystore, err := NewFileKeystore(config.Path)
-	return keystore, err
+	return NewFileKeystore(config.Path)
 }
 
 // ResolverFromConfig create a resolver from a configuration.
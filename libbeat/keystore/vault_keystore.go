@@ -0,0 +1,313 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+func init() {
+	RegisterBackend("vault", newVaultBackend)
+}
+
+// VaultConfig holds the settings for reading secrets from a HashiCorp Vault
+// KV secrets engine.
+type VaultConfig struct {
+	Address   string            `config:"address" validate:"required"`
+	Mount     string            `config:"mount"`
+	Path      string            `config:"path" validate:"required"`
+	KVVersion int               `config:"kv_version"`
+	Namespace string            `config:"namespace"`
+	Token     string            `config:"token"`
+	AppRole   *VaultAppRole     `config:"approle"`
+	TLS       *tlscommon.Config `config:"ssl"`
+	Timeout   time.Duration     `config:"timeout" validate:"nonzero,positive"`
+}
+
+// VaultAppRole holds the credentials used to authenticate with the AppRole
+// auth method.
+type VaultAppRole struct {
+	Mount    string `config:"mount"`
+	RoleID   string `config:"role_id" validate:"required"`
+	SecretID string `config:"secret_id"`
+}
+
+var defaultVaultConfig = VaultConfig{
+	Mount:     "secret",
+	KVVersion: 2,
+	Timeout:   30 * time.Second,
+}
+
+// Validate checks the Vault settings.
+func (c *VaultConfig) Validate() error {
+	if c.KVVersion != 1 && c.KVVersion != 2 {
+		return fmt.Errorf("unsupported kv_version %v, must be 1 or 2", c.KVVersion)
+	}
+	if c.Token == "" && c.AppRole == nil {
+		return errors.New("either token or approle must be configured")
+	}
+	if c.Token != "" && c.AppRole != nil {
+		return errors.New("token and approle can not be used at the same time")
+	}
+	return nil
+}
+
+type vaultClient struct {
+	config  VaultConfig
+	address string
+	http    *http.Client
+
+	mu           sync.Mutex
+	token        string
+	tokenExpires time.Time // zero if the token does not expire
+
+	// for testing
+	now func() time.Time
+}
+
+func newVaultBackend(config Config, cfg *common.Config) (Keystore, error) {
+	if !cfg.HasField("vault") {
+		return nil, errors.New("vault settings are required by the vault keystore")
+	}
+	sub, err := cfg.Child("vault", -1)
+	if err != nil {
+		return nil, err
+	}
+
+	vaultConfig := defaultVaultConfig
+	if err := sub.Unpack(&vaultConfig); err != nil {
+		return nil, fmt.Errorf("could not read vault keystore configuration, err: %v", err)
+	}
+
+	return NewVaultKeystore(vaultConfig, config)
+}
+
+// NewVaultKeystore returns a read-only keystore reading all keys of a single
+// secret from the HashiCorp Vault KV secrets engine. The client
+// authenticates using a token or an AppRole. Tokens obtained from an AppRole
+// login are renewed by logging in again when they expire.
+func NewVaultKeystore(vaultConfig VaultConfig, config Config) (Keystore, error) {
+	client, err := newVaultClient(vaultConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newCachedKeystore("vault", config.RefreshInterval, client.secrets)
+}
+
+func newVaultClient(config VaultConfig) (*vaultClient, error) {
+	u, err := url.Parse(config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse vault address: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported vault address scheme: %v", u.Scheme)
+	}
+
+	tls, err := tlscommon.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := transport.NetDialer(config.Timeout)
+	tlsDialer, err := transport.TLSDialer(dialer, tls, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vaultClient{
+		config:  config,
+		address: strings.TrimSuffix(u.String(), "/"),
+		token:   config.Token,
+		http: &http.Client{
+			Transport: &http.Transport{
+				Dial:    dialer.Dial,
+				DialTLS: tlsDialer.Dial,
+				Proxy:   http.ProxyFromEnvironment,
+			},
+			Timeout: config.Timeout,
+		},
+		now: time.Now,
+	}, nil
+}
+
+// secretPath returns the API path of the configured secret.
+func (c *vaultClient) secretPath() string {
+	mount := strings.Trim(c.config.Mount, "/")
+	path := strings.Trim(c.config.Path, "/")
+	if c.config.KVVersion == 2 {
+		return "/v1/" + mount + "/data/" + path
+	}
+	return "/v1/" + mount + "/" + path
+}
+
+// secrets reads all keys of the configured secret.
+func (c *vaultClient) secrets() (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	status, err := c.authorizedRequest("GET", c.secretPath(), nil, &resp)
+	if err != nil && status == http.StatusForbidden && c.config.AppRole != nil {
+		// token might have been revoked, login again
+		c.token = ""
+		_, err = c.authorizedRequest("GET", c.secretPath(), nil, &resp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret from vault: %v", err)
+	}
+
+	data := resp.Data
+	if c.config.KVVersion == 2 {
+		var v2 struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &v2); err != nil {
+			return nil, fmt.Errorf("failed to parse vault secret: %v", err)
+		}
+		data = v2.Data
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse vault secret: %v", err)
+	}
+
+	secrets := make(map[string]string, len(values))
+	for key, value := range values {
+		if s, ok := value.(string); ok {
+			secrets[key] = s
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		secrets[key] = string(encoded)
+	}
+	return secrets, nil
+}
+
+func (c *vaultClient) authorizedRequest(method, path string, body, result interface{}) (int, error) {
+	if err := c.ensureToken(); err != nil {
+		return 0, err
+	}
+	return c.request(method, path, c.token, body, result)
+}
+
+// ensureToken logs in using the AppRole if no valid token is available.
+func (c *vaultClient) ensureToken() error {
+	if c.config.AppRole == nil {
+		return nil
+	}
+	if c.token != "" && (c.tokenExpires.IsZero() || c.now().Before(c.tokenExpires)) {
+		return nil
+	}
+
+	appRole := c.config.AppRole
+	mount := strings.Trim(appRole.Mount, "/")
+	if mount == "" {
+		mount = "approle"
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	login := map[string]string{"role_id": appRole.RoleID}
+	if appRole.SecretID != "" {
+		login["secret_id"] = appRole.SecretID
+	}
+	if _, err := c.request("POST", "/v1/auth/"+mount+"/login", "", login, &resp); err != nil {
+		return fmt.Errorf("vault approle login failed: %v", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return errors.New("vault approle login failed: no token returned")
+	}
+
+	c.token = resp.Auth.ClientToken
+	c.tokenExpires = time.Time{}
+	if lease := time.Duration(resp.Auth.LeaseDuration) * time.Second; lease > 0 {
+		// renew the token a little before it expires
+		c.tokenExpires = c.now().Add(lease * 9 / 10)
+	}
+	return nil
+}
+
+func (c *vaultClient) request(method, path, token string, body, result interface{}) (int, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.address+path, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.config.Namespace)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode >= 300 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return resp.StatusCode, fmt.Errorf("vault returned %v: %v", resp.Status, strings.Join(vaultErr.Errors, ", "))
+		}
+		return resp.StatusCode, fmt.Errorf("vault returned %v", resp.Status)
+	}
+
+	return resp.StatusCode, json.Unmarshal(data, result)
+}
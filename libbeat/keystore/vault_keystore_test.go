@@ -0,0 +1,223 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// vaultStandIn is a minimal stand-in for the Vault HTTP API, serving a
+// single KV secret and supporting the AppRole login.
+type vaultStandIn struct {
+	sync.Mutex
+	token   string
+	secret  map[string]interface{}
+	logins  int
+	lease   int
+	revoked bool
+}
+
+func (v *vaultStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.Lock()
+	defer v.Unlock()
+
+	writeJSON := func(status int, body interface{}) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		var login map[string]string
+		json.NewDecoder(r.Body).Decode(&login)
+		if login["role_id"] != "role" || login["secret_id"] != "secret" {
+			writeJSON(400, map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		v.logins++
+		v.revoked = false
+		writeJSON(200, map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": v.token, "lease_duration": v.lease},
+		})
+
+	case "/v1/secret/data/beats":
+		if r.Header.Get("X-Vault-Token") != v.token || v.revoked {
+			writeJSON(403, map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		writeJSON(200, map[string]interface{}{
+			"data": map[string]interface{}{"data": v.secret, "metadata": map[string]interface{}{"version": 1}},
+		})
+
+	case "/v1/kv/beats":
+		if r.Header.Get("X-Vault-Token") != v.token {
+			writeJSON(403, map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		writeJSON(200, map[string]interface{}{"data": v.secret})
+
+	default:
+		writeJSON(404, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func newVaultStandIn() (*vaultStandIn, *httptest.Server) {
+	v := &vaultStandIn{
+		token:  "s.token",
+		secret: map[string]interface{}{"ES_PASSWORD": "changeme", "PORT": 9200},
+	}
+	return v, httptest.NewServer(v)
+}
+
+func TestVaultKeystoreToken(t *testing.T) {
+	_, server := newVaultStandIn()
+	defer server.Close()
+
+	store, err := Factory(common.MustNewConfigFrom(map[string]interface{}{
+		"type": "vault",
+		"vault": map[string]interface{}{
+			"address": server.URL,
+			"path":    "beats",
+			"token":   "s.token",
+		},
+	}), "")
+	require.NoError(t, err)
+
+	resolver := ResolverWrap(store)
+	v, err := resolver("ES_PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "changeme", v)
+
+	v, err = resolver("PORT")
+	require.NoError(t, err)
+	assert.Equal(t, "9200", v)
+}
+
+func TestVaultKeystoreKVVersion1(t *testing.T) {
+	_, server := newVaultStandIn()
+	defer server.Close()
+
+	store, err := Factory(common.MustNewConfigFrom(map[string]interface{}{
+		"type": "vault",
+		"vault": map[string]interface{}{
+			"address":    server.URL,
+			"mount":      "kv",
+			"path":       "beats",
+			"kv_version": 1,
+			"token":      "s.token",
+		},
+	}), "")
+	require.NoError(t, err)
+
+	keys, err := store.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ES_PASSWORD", "PORT"}, keys)
+}
+
+func TestVaultKeystoreInvalidToken(t *testing.T) {
+	_, server := newVaultStandIn()
+	defer server.Close()
+
+	_, err := Factory(common.MustNewConfigFrom(map[string]interface{}{
+		"type": "vault",
+		"vault": map[string]interface{}{
+			"address": server.URL,
+			"path":    "beats",
+			"token":   "invalid",
+		},
+	}), "")
+	assert.EqualError(t, err, "failed to read secret from vault: vault returned 403 Forbidden: permission denied")
+}
+
+func TestVaultAppRole(t *testing.T) {
+	v, server := newVaultStandIn()
+	defer server.Close()
+	v.lease = 60
+
+	config := defaultVaultConfig
+	config.Address = server.URL
+	config.Path = "beats"
+	config.AppRole = &VaultAppRole{RoleID: "role", SecretID: "secret"}
+	require.NoError(t, config.Validate())
+
+	client, err := newVaultClient(config)
+	require.NoError(t, err)
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	secrets, err := client.secrets()
+	require.NoError(t, err)
+	assert.Equal(t, "changeme", secrets["ES_PASSWORD"])
+	assert.Equal(t, 1, v.logins)
+
+	// token is reused while valid
+	_, err = client.secrets()
+	require.NoError(t, err)
+	assert.Equal(t, 1, v.logins)
+
+	// login again once the token expires
+	now = now.Add(time.Minute)
+	_, err = client.secrets()
+	require.NoError(t, err)
+	assert.Equal(t, 2, v.logins)
+
+	// login again if the token has been revoked
+	v.revoked = true
+	_, err = client.secrets()
+	require.NoError(t, err)
+	assert.Equal(t, 3, v.logins)
+}
+
+func TestVaultAppRoleLoginFailure(t *testing.T) {
+	_, server := newVaultStandIn()
+	defer server.Close()
+
+	config := defaultVaultConfig
+	config.Address = server.URL
+	config.Path = "beats"
+	config.AppRole = &VaultAppRole{RoleID: "role", SecretID: "wrong"}
+
+	client, err := newVaultClient(config)
+	require.NoError(t, err)
+
+	_, err = client.secrets()
+	assert.EqualError(t, err, "failed to read secret from vault: vault approle login failed: vault returned 400 Bad Request: invalid role or secret ID")
+}
+
+func TestVaultConfigValidate(t *testing.T) {
+	for name, settings := range map[string]map[string]interface{}{
+		"missing auth":       {"address": "http://localhost:8200", "path": "beats"},
+		"token and approle":  {"address": "http://localhost:8200", "path": "beats", "token": "t", "approle.role_id": "r"},
+		"invalid kv version": {"address": "http://localhost:8200", "path": "beats", "token": "t", "kv_version": 3},
+		"missing path":       {"address": "http://localhost:8200", "token": "t"},
+	} {
+		config := defaultVaultConfig
+		err := common.MustNewConfigFrom(settings).Unpack(&config)
+		assert.Error(t, err, name)
+	}
+}
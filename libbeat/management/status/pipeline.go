@@ -0,0 +1,67 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package status
+
+import "github.com/elastic/beats/libbeat/beat"
+
+type pipeline struct {
+	beat.Pipeline
+	runner *Runner
+}
+
+type client struct {
+	beat.Client
+	runner *Runner
+}
+
+// Pipeline wraps p, such that events published by clients connected to the
+// returned pipeline are accounted to the runner.
+func (r *Runner) Pipeline(p beat.Pipeline) beat.Pipeline {
+	return &pipeline{Pipeline: p, runner: r}
+}
+
+// Client wraps c, such that events published by c are accounted to the runner.
+func (r *Runner) Client(c beat.Client) beat.Client {
+	return &client{Client: c, runner: r}
+}
+
+func (p *pipeline) Connect() (beat.Client, error) {
+	c, err := p.Pipeline.Connect()
+	if err != nil {
+		return nil, err
+	}
+	return p.runner.Client(c), nil
+}
+
+func (p *pipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
+	c, err := p.Pipeline.ConnectWith(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p.runner.Client(c), nil
+}
+
+func (c *client) Publish(event beat.Event) {
+	c.Client.Publish(event)
+	c.runner.published.Inc()
+}
+
+func (c *client) PublishAll(events []beat.Event) {
+	c.Client.PublishAll(events)
+	c.runner.published.Add(uint64(len(events)))
+}
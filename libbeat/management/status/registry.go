@@ -0,0 +1,176 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package status
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/common/atomic"
+)
+
+// Default is the registry reporting the status of the running beat.
+var Default = NewRegistry()
+
+// Registry keeps track of the status of the beat and of all registered
+// runners.
+type Registry struct {
+	mu      sync.RWMutex
+	status  Status
+	msg     string
+	runners map[*Runner]struct{}
+}
+
+// Runner reports the status of a single input or module runner. Runner
+// implements the Reporter interface.
+type Runner struct {
+	registry   *Registry
+	id         string
+	typ        string
+	configHash uint64
+	published  atomic.Uint64
+
+	mu        sync.Mutex
+	status    Status
+	lastError string
+}
+
+// RunnerState is a snapshot of the status of a runner.
+type RunnerState struct {
+	ID         string
+	Type       string
+	ConfigHash uint64
+	Status     Status
+	LastError  string
+	Published  uint64
+}
+
+// NewRegistry creates a new registry for a beat that is starting.
+func NewRegistry() *Registry {
+	return &Registry{
+		status:  Starting,
+		runners: map[*Runner]struct{}{},
+	}
+}
+
+// UpdateStatus updates the status of the beat.
+func (r *Registry) UpdateStatus(status Status, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status, r.msg = status, msg
+}
+
+// Status returns the status of the beat and the message reported with it.
+func (r *Registry) Status() (Status, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.status, r.msg
+}
+
+// Register adds a new runner to the registry. The runner starts in the
+// Starting state and must be removed using Unregister once it has been
+// stopped.
+func (r *Registry) Register(id, typ string, configHash uint64) *Runner {
+	runner := &Runner{
+		registry:   r,
+		id:         id,
+		typ:        typ,
+		configHash: configHash,
+		status:     Starting,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runners[runner] = struct{}{}
+	return runner
+}
+
+// Runners returns the state of all registered runners, sorted by ID.
+func (r *Registry) Runners() []RunnerState {
+	r.mu.RLock()
+	states := make([]RunnerState, 0, len(r.runners))
+	for runner := range r.runners {
+		states = append(states, runner.State())
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].ID != states[j].ID {
+			return states[i].ID < states[j].ID
+		}
+		return states[i].ConfigHash < states[j].ConfigHash
+	})
+	return states
+}
+
+// Ready returns an error if the beat is not running yet, or if any of the
+// registered runners is still starting or has failed.
+func (r *Registry) Ready() error {
+	if status, _ := r.Status(); status != Running {
+		return fmt.Errorf("beat is %v", status)
+	}
+
+	var notReady []string
+	for _, state := range r.Runners() {
+		if state.Status == Starting || state.Status == Failed {
+			notReady = append(notReady, fmt.Sprintf("%v is %v", state.ID, state.Status))
+		}
+	}
+	if len(notReady) > 0 {
+		return fmt.Errorf("inputs not ready: %v", strings.Join(notReady, ", "))
+	}
+	return nil
+}
+
+func (r *Registry) unregister(runner *Runner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.runners, runner)
+}
+
+// UpdateStatus updates the status of the runner. A non empty message is kept
+// as the last error of the runner.
+func (r *Runner) UpdateStatus(status Status, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+	if msg != "" {
+		r.lastError = msg
+	}
+}
+
+// State returns a snapshot of the runner state.
+func (r *Runner) State() RunnerState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return RunnerState{
+		ID:         r.id,
+		Type:       r.typ,
+		ConfigHash: r.configHash,
+		Status:     r.status,
+		LastError:  r.lastError,
+		Published:  r.published.Load(),
+	}
+}
+
+// Unregister removes the runner from its registry.
+func (r *Runner) Unregister() {
+	r.registry.unregister(r)
+}
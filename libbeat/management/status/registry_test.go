@@ -0,0 +1,110 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+)
+
+type fakePipeline struct {
+	published int
+}
+
+type fakeClient struct {
+	pipeline *fakePipeline
+}
+
+func (p *fakePipeline) Connect() (beat.Client, error) { return &fakeClient{p}, nil }
+func (p *fakePipeline) ConnectWith(beat.ClientConfig) (beat.Client, error) {
+	return &fakeClient{p}, nil
+}
+func (p *fakePipeline) SetACKHandler(beat.PipelineACKHandler) error { return nil }
+
+func (c *fakeClient) Publish(beat.Event)         { c.pipeline.published++ }
+func (c *fakeClient) PublishAll(es []beat.Event) { c.pipeline.published += len(es) }
+func (c *fakeClient) Close() error               { return nil }
+
+func TestRegistryRunners(t *testing.T) {
+	reg := NewRegistry()
+	b := reg.Register("b", "log", 2)
+	a := reg.Register("a", "system", 1)
+
+	a.UpdateStatus(Degraded, "connection refused")
+	a.UpdateStatus(Running, "")
+
+	assert.Equal(t, []RunnerState{
+		{ID: "a", Type: "system", ConfigHash: 1, Status: Running, LastError: "connection refused"},
+		{ID: "b", Type: "log", ConfigHash: 2, Status: Starting},
+	}, reg.Runners())
+
+	b.Unregister()
+	assert.Len(t, reg.Runners(), 1)
+}
+
+func TestRegistryReady(t *testing.T) {
+	reg := NewRegistry()
+	assert.EqualError(t, reg.Ready(), "beat is starting")
+
+	reg.UpdateStatus(Running, "")
+	assert.NoError(t, reg.Ready())
+
+	runner := reg.Register("a", "log", 1)
+	assert.EqualError(t, reg.Ready(), "inputs not ready: a is starting")
+
+	runner.UpdateStatus(Degraded, "some error")
+	assert.NoError(t, reg.Ready())
+
+	runner.UpdateStatus(Failed, "fatal error")
+	assert.EqualError(t, reg.Ready(), "inputs not ready: a is failed")
+
+	runner.Unregister()
+	assert.NoError(t, reg.Ready())
+
+	reg.UpdateStatus(Stopping, "")
+	assert.EqualError(t, reg.Ready(), "beat is stopping")
+}
+
+func TestRunnerPipeline(t *testing.T) {
+	reg := NewRegistry()
+	runner := reg.Register("a", "log", 1)
+	out := &fakePipeline{}
+
+	p := runner.Pipeline(out)
+	c1, err := p.Connect()
+	require.NoError(t, err)
+	c2, err := p.ConnectWith(beat.ClientConfig{})
+	require.NoError(t, err)
+
+	c1.Publish(beat.Event{})
+	c2.PublishAll([]beat.Event{{}, {}})
+
+	assert.Equal(t, 3, out.published)
+	assert.Equal(t, uint64(3), runner.State().Published)
+}
+
+func TestStatusString(t *testing.T) {
+	assert.Equal(t, "running", Running.String())
+	assert.Equal(t, "unknown", Status(100).String())
+}
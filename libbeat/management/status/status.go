@@ -0,0 +1,64 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package status keeps track of the status of a beat and of the inputs and
+// modules it runs.
+package status
+
+// Status describes the state of the beat or of one of its runners.
+type Status int
+
+// List of known states.
+const (
+	Unknown Status = iota
+	Starting
+	Running
+	Degraded
+	Failed
+	Stopping
+	Stopped
+)
+
+var statusNames = map[Status]string{
+	Unknown:  "unknown",
+	Starting: "starting",
+	Running:  "running",
+	Degraded: "degraded",
+	Failed:   "failed",
+	Stopping: "stopping",
+	Stopped:  "stopped",
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return statusNames[Unknown]
+}
+
+// Reporter is used to report status changes. The message describes the
+// cause of the status change, usually the last error encountered.
+type Reporter interface {
+	UpdateStatus(status Status, msg string)
+}
+
+// WithReporter is implemented by runners and beaters that report their own
+// status. Runners and beaters not implementing WithReporter are considered to
+// be running once started.
+type WithReporter interface {
+	SetStatusReporter(reporter Reporter)
+}
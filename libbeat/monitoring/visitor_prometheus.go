@@ -0,0 +1,263 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package monitoring
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusVisitor collects the numeric metrics of one or more registries and
+// renders them in the Prometheus text exposition format.
+//
+// Metric names are built from the configured prefix and the path of the
+// metric in the registry, with every character not allowed by Prometheus
+// replaced by '_'. Bool metrics are reported as 0 or 1, string metrics are
+// not reported but can be used as label values (see LabelValue).
+// The registry does not distinguish counters from gauges, so all metrics are
+// reported as untyped.
+type PrometheusVisitor struct {
+	prefix     string
+	labels     []promLabel
+	keyRules   []promKeyRule
+	valueRules []promValueRule
+
+	level   []string
+	samples []promSample
+	strings map[string]string
+}
+
+type promLabel struct {
+	name, value string
+}
+
+type promKeyRule struct {
+	path  []string
+	label string
+	name  []string
+}
+
+type promValueRule struct {
+	path  []string
+	label string
+	key   string
+}
+
+type promSample struct {
+	path  []string
+	value float64
+}
+
+// NewPrometheusVisitor creates a new visitor prefixing all metric names with
+// prefix. The labels are added to all reported metrics.
+func NewPrometheusVisitor(prefix string, labels map[string]string) *PrometheusVisitor {
+	vs := &PrometheusVisitor{
+		prefix:  prefix,
+		strings: map[string]string{},
+	}
+	for name, value := range labels {
+		vs.labels = append(vs.labels, promLabel{name, value})
+	}
+	return vs
+}
+
+// LabelKey reports the names of the registries found directly below path as
+// the value of label. The metrics of these registries are reported as if
+// they were found below name.
+//
+// For example LabelKey("outputs", "output", "output") reports the metric
+// 'outputs.es.events.acked' as 'output_events_acked{output="es"}'.
+func (vs *PrometheusVisitor) LabelKey(path, label, name string) {
+	vs.keyRules = append(vs.keyRules, promKeyRule{
+		path:  splitPath(path),
+		label: label,
+		name:  splitPath(name),
+	})
+}
+
+// LabelValue reports the value of the string metric key found in path as
+// label for all metrics below path.
+func (vs *PrometheusVisitor) LabelValue(path, label, key string) {
+	vs.valueRules = append(vs.valueRules, promValueRule{
+		path:  splitPath(path),
+		label: label,
+		key:   key,
+	})
+}
+
+func (vs *PrometheusVisitor) OnRegistryStart() {}
+
+func (vs *PrometheusVisitor) OnRegistryFinished() {
+	if len(vs.level) > 0 {
+		vs.dropName()
+	}
+}
+
+func (vs *PrometheusVisitor) OnKey(name string) {
+	vs.level = append(vs.level, name)
+}
+
+func (vs *PrometheusVisitor) OnString(s string) {
+	vs.strings[strings.Join(vs.level, ".")] = s
+	vs.dropName()
+}
+
+func (vs *PrometheusVisitor) OnBool(b bool) {
+	if b {
+		vs.addSample(1)
+	} else {
+		vs.addSample(0)
+	}
+}
+
+func (vs *PrometheusVisitor) OnInt(i int64)            { vs.addSample(float64(i)) }
+func (vs *PrometheusVisitor) OnFloat(f float64)        { vs.addSample(f) }
+func (vs *PrometheusVisitor) OnStringSlice(f []string) { vs.dropName() }
+
+func (vs *PrometheusVisitor) addSample(value float64) {
+	path := make([]string, len(vs.level))
+	copy(path, vs.level)
+	vs.samples = append(vs.samples, promSample{path: path, value: value})
+	vs.dropName()
+}
+
+func (vs *PrometheusVisitor) dropName() {
+	vs.level = vs.level[:len(vs.level)-1]
+}
+
+// WriteTo writes all metrics collected so far in the Prometheus text format.
+// Metrics are sorted by name, samples of the same metric by their labels.
+func (vs *PrometheusVisitor) WriteTo(w io.Writer) (int64, error) {
+	families := map[string][]string{}
+	for _, sample := range vs.samples {
+		name, labels := vs.describe(sample.path)
+		line := name + formatPromLabels(labels) + " " + formatPromValue(sample.value) + "\n"
+		families[name] = append(families[name], line)
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		lines := families[name]
+		sort.Strings(lines)
+
+		buf.WriteString("# TYPE " + name + " untyped\n")
+		for _, line := range lines {
+			buf.WriteString(line)
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+// describe computes the metric name and labels of the metric found at path.
+func (vs *PrometheusVisitor) describe(path []string) (string, []promLabel) {
+	labels := append([]promLabel{}, vs.labels...)
+	name := path
+
+	for _, rule := range vs.valueRules {
+		if !hasPathPrefix(path, rule.path) {
+			continue
+		}
+		key := strings.Join(append(append([]string{}, rule.path...), rule.key), ".")
+		if value := vs.strings[key]; value != "" {
+			labels = append(labels, promLabel{rule.label, value})
+		}
+	}
+
+	for _, rule := range vs.keyRules {
+		if len(path) <= len(rule.path)+1 || !hasPathPrefix(path, rule.path) {
+			continue
+		}
+		labels = append(labels, promLabel{rule.label, path[len(rule.path)]})
+		name = append(append([]string{}, rule.name...), path[len(rule.path)+1:]...)
+		break
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+	return promMetricName(vs.prefix, name), labels
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+func hasPathPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func promMetricName(prefix string, path []string) string {
+	var b strings.Builder
+	if prefix != "" {
+		b.WriteString(sanitizePromName(prefix))
+	}
+	for _, elem := range path {
+		if b.Len() > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteString(sanitizePromName(elem))
+	}
+	return b.String()
+}
+
+func sanitizePromName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatPromLabels(labels []promLabel) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = sanitizePromName(l.name) + `="` + promLabelEscaper.Replace(l.value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatPromValue formats sample values. strconv already uses the NaN, +Inf
+// and -Inf spelling required by Prometheus.
+func formatPromValue(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,127 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package monitoring
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collectPrometheus(t *testing.T, vs *PrometheusVisitor, reg *Registry) string {
+	reg.Visit(Full, vs)
+
+	var buf bytes.Buffer
+	_, err := vs.WriteTo(&buf)
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func TestPrometheusVisitor(t *testing.T) {
+	reg := NewRegistry()
+	NewInt(reg, "beat.handles.open").Set(12)
+	NewUint(reg, "events.acked").Set(42)
+	NewFloat(reg, "system.load.1").Set(0.5)
+	NewBool(reg, "running").Set(true)
+	NewString(reg, "version").Set("7.5.0")
+
+	vs := NewPrometheusVisitor("beat", map[string]string{"name": "host-1", "beat": "test"})
+	expected := `# TYPE beat_beat_handles_open untyped
+beat_beat_handles_open{beat="test",name="host-1"} 12
+# TYPE beat_events_acked untyped
+beat_events_acked{beat="test",name="host-1"} 42
+# TYPE beat_running untyped
+beat_running{beat="test",name="host-1"} 1
+# TYPE beat_system_load_1 untyped
+beat_system_load_1{beat="test",name="host-1"} 0.5
+`
+	assert.Equal(t, expected, collectPrometheus(t, vs, reg))
+}
+
+func TestPrometheusVisitorLabelKey(t *testing.T) {
+	reg := NewRegistry()
+	NewUint(reg, "outputs.es-0.events.acked").Set(1)
+	NewUint(reg, "outputs.es-1.events.acked").Set(2)
+	NewString(reg, "outputs.es-1.type").Set("elasticsearch")
+	NewUint(reg, "outputs.total").Set(3)
+
+	vs := NewPrometheusVisitor("beat", nil)
+	vs.LabelKey("outputs", "output", "output")
+	expected := `# TYPE beat_output_events_acked untyped
+beat_output_events_acked{output="es-0"} 1
+beat_output_events_acked{output="es-1"} 2
+# TYPE beat_outputs_total untyped
+beat_outputs_total 3
+`
+	assert.Equal(t, expected, collectPrometheus(t, vs, reg))
+}
+
+func TestPrometheusVisitorLabelValue(t *testing.T) {
+	reg := NewRegistry()
+	NewUint(reg, "output.events.acked").Set(5)
+	NewString(reg, "output.type").Set("kafka")
+	NewUint(reg, "pipeline.events.total").Set(6)
+
+	vs := NewPrometheusVisitor("", nil)
+	vs.LabelValue("output", "output", "type")
+	expected := `# TYPE output_events_acked untyped
+output_events_acked{output="kafka"} 5
+# TYPE pipeline_events_total untyped
+pipeline_events_total 6
+`
+	assert.Equal(t, expected, collectPrometheus(t, vs, reg))
+}
+
+func TestPrometheusVisitorMultipleRegistries(t *testing.T) {
+	stats := NewRegistry()
+	NewUint(stats, "events").Set(1)
+
+	dataset := NewRegistry()
+	NewUint(dataset, "my-input.events").Set(2)
+
+	vs := NewPrometheusVisitor("beat", nil)
+	vs.LabelKey("dataset", "input", "input")
+	stats.Visit(Full, vs)
+	ReportVar(vs, "dataset", Full, dataset)
+
+	var buf bytes.Buffer
+	_, err := vs.WriteTo(&buf)
+	require.NoError(t, err)
+
+	expected := `# TYPE beat_events untyped
+beat_events 1
+# TYPE beat_input_events untyped
+beat_input_events{input="my-input"} 2
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestPrometheusVisitorEscaping(t *testing.T) {
+	reg := NewRegistry()
+	NewInt(reg, "a-b:c").Set(1)
+
+	vs := NewPrometheusVisitor("beat", map[string]string{"name": "quote\"back\\slash\nnewline"})
+	expected := `# TYPE beat_a_b_c untyped
+beat_a_b_c{name="quote\"back\\slash\nnewline"} 1
+`
+	assert.Equal(t, expected, collectPrometheus(t, vs, reg))
+}
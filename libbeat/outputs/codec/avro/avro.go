@@ -0,0 +1,220 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package avro provides a codec encoding events using the Avro binary
+// encoding. Optionally the schema is managed by a schema registry, in which
+// case events are encoded using the schema registry wire format.
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs/codec"
+	"github.com/elastic/beats/libbeat/outputs/codec/schemaregistry"
+)
+
+// Encoder for serializing a beat.Event using an Avro schema.
+type Encoder struct {
+	version string
+	enc     encoder
+
+	// mu guards the encoding buffer and the resolved schema. connectMu
+	// serializes the requests to the schema registry, it is never held by
+	// Encode.
+	mu        sync.Mutex
+	schema    *schema
+	header    []byte
+	connectMu sync.Mutex
+
+	schemaText string
+	registry   registry
+	subject    string
+}
+
+var errNotConnected = errors.New("avro schema has not been resolved from the schema registry")
+
+// registry is the subset of the schema registry client used by the encoder.
+type registry interface {
+	Register(subject, schema string) (int, error)
+	Latest(subject string) (schemaregistry.Schema, error)
+}
+
+type config struct {
+	Schema     string         `config:"schema"`
+	SchemaFile string         `config:"schema_file"`
+	Registry   *common.Config `config:"schema_registry"`
+}
+
+func (c *config) Validate() error {
+	if c.Schema != "" && c.SchemaFile != "" {
+		return errors.New("schema and schema_file can not be used together")
+	}
+	if c.Schema == "" && c.SchemaFile == "" && c.Registry == nil {
+		return errors.New("one of schema, schema_file or schema_registry must be configured")
+	}
+	return nil
+}
+
+func init() {
+	codec.RegisterType("avro", func(info beat.Info, cfg *common.Config) (codec.Codec, error) {
+		if cfg == nil {
+			return nil, errors.New("avro codec requires a schema")
+		}
+
+		var config config
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+
+		schema := config.Schema
+		if config.SchemaFile != "" {
+			content, err := ioutil.ReadFile(config.SchemaFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read avro schema: %v", err)
+			}
+			schema = string(content)
+		}
+
+		if config.Registry == nil {
+			return New(info.Version, schema)
+		}
+
+		regConfig, err := schemaregistry.ReadConfig(config.Registry)
+		if err != nil {
+			return nil, err
+		}
+		client, err := schemaregistry.NewClient(regConfig)
+		if err != nil {
+			return nil, err
+		}
+		return NewWithRegistry(info.Version, schema, client, regConfig)
+	})
+}
+
+// New creates a new avro Encoder using the given schema.
+func New(version, schema string) (*Encoder, error) {
+	s, err := parseSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &Encoder{version: version, schema: s}, nil
+}
+
+// NewWithRegistry creates a new avro Encoder whose schema is managed by a
+// schema registry. If schema is set, it is registered under the configured
+// subject. If schema is empty, the latest schema registered under the subject
+// is used. The schema is resolved by Connect, when the output connects, such
+// that an unavailable registry fails the connection and is retried.
+func NewWithRegistry(
+	version, schema string,
+	client registry,
+	config schemaregistry.Config,
+) (*Encoder, error) {
+	if schema != "" && !config.AutoRegister {
+		return nil, errors.New("avro schema can not be configured if auto_register is disabled")
+	}
+
+	e := &Encoder{
+		version:    version,
+		schemaText: schema,
+		registry:   client,
+		subject:    config.Subject,
+	}
+	if schema != "" {
+		s, err := parseSchema(schema)
+		if err != nil {
+			return nil, err
+		}
+		e.schema = s
+	}
+	return e, nil
+}
+
+// Encode serializes a beat event using the Avro binary encoding. It adds
+// additional metadata in the `@metadata` namespace, such that the schema can
+// reference it. The returned buffer is reused by the next call to Encode.
+func (e *Encoder) Encode(index string, event *beat.Event) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.registry != nil && e.header == nil {
+		return nil, errNotConnected
+	}
+
+	e.enc.reset()
+	e.enc.buf = append(e.enc.buf, e.header...)
+	if err := e.enc.encode(e.schema, codec.MakeEventValues(index, e.version, event)); err != nil {
+		return nil, err
+	}
+	return e.enc.buf, nil
+}
+
+// Connect resolves the schema from the schema registry if it has not been
+// resolved yet. Events can only be encoded once Connect succeeded.
+func (e *Encoder) Connect() error {
+	if e.registry == nil {
+		return nil
+	}
+
+	e.connectMu.Lock()
+	defer e.connectMu.Unlock()
+
+	e.mu.Lock()
+	resolved := e.header != nil
+	e.mu.Unlock()
+	if resolved {
+		return nil
+	}
+
+	s, header, err := e.resolve()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.schema, e.header = s, header
+	return nil
+}
+
+// resolve registers or fetches the schema from the schema registry. It
+// returns the schema to encode events with and the header of the encoded
+// events.
+func (e *Encoder) resolve() (*schema, []byte, error) {
+	if e.schemaText != "" {
+		id, err := e.registry.Register(e.subject, e.schemaText)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to register avro schema: %v", err)
+		}
+		return e.schema, schemaregistry.WireHeader(id), nil
+	}
+
+	latest, err := e.registry.Latest(e.subject)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch avro schema: %v", err)
+	}
+	s, err := parseSchema(latest.Schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, schemaregistry.WireHeader(latest.ID), nil
+}
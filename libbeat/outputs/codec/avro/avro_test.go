@@ -0,0 +1,211 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package avro
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs/codec"
+	"github.com/elastic/beats/libbeat/outputs/codec/schemaregistry"
+)
+
+const testSchema = `{
+	"type": "record",
+	"name": "Event",
+	"namespace": "beats",
+	"fields": [
+		{"name": "@timestamp", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "message", "type": "string"},
+		{"name": "count", "type": "int"},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "host", "type": ["null", "string"]},
+		{"name": "level", "type": {"type": "enum", "name": "Level", "symbols": ["INFO", "WARN"]}},
+		{"name": "extra", "type": "string", "default": "x"}
+	]
+}`
+
+var testEncoded = []byte{
+	0xd0, 0x0f, // @timestamp: 1000ms
+	0x04, 'h', 'i', // message
+	0x06,                  // count: 3
+	0x02, 0x02, 'a', 0x00, // tags: ["a"]
+	0x00,      // host: null branch
+	0x02,      // level: WARN
+	0x02, 'x', // extra: default value
+}
+
+func testEvent() *beat.Event {
+	return &beat.Event{
+		Timestamp: time.Unix(1, 0),
+		Fields: common.MapStr{
+			"message": "hi",
+			"count":   3,
+			"tags":    []string{"a"},
+			"level":   "WARN",
+		},
+	}
+}
+
+func TestEncode(t *testing.T) {
+	enc, err := New("1.2.3", testSchema)
+	require.NoError(t, err)
+
+	out, err := enc.Encode("test", testEvent())
+	require.NoError(t, err)
+	assert.Equal(t, testEncoded, out)
+}
+
+func TestEncodeTypes(t *testing.T) {
+	cases := map[string]struct {
+		schema   string
+		value    interface{}
+		expected []byte
+	}{
+		"boolean":   {`"boolean"`, true, []byte{0x01}},
+		"negative":  {`"long"`, -2, []byte{0x03}},
+		"float":     {`"float"`, 1.0, []byte{0x00, 0x00, 0x80, 0x3f}},
+		"double":    {`"double"`, 1, []byte{0, 0, 0, 0, 0, 0, 0xf0, 0x3f}},
+		"bytes":     {`"bytes"`, []byte{0xff}, []byte{0x02, 0xff}},
+		"fixed":     {`{"type": "fixed", "name": "f", "size": 2}`, "ab", []byte{'a', 'b'}},
+		"map":       {`{"type": "map", "values": "int"}`, map[string]int{"b": 2, "a": 1}, []byte{0x04, 0x02, 'a', 0x02, 0x02, 'b', 0x04, 0x00}},
+		"union":     {`["null", "int", "string"]`, "s", []byte{0x04, 0x02, 's'}},
+		"timestamp": {`"string"`, common.Time(time.Unix(0, 0).UTC()), append([]byte{0x30}, "1970-01-01T00:00:00.000Z"...)},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := parseSchema(test.schema)
+			require.NoError(t, err)
+
+			var enc encoder
+			require.NoError(t, enc.encode(s, test.value))
+			assert.Equal(t, test.expected, enc.buf)
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	enc, err := New("1.2.3", `{"type": "record", "name": "r", "fields": [{"name": "required", "type": "string"}]}`)
+	require.NoError(t, err)
+
+	_, err = enc.Encode("test", &beat.Event{Fields: common.MapStr{}})
+	assert.EqualError(t, err, "missing required field required in record r")
+
+	enc, err = New("1.2.3", `{"type": "enum", "name": "e", "symbols": ["A"]}`)
+	require.NoError(t, err)
+	_, err = enc.Encode("test", &beat.Event{})
+	assert.Error(t, err)
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	cases := map[string]string{
+		"invalid json":   `{`,
+		"unknown type":   `"unknown"`,
+		"nested union":   `["null", ["int"]]`,
+		"missing fields": `{"type": "record", "name": "r"}`,
+		"duplicate name": `{"type": "record", "name": "r", "fields": [{"name": "a", "type": {"type": "record", "name": "r", "fields": []}}]}`,
+	}
+
+	for name, schema := range cases {
+		_, err := parseSchema(schema)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	var registered, requests int
+	available := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		switch r.Method {
+		case "POST":
+			registered++
+			w.Write([]byte(`{"id":5}`))
+		case "GET":
+			w.Write([]byte(`{"id":6,"version":1,"schema":"{\"type\":\"record\",\"name\":\"r\",\"fields\":[{\"name\":\"message\",\"type\":\"string\"}]}"}`))
+		}
+	}))
+	defer server.Close()
+
+	newEncoder := func(schema string) *Encoder {
+		config, err := schemaregistry.ReadConfig(common.MustNewConfigFrom(map[string]interface{}{
+			"url":     server.URL,
+			"subject": "test-value",
+		}))
+		require.NoError(t, err)
+
+		client, err := schemaregistry.NewClient(config)
+		require.NoError(t, err)
+
+		enc, err := NewWithRegistry("1.2.3", schema, client, config)
+		require.NoError(t, err)
+		return enc
+	}
+
+	t.Run("register schema", func(t *testing.T) {
+		enc := newEncoder(testSchema)
+		require.NoError(t, enc.Connect())
+		require.NoError(t, enc.Connect())
+		for i := 0; i < 2; i++ {
+			out, err := enc.Encode("test", testEvent())
+			require.NoError(t, err)
+			assert.Equal(t, append([]byte{0, 0, 0, 0, 5}, testEncoded...), out)
+		}
+		assert.Equal(t, 1, registered)
+	})
+
+	t.Run("latest schema", func(t *testing.T) {
+		enc := newEncoder("")
+		require.NoError(t, codec.Connect(enc))
+		out, err := enc.Encode("test", &beat.Event{Fields: common.MapStr{"message": "hi"}})
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0, 0, 0, 0, 6, 0x04, 'h', 'i'}, out)
+	})
+
+	t.Run("unavailable registry", func(t *testing.T) {
+		available = false
+		defer func() { available = true }()
+
+		// Events are not encoded until the schema is resolved, without
+		// sending requests to the registry.
+		enc := newEncoder(testSchema)
+		requests = 0
+		_, err := enc.Encode("test", testEvent())
+		assert.Equal(t, errNotConnected, err)
+		assert.Equal(t, 0, requests)
+
+		assert.Error(t, enc.Connect())
+		available = true
+		require.NoError(t, enc.Connect())
+		_, err = enc.Encode("test", testEvent())
+		assert.NoError(t, err)
+	})
+}
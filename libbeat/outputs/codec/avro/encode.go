@@ -0,0 +1,276 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package avro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/elastic/beats/libbeat/outputs/codec"
+)
+
+var errNull = errors.New("value is null")
+
+// encoder writes values in the Avro binary encoding.
+type encoder struct {
+	buf     []byte
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *encoder) reset() {
+	e.buf = e.buf[:0]
+}
+
+// writeLong writes a zig-zag encoded variable length integer, as used by Avro
+// for int and long values, lengths and indices.
+func (e *encoder) writeLong(n int64) {
+	l := binary.PutVarint(e.scratch[:], n)
+	e.buf = append(e.buf, e.scratch[:l]...)
+}
+
+func (e *encoder) writeBytes(b []byte) {
+	e.writeLong(int64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) encode(s *schema, v interface{}) error {
+	if v == nil && s.typ != typeNull && s.typ != typeUnion {
+		return errNull
+	}
+
+	switch s.typ {
+	case typeNull:
+		if v != nil {
+			return fmt.Errorf("expected null, got %T", v)
+		}
+		return nil
+
+	case typeBoolean:
+		b, err := codec.ToBool(v)
+		if err != nil {
+			return err
+		}
+		if b {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+		return nil
+
+	case typeInt:
+		n, err := e.integer(s, v)
+		if err != nil {
+			return err
+		}
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return fmt.Errorf("value %v overflows int", n)
+		}
+		e.writeLong(n)
+		return nil
+
+	case typeLong:
+		n, err := e.integer(s, v)
+		if err != nil {
+			return err
+		}
+		e.writeLong(n)
+		return nil
+
+	case typeFloat:
+		f, err := codec.ToFloat64(v)
+		if err != nil {
+			return err
+		}
+		var tmp [4]byte
+		binary.LittleEndian.PutUint32(tmp[:], math.Float32bits(float32(f)))
+		e.buf = append(e.buf, tmp[:]...)
+		return nil
+
+	case typeDouble:
+		f, err := codec.ToFloat64(v)
+		if err != nil {
+			return err
+		}
+		var tmp [8]byte
+		binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(f))
+		e.buf = append(e.buf, tmp[:]...)
+		return nil
+
+	case typeBytes:
+		switch b := v.(type) {
+		case []byte:
+			e.writeBytes(b)
+		case string:
+			e.writeBytes([]byte(b))
+		default:
+			return fmt.Errorf("can not convert %T to bytes", v)
+		}
+		return nil
+
+	case typeString:
+		str, err := codec.ToString(v)
+		if err != nil {
+			return err
+		}
+		e.writeLong(int64(len(str)))
+		e.buf = append(e.buf, str...)
+		return nil
+
+	case typeFixed:
+		var b []byte
+		switch val := v.(type) {
+		case []byte:
+			b = val
+		case string:
+			b = []byte(val)
+		default:
+			return fmt.Errorf("can not convert %T to fixed %v", v, s.name)
+		}
+		if len(b) != s.size {
+			return fmt.Errorf("fixed %v requires %v bytes, got %v", s.name, s.size, len(b))
+		}
+		e.buf = append(e.buf, b...)
+		return nil
+
+	case typeEnum:
+		str, err := codec.ToString(v)
+		if err != nil {
+			return err
+		}
+		for i, sym := range s.symbols {
+			if sym == str {
+				e.writeLong(int64(i))
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown symbol '%v' for enum %v", str, s.name)
+
+	case typeArray:
+		items, err := codec.ToSlice(v)
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			e.writeLong(int64(len(items)))
+			for i, item := range items {
+				if err := e.encode(s.items, item); err != nil {
+					return fmt.Errorf("array index %v: %v", i, err)
+				}
+			}
+		}
+		e.writeLong(0)
+		return nil
+
+	case typeMap:
+		m, err := codec.ToMap(v)
+		if err != nil {
+			return err
+		}
+		if len(m) > 0 {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			e.writeLong(int64(len(keys)))
+			for _, k := range keys {
+				e.writeBytes([]byte(k))
+				if err := e.encode(s.values, m[k]); err != nil {
+					return fmt.Errorf("map key %v: %v", k, err)
+				}
+			}
+		}
+		e.writeLong(0)
+		return nil
+
+	case typeUnion:
+		return e.encodeUnion(s, v)
+
+	case typeRecord:
+		return e.encodeRecord(s, v)
+	}
+
+	return fmt.Errorf("unsupported avro type %v", s.typ)
+}
+
+// integer converts v to an int64, honoring the date and timestamp logical
+// types when v is a timestamp.
+func (e *encoder) integer(s *schema, v interface{}) (int64, error) {
+	if s.logicalType != "" {
+		if t, err := codec.ToTime(v); err == nil {
+			switch s.logicalType {
+			case "timestamp-millis":
+				return t.UnixNano() / int64(time.Millisecond), nil
+			case "timestamp-micros":
+				return t.UnixNano() / int64(time.Microsecond), nil
+			case "date":
+				return t.Unix() / (24 * 60 * 60), nil
+			}
+		}
+	}
+	return codec.ToInt64(v)
+}
+
+// encodeUnion writes the index of the first branch able to encode v, followed
+// by the encoded value. Null values select the null branch.
+func (e *encoder) encodeUnion(s *schema, v interface{}) error {
+	start := len(e.buf)
+	for i, branch := range s.branches {
+		if (v == nil) != (branch.typ == typeNull) {
+			continue
+		}
+
+		e.writeLong(int64(i))
+		if err := e.encode(branch, v); err == nil {
+			return nil
+		}
+		e.buf = e.buf[:start]
+	}
+
+	if v == nil {
+		return errNull
+	}
+	return fmt.Errorf("value of type %T does not match any type of the union", v)
+}
+
+func (e *encoder) encodeRecord(s *schema, v interface{}) error {
+	values, err := codec.ToMap(v)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range s.fields {
+		val, found := codec.LookupValue(values, f.name)
+		if !found && f.hasDefault {
+			val = f.defaultVal
+		}
+
+		if err := e.encode(f.schema, val); err != nil {
+			if err == errNull && !found {
+				return fmt.Errorf("missing required field %v in record %v", f.name, s.name)
+			}
+			return fmt.Errorf("field %v: %v", f.name, err)
+		}
+	}
+	return nil
+}
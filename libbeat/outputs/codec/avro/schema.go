@@ -0,0 +1,278 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type schemaType uint8
+
+const (
+	typeNull schemaType = iota
+	typeBoolean
+	typeInt
+	typeLong
+	typeFloat
+	typeDouble
+	typeBytes
+	typeString
+	typeRecord
+	typeEnum
+	typeArray
+	typeMap
+	typeUnion
+	typeFixed
+)
+
+var primitiveTypes = map[string]schemaType{
+	"null":    typeNull,
+	"boolean": typeBoolean,
+	"int":     typeInt,
+	"long":    typeLong,
+	"float":   typeFloat,
+	"double":  typeDouble,
+	"bytes":   typeBytes,
+	"string":  typeString,
+}
+
+// schema is a parsed Avro schema.
+type schema struct {
+	typ         schemaType
+	name        string
+	logicalType string
+
+	fields   []field   // record
+	symbols  []string  // enum
+	items    *schema   // array
+	values   *schema   // map
+	branches []*schema // union
+	size     int       // fixed
+}
+
+type field struct {
+	name       string
+	schema     *schema
+	hasDefault bool
+	defaultVal interface{}
+}
+
+// parseSchema parses an Avro schema in its JSON representation.
+func parseSchema(s string) (*schema, error) {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %v", err)
+	}
+
+	p := &schemaParser{named: map[string]*schema{}}
+	return p.parse(raw, "")
+}
+
+type schemaParser struct {
+	named map[string]*schema
+}
+
+func (p *schemaParser) parse(raw interface{}, namespace string) (*schema, error) {
+	switch v := raw.(type) {
+	case string:
+		return p.parseName(v, namespace)
+	case []interface{}:
+		return p.parseUnion(v, namespace)
+	case map[string]interface{}:
+		return p.parseComplex(v, namespace)
+	}
+	return nil, fmt.Errorf("invalid avro schema definition: %v", raw)
+}
+
+func (p *schemaParser) parseName(name, namespace string) (*schema, error) {
+	if t, ok := primitiveTypes[name]; ok {
+		return &schema{typ: t}, nil
+	}
+
+	if s, ok := p.named[fullName(name, namespace)]; ok {
+		return s, nil
+	}
+	if s, ok := p.named[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown avro type: %v", name)
+}
+
+func (p *schemaParser) parseUnion(branches []interface{}, namespace string) (*schema, error) {
+	s := &schema{typ: typeUnion}
+	for _, raw := range branches {
+		branch, err := p.parse(raw, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if branch.typ == typeUnion {
+			return nil, errors.New("avro unions can not contain unions")
+		}
+		s.branches = append(s.branches, branch)
+	}
+	return s, nil
+}
+
+func (p *schemaParser) parseComplex(def map[string]interface{}, namespace string) (*schema, error) {
+	typeName, ok := def["type"].(string)
+	if !ok {
+		// The type itself can be a complex type definition.
+		if inner, exists := def["type"]; exists {
+			return p.parse(inner, namespace)
+		}
+		return nil, errors.New("avro schema definition without type")
+	}
+
+	logicalType, _ := def["logicalType"].(string)
+
+	switch typeName {
+	case "record", "error":
+		return p.parseRecord(def, namespace)
+	case "enum":
+		return p.parseEnum(def, namespace)
+	case "fixed":
+		return p.parseFixed(def, namespace)
+	case "array":
+		items, err := p.parse(def["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{typ: typeArray, items: items}, nil
+	case "map":
+		values, err := p.parse(def["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{typ: typeMap, values: values}, nil
+	}
+
+	s, err := p.parseName(typeName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if logicalType != "" {
+		annotated := *s
+		annotated.logicalType = logicalType
+		return &annotated, nil
+	}
+	return s, nil
+}
+
+func (p *schemaParser) define(def map[string]interface{}, namespace string, s *schema) (string, error) {
+	name, _ := def["name"].(string)
+	if name == "" {
+		return "", errors.New("named avro type without name")
+	}
+
+	if ns, ok := def["namespace"].(string); ok && !strings.Contains(name, ".") {
+		namespace = ns
+	}
+	s.name = fullName(name, namespace)
+	if _, exists := p.named[s.name]; exists {
+		return "", fmt.Errorf("avro type %v defined twice", s.name)
+	}
+	p.named[s.name] = s
+
+	if i := strings.LastIndex(s.name, "."); i >= 0 {
+		return s.name[:i], nil
+	}
+	return "", nil
+}
+
+func (p *schemaParser) parseRecord(def map[string]interface{}, namespace string) (*schema, error) {
+	s := &schema{typ: typeRecord}
+	namespace, err := p.define(def, namespace, s)
+	if err != nil {
+		return nil, err
+	}
+
+	rawFields, ok := def["fields"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("avro record %v without fields", s.name)
+	}
+
+	for _, raw := range rawFields {
+		fieldDef, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid field in avro record %v", s.name)
+		}
+
+		name, _ := fieldDef["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("field without name in avro record %v", s.name)
+		}
+
+		fieldSchema, err := p.parse(fieldDef["type"], namespace)
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %v", name, err)
+		}
+
+		defaultVal, hasDefault := fieldDef["default"]
+		s.fields = append(s.fields, field{
+			name:       name,
+			schema:     fieldSchema,
+			hasDefault: hasDefault,
+			defaultVal: defaultVal,
+		})
+	}
+	return s, nil
+}
+
+func (p *schemaParser) parseEnum(def map[string]interface{}, namespace string) (*schema, error) {
+	s := &schema{typ: typeEnum}
+	if _, err := p.define(def, namespace, s); err != nil {
+		return nil, err
+	}
+
+	symbols, ok := def["symbols"].([]interface{})
+	if !ok || len(symbols) == 0 {
+		return nil, fmt.Errorf("avro enum %v without symbols", s.name)
+	}
+	for _, sym := range symbols {
+		str, ok := sym.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid symbol in avro enum %v", s.name)
+		}
+		s.symbols = append(s.symbols, str)
+	}
+	return s, nil
+}
+
+func (p *schemaParser) parseFixed(def map[string]interface{}, namespace string) (*schema, error) {
+	s := &schema{typ: typeFixed}
+	if _, err := p.define(def, namespace, s); err != nil {
+		return nil, err
+	}
+
+	size, ok := def["size"].(float64)
+	if !ok || size < 0 {
+		return nil, fmt.Errorf("avro fixed %v without valid size", s.name)
+	}
+	s.size = int(size)
+	return s, nil
+}
+
+func fullName(name, namespace string) string {
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
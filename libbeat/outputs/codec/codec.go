@@ -22,3 +22,18 @@ import "github.com/elastic/beats/libbeat/beat"
 type Codec interface {
 	Encode(index string, event *beat.Event) ([]byte, error)
 }
+
+// Connector is implemented by codecs depending on an external service, like a
+// schema registry. Outputs connect the codec when they connect, events can only
+// be encoded once the codec is connected.
+type Connector interface {
+	Connect() error
+}
+
+// Connect connects the codec if it implements Connector.
+func Connect(c Codec) error {
+	if conn, ok := c.(Connector); ok {
+		return conn.Connect()
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ package codec
 import (
 	"time"
 
+	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/dtfmt"
 	"github.com/elastic/go-structform"
@@ -70,3 +71,40 @@ func MakeBCTimestampEncoder() func(*common.Time, structform.ExtVisitor) error {
 		return enc((*time.Time)(t), v)
 	}
 }
+
+// MakeEventValues returns the top-level values of an event as used by schema
+// based codecs. Like the json codec it adds the `@timestamp` and `@metadata`
+// fields to the event fields.
+func MakeEventValues(index, version string, event *beat.Event) common.MapStr {
+	meta := common.MapStr{
+		"beat":    index,
+		"type":    "_doc",
+		"version": version,
+	}
+	for k, v := range event.Meta {
+		meta[k] = v
+	}
+
+	values := make(common.MapStr, len(event.Fields)+2)
+	for k, v := range event.Fields {
+		values[k] = v
+	}
+	values["@timestamp"] = event.Timestamp
+	values["@metadata"] = meta
+	return values
+}
+
+// LookupValue returns the value for the field with the given name. Schema
+// languages don't allow `@` in field names, so the names `timestamp` and
+// `metadata` refer to `@timestamp` and `@metadata` if the event has no field
+// with that name.
+func LookupValue(values map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := values[name]; ok {
+		return v, true
+	}
+	if name == "timestamp" || name == "metadata" {
+		v, ok := values["@"+name]
+		return v, ok
+	}
+	return nil, false
+}
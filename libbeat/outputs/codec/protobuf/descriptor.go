@@ -0,0 +1,183 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protobuf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// message describes a protobuf message type.
+type message struct {
+	name   string
+	fields []*messageField
+	proto3 bool
+	entry  bool // synthetic map entry message
+}
+
+type messageField struct {
+	*descriptor.FieldDescriptorProto
+	message *message         // set for message and map fields
+	enum    map[string]int32 // set for enum fields
+}
+
+// registry indexes all message and enum types found in a set of file
+// descriptors by their fully qualified names.
+type registry struct {
+	messages map[string]*message
+	enums    map[string]map[string]int32
+
+	descriptors map[string]*descriptor.DescriptorProto
+	syntax      map[string]string
+}
+
+// loadDescriptorSet reads a FileDescriptorSet as generated by
+// `protoc --include_imports --descriptor_set_out`.
+func loadDescriptorSet(path string) (*descriptor.FileDescriptorSet, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read protobuf descriptor: %v", err)
+	}
+
+	set := &descriptor.FileDescriptorSet{}
+	if err := proto.Unmarshal(content, set); err != nil {
+		return nil, fmt.Errorf("invalid protobuf descriptor %v: %v", path, err)
+	}
+	return set, nil
+}
+
+// resolveMessage returns the message type with the given name, resolving the
+// types of all fields referenced by it.
+func resolveMessage(set *descriptor.FileDescriptorSet, name string) (*message, error) {
+	r := &registry{
+		messages:    map[string]*message{},
+		enums:       map[string]map[string]int32{},
+		descriptors: map[string]*descriptor.DescriptorProto{},
+		syntax:      map[string]string{},
+	}
+
+	for _, file := range set.File {
+		prefix := ""
+		if pkg := file.GetPackage(); pkg != "" {
+			prefix = "." + pkg
+		}
+		for _, enum := range file.EnumType {
+			r.addEnum(prefix, enum)
+		}
+		for _, msg := range file.MessageType {
+			r.addMessage(prefix, file.GetSyntax(), msg)
+		}
+	}
+
+	if name == "" || name[0] != '.' {
+		name = "." + name
+	}
+	return r.resolve(name)
+}
+
+func (r *registry) addEnum(prefix string, enum *descriptor.EnumDescriptorProto) {
+	values := map[string]int32{}
+	for _, v := range enum.Value {
+		values[v.GetName()] = v.GetNumber()
+	}
+	r.enums[prefix+"."+enum.GetName()] = values
+}
+
+func (r *registry) addMessage(prefix, syntax string, msg *descriptor.DescriptorProto) {
+	name := prefix + "." + msg.GetName()
+	r.descriptors[name] = msg
+	r.syntax[name] = syntax
+
+	for _, enum := range msg.EnumType {
+		r.addEnum(name, enum)
+	}
+	for _, nested := range msg.NestedType {
+		r.addMessage(name, syntax, nested)
+	}
+}
+
+func (r *registry) resolve(name string) (*message, error) {
+	if m, ok := r.messages[name]; ok {
+		return m, nil
+	}
+
+	desc, ok := r.descriptors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown protobuf message type %v", name[1:])
+	}
+
+	m := &message{
+		name:   name[1:],
+		proto3: r.syntax[name] == "proto3",
+		entry:  desc.GetOptions().GetMapEntry(),
+	}
+	// register before resolving the fields, such that recursive types
+	// resolve to the same message.
+	r.messages[name] = m
+
+	for _, fd := range desc.Field {
+		f := &messageField{FieldDescriptorProto: fd}
+		switch fd.GetType() {
+		case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+			nested, err := r.resolve(fd.GetTypeName())
+			if err != nil {
+				return nil, err
+			}
+			f.message = nested
+		case descriptor.FieldDescriptorProto_TYPE_ENUM:
+			enum, ok := r.enums[fd.GetTypeName()]
+			if !ok {
+				return nil, fmt.Errorf("unknown protobuf enum type %v", fd.GetTypeName())
+			}
+			f.enum = enum
+		case descriptor.FieldDescriptorProto_TYPE_GROUP:
+			return nil, fmt.Errorf("field %v of %v: groups are not supported", fd.GetName(), m.name)
+		}
+		m.fields = append(m.fields, f)
+	}
+
+	sort.Slice(m.fields, func(i, j int) bool {
+		return m.fields[i].GetNumber() < m.fields[j].GetNumber()
+	})
+	return m, nil
+}
+
+// isMap reports whether the field is a map field.
+func (f *messageField) isMap() bool {
+	return f.message != nil && f.message.entry && f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED
+}
+
+// isPacked reports whether repeated values of the field are encoded using the
+// packed encoding.
+func (f *messageField) isPacked(proto3 bool) bool {
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_STRING,
+		descriptor.FieldDescriptorProto_TYPE_BYTES,
+		descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		return false
+	}
+
+	if opts := f.GetOptions(); opts != nil && opts.Packed != nil {
+		return opts.GetPacked()
+	}
+	return proto3
+}
@@ -0,0 +1,263 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protobuf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+	"github.com/elastic/beats/libbeat/outputs/codec"
+)
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+const timestampType = "google.protobuf.Timestamp"
+
+func encodeMessage(buf *proto.Buffer, m *message, v interface{}) error {
+	values, err := codec.ToMap(v)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range m.fields {
+		val, found := codec.LookupValue(values, f.GetName())
+		if !found && f.GetJsonName() != "" {
+			val, found = codec.LookupValue(values, f.GetJsonName())
+		}
+		if !found || val == nil {
+			continue
+		}
+
+		if err := encodeField(buf, m, f, val); err != nil {
+			return fmt.Errorf("field %v: %v", f.GetName(), err)
+		}
+	}
+	return nil
+}
+
+func encodeField(buf *proto.Buffer, m *message, f *messageField, v interface{}) error {
+	if f.GetLabel() != descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		return encodeValue(buf, f, v)
+	}
+
+	if f.isMap() {
+		return encodeMap(buf, f, v)
+	}
+
+	items, err := codec.ToSlice(v)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	if !f.isPacked(m.proto3) {
+		for _, item := range items {
+			if err := encodeValue(buf, f, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	packed := proto.NewBuffer(nil)
+	for _, item := range items {
+		if err := encodeScalar(packed, f, item); err != nil {
+			return err
+		}
+	}
+	buf.EncodeVarint(fieldKey(f, wireBytes))
+	return buf.EncodeRawBytes(packed.Bytes())
+}
+
+// encodeMap encodes each key-value pair as map entry message, sorted by key.
+func encodeMap(buf *proto.Buffer, f *messageField, v interface{}) error {
+	m, err := codec.ToMap(v)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if m[k] == nil {
+			continue
+		}
+
+		entry := map[string]interface{}{"key": k, "value": m[k]}
+		if err := encodeValue(buf, f, entry); err != nil {
+			return fmt.Errorf("key %v: %v", k, err)
+		}
+	}
+	return nil
+}
+
+// encodeValue writes the field key followed by a single value.
+func encodeValue(buf *proto.Buffer, f *messageField, v interface{}) error {
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		s, err := codec.ToString(v)
+		if err != nil {
+			return err
+		}
+		buf.EncodeVarint(fieldKey(f, wireBytes))
+		return buf.EncodeStringBytes(s)
+
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		var b []byte
+		switch val := v.(type) {
+		case []byte:
+			b = val
+		case string:
+			b = []byte(val)
+		default:
+			return fmt.Errorf("can not convert %T to bytes", v)
+		}
+		buf.EncodeVarint(fieldKey(f, wireBytes))
+		return buf.EncodeRawBytes(b)
+
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		if f.message.name == timestampType {
+			if t, err := codec.ToTime(v); err == nil {
+				v = map[string]interface{}{
+					"seconds": t.Unix(),
+					"nanos":   t.Nanosecond(),
+				}
+			}
+		}
+
+		nested := proto.NewBuffer(nil)
+		if err := encodeMessage(nested, f.message, v); err != nil {
+			return err
+		}
+		buf.EncodeVarint(fieldKey(f, wireBytes))
+		return buf.EncodeRawBytes(nested.Bytes())
+	}
+
+	buf.EncodeVarint(fieldKey(f, wireType(f)))
+	return encodeScalar(buf, f, v)
+}
+
+// encodeScalar writes a numeric value without field key.
+func encodeScalar(buf *proto.Buffer, f *messageField, v interface{}) error {
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		d, err := codec.ToFloat64(v)
+		if err != nil {
+			return err
+		}
+		return buf.EncodeFixed64(math.Float64bits(d))
+
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		d, err := codec.ToFloat64(v)
+		if err != nil {
+			return err
+		}
+		return buf.EncodeFixed32(uint64(math.Float32bits(float32(d))))
+
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		b, err := codec.ToBool(v)
+		if err != nil {
+			return err
+		}
+		if b {
+			return buf.EncodeVarint(1)
+		}
+		return buf.EncodeVarint(0)
+
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		if name, ok := v.(string); ok {
+			n, found := f.enum[name]
+			if !found {
+				return fmt.Errorf("unknown enum value '%v'", name)
+			}
+			return buf.EncodeVarint(uint64(int64(n)))
+		}
+	}
+
+	n, err := codec.ToInt64(v)
+	if err != nil {
+		return err
+	}
+
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_INT32,
+		descriptor.FieldDescriptorProto_TYPE_SINT32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32,
+		descriptor.FieldDescriptorProto_TYPE_ENUM:
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return fmt.Errorf("value %v overflows int32", n)
+		}
+	case descriptor.FieldDescriptorProto_TYPE_UINT32,
+		descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		if n < 0 || n > math.MaxUint32 {
+			return fmt.Errorf("value %v overflows uint32", n)
+		}
+	case descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		if n < 0 {
+			return fmt.Errorf("negative value %v for unsigned field", n)
+		}
+	}
+
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_SINT32:
+		return buf.EncodeZigzag32(uint64(n))
+	case descriptor.FieldDescriptorProto_TYPE_SINT64:
+		return buf.EncodeZigzag64(uint64(n))
+	case descriptor.FieldDescriptorProto_TYPE_FIXED32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		return buf.EncodeFixed32(uint64(uint32(n)))
+	case descriptor.FieldDescriptorProto_TYPE_FIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		return buf.EncodeFixed64(uint64(n))
+	}
+	return buf.EncodeVarint(uint64(n))
+}
+
+func wireType(f *messageField) uint64 {
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		return wireFixed64
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT,
+		descriptor.FieldDescriptorProto_TYPE_FIXED32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		return wireFixed32
+	}
+	return wireVarint
+}
+
+func fieldKey(f *messageField, wire uint64) uint64 {
+	return uint64(f.GetNumber())<<3 | wire
+}
@@ -0,0 +1,109 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package protobuf provides a codec encoding events as protobuf messages. The
+// message type is read from a descriptor set compiled by protoc.
+package protobuf
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs/codec"
+)
+
+// Encoder for serializing a beat.Event as protobuf message.
+type Encoder struct {
+	version string
+	message *message
+	config  Config
+
+	buf *proto.Buffer
+	out *proto.Buffer
+}
+
+// Config is used to pass encoding parameters to New.
+type Config struct {
+	// Descriptor is the path to a FileDescriptorSet containing the message
+	// type and all its dependencies.
+	Descriptor string `config:"descriptor" validate:"required"`
+
+	// Message is the fully qualified name of the message type.
+	Message string `config:"message" validate:"required"`
+
+	// Delimited prefixes each message with its length as varint.
+	Delimited bool `config:"delimited"`
+}
+
+func init() {
+	codec.RegisterType("protobuf", func(info beat.Info, cfg *common.Config) (codec.Codec, error) {
+		if cfg == nil {
+			return nil, errors.New("protobuf codec requires a descriptor and message")
+		}
+
+		var config Config
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+
+		set, err := loadDescriptorSet(config.Descriptor)
+		if err != nil {
+			return nil, err
+		}
+		return New(info.Version, set, config)
+	})
+}
+
+// New creates a new protobuf Encoder for the message type configured in
+// config. The message type must be defined in set.
+func New(version string, set *descriptor.FileDescriptorSet, config Config) (*Encoder, error) {
+	m, err := resolveMessage(set, config.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Encoder{
+		version: version,
+		message: m,
+		config:  config,
+		buf:     proto.NewBuffer(nil),
+		out:     proto.NewBuffer(nil),
+	}, nil
+}
+
+// Encode serializes a beat event as protobuf message. It adds additional
+// metadata in the `@metadata` namespace, such that the message can reference
+// it. Fields not defined in the message type are ignored. The returned buffer
+// is reused by the next call to Encode.
+func (e *Encoder) Encode(index string, event *beat.Event) ([]byte, error) {
+	e.buf.Reset()
+	if err := encodeMessage(e.buf, e.message, codec.MakeEventValues(index, e.version, event)); err != nil {
+		return nil, err
+	}
+
+	if !e.config.Delimited {
+		return e.buf.Bytes(), nil
+	}
+
+	e.out.Reset()
+	e.out.EncodeRawBytes(e.buf.Bytes())
+	return e.out.Bytes(), nil
+}
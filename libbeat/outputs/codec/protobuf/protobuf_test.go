@@ -0,0 +1,218 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protobuf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs/codec"
+)
+
+func testField(name string, number int32, typ descriptor.FieldDescriptorProto_Type, typeName string) *descriptor.FieldDescriptorProto {
+	f := &descriptor.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   typ.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func repeated(f *descriptor.FieldDescriptorProto) *descriptor.FieldDescriptorProto {
+	f.Label = descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+// testDescriptorSet is equivalent to compiling the following definition with
+// `protoc --include_imports`:
+//
+//	syntax = "proto3";
+//	package test;
+//	import "google/protobuf/timestamp.proto";
+//
+//	message Event {
+//	  enum Level { INFO = 0; WARN = 1; }
+//	  message Host { string name = 1; }
+//
+//	  google.protobuf.Timestamp timestamp = 1;
+//	  string message = 2;
+//	  repeated int32 codes = 3;
+//	  Level level = 4;
+//	  map<string, string> labels = 5;
+//	  sint64 delta = 6;
+//	  Host host = 7;
+//	}
+func testDescriptorSet() *descriptor.FileDescriptorSet {
+	return &descriptor.FileDescriptorSet{
+		File: []*descriptor.FileDescriptorProto{
+			{
+				Name:    proto.String("google/protobuf/timestamp.proto"),
+				Package: proto.String("google.protobuf"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*descriptor.DescriptorProto{{
+					Name: proto.String("Timestamp"),
+					Field: []*descriptor.FieldDescriptorProto{
+						testField("seconds", 1, descriptor.FieldDescriptorProto_TYPE_INT64, ""),
+						testField("nanos", 2, descriptor.FieldDescriptorProto_TYPE_INT32, ""),
+					},
+				}},
+			},
+			{
+				Name:       proto.String("event.proto"),
+				Package:    proto.String("test"),
+				Syntax:     proto.String("proto3"),
+				Dependency: []string{"google/protobuf/timestamp.proto"},
+				MessageType: []*descriptor.DescriptorProto{{
+					Name: proto.String("Event"),
+					Field: []*descriptor.FieldDescriptorProto{
+						testField("timestamp", 1, descriptor.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+						testField("message", 2, descriptor.FieldDescriptorProto_TYPE_STRING, ""),
+						repeated(testField("codes", 3, descriptor.FieldDescriptorProto_TYPE_INT32, "")),
+						testField("level", 4, descriptor.FieldDescriptorProto_TYPE_ENUM, ".test.Event.Level"),
+						repeated(testField("labels", 5, descriptor.FieldDescriptorProto_TYPE_MESSAGE, ".test.Event.LabelsEntry")),
+						testField("delta", 6, descriptor.FieldDescriptorProto_TYPE_SINT64, ""),
+						testField("host", 7, descriptor.FieldDescriptorProto_TYPE_MESSAGE, ".test.Event.Host"),
+					},
+					NestedType: []*descriptor.DescriptorProto{
+						{
+							Name: proto.String("Host"),
+							Field: []*descriptor.FieldDescriptorProto{
+								testField("name", 1, descriptor.FieldDescriptorProto_TYPE_STRING, ""),
+							},
+						},
+						{
+							Name:    proto.String("LabelsEntry"),
+							Options: &descriptor.MessageOptions{MapEntry: proto.Bool(true)},
+							Field: []*descriptor.FieldDescriptorProto{
+								testField("key", 1, descriptor.FieldDescriptorProto_TYPE_STRING, ""),
+								testField("value", 2, descriptor.FieldDescriptorProto_TYPE_STRING, ""),
+							},
+						},
+					},
+					EnumType: []*descriptor.EnumDescriptorProto{{
+						Name: proto.String("Level"),
+						Value: []*descriptor.EnumValueDescriptorProto{
+							{Name: proto.String("INFO"), Number: proto.Int32(0)},
+							{Name: proto.String("WARN"), Number: proto.Int32(1)},
+						},
+					}},
+				}},
+			},
+		},
+	}
+}
+
+var testEncoded = []byte{
+	0x0a, 0x04, 0x08, 0x01, 0x10, 0x05, // timestamp
+	0x12, 0x02, 'h', 'i', // message
+	0x1a, 0x02, 0x01, 0x02, // codes, packed
+	0x20, 0x01, // level
+	0x2a, 0x06, 0x0a, 0x01, 'a', 0x12, 0x01, 'b', // labels
+	0x30, 0x01, // delta
+	0x3a, 0x03, 0x0a, 0x01, 'h', // host
+}
+
+func testEvent() *beat.Event {
+	return &beat.Event{
+		Timestamp: time.Unix(1, 5),
+		Fields: common.MapStr{
+			"message": "hi",
+			"codes":   []int{1, 2},
+			"level":   "WARN",
+			"labels":  common.MapStr{"a": "b"},
+			"delta":   -1,
+			"host":    common.MapStr{"name": "h"},
+			"ignored": true,
+		},
+	}
+}
+
+func TestEncode(t *testing.T) {
+	enc, err := New("1.2.3", testDescriptorSet(), Config{Message: "test.Event"})
+	require.NoError(t, err)
+
+	out, err := enc.Encode("test", testEvent())
+	require.NoError(t, err)
+	assert.Equal(t, testEncoded, out)
+}
+
+func TestEncodeDelimited(t *testing.T) {
+	enc, err := New("1.2.3", testDescriptorSet(), Config{Message: "test.Event", Delimited: true})
+	require.NoError(t, err)
+
+	out, err := enc.Encode("test", testEvent())
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{byte(len(testEncoded))}, testEncoded...), out)
+}
+
+func TestEncodeErrors(t *testing.T) {
+	enc, err := New("1.2.3", testDescriptorSet(), Config{Message: "test.Event"})
+	require.NoError(t, err)
+
+	_, err = enc.Encode("test", &beat.Event{Fields: common.MapStr{"level": "DEBUG"}})
+	assert.EqualError(t, err, "field level: unknown enum value 'DEBUG'")
+
+	_, err = enc.Encode("test", &beat.Event{Fields: common.MapStr{"codes": []interface{}{"x"}}})
+	assert.Error(t, err)
+}
+
+func TestUnknownMessage(t *testing.T) {
+	_, err := New("1.2.3", testDescriptorSet(), Config{Message: "test.Unknown"})
+	assert.EqualError(t, err, "unknown protobuf message type test.Unknown")
+}
+
+func TestCodecFromDescriptorFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "protobuf-codec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	content, err := proto.Marshal(testDescriptorSet())
+	require.NoError(t, err)
+	path := filepath.Join(dir, "event.desc")
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+
+	var config codec.Config
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"protobuf": map[string]interface{}{
+			"descriptor": path,
+			"message":    "test.Event",
+		},
+	})
+	require.NoError(t, cfg.Unpack(&config))
+
+	enc, err := codec.CreateEncoder(beat.Info{Version: "1.2.3"}, config)
+	require.NoError(t, err)
+
+	out, err := enc.Encode("test", testEvent())
+	require.NoError(t, err)
+	assert.Equal(t, testEncoded, out)
+}
@@ -0,0 +1,194 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package schemaregistry provides a client for Confluent compatible schema
+// registries and helpers for the schema registry wire format.
+package schemaregistry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// magicByte is the first byte of every message encoded using the schema
+// registry wire format.
+const magicByte = 0
+
+// Config holds the settings for connecting to a schema registry.
+type Config struct {
+	URL          string            `config:"url" validate:"required"`
+	Subject      string            `config:"subject" validate:"required"`
+	Username     string            `config:"username"`
+	Password     string            `config:"password"`
+	AutoRegister bool              `config:"auto_register"`
+	TLS          *tlscommon.Config `config:"ssl"`
+	Timeout      time.Duration     `config:"timeout" validate:"min=1"`
+}
+
+var defaultConfig = Config{
+	AutoRegister: true,
+	Timeout:      30 * time.Second,
+}
+
+// Client talks to the REST API of a schema registry.
+type Client struct {
+	url      string
+	username string
+	password string
+	http     *http.Client
+}
+
+// Schema is a schema stored in the schema registry.
+type Schema struct {
+	ID      int    `json:"id"`
+	Version int    `json:"version"`
+	Schema  string `json:"schema"`
+}
+
+// ReadConfig unpacks the schema registry settings applying the defaults.
+func ReadConfig(cfg *common.Config) (Config, error) {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// NewClient creates a new schema registry client.
+func NewClient(config Config) (*Client, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema registry URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported schema registry URL scheme: %v", u.Scheme)
+	}
+
+	tls, err := tlscommon.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := transport.NetDialer(config.Timeout)
+	tlsDialer, err := transport.TLSDialer(dialer, tls, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		url:      strings.TrimSuffix(u.String(), "/"),
+		username: config.Username,
+		password: config.Password,
+		http: &http.Client{
+			Transport: &http.Transport{
+				Dial:    dialer.Dial,
+				DialTLS: tlsDialer.Dial,
+				Proxy:   http.ProxyFromEnvironment,
+			},
+			Timeout: config.Timeout,
+		},
+	}, nil
+}
+
+// Register registers the schema under the subject and returns its ID. If the
+// schema is already registered, the ID of the existing schema is returned.
+func (c *Client) Register(subject, schema string) (int, error) {
+	var resp Schema
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := c.do("POST", path, map[string]string{"schema": schema}, &resp); err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+// Latest returns the latest version of the schema registered under the
+// subject.
+func (c *Client) Latest(subject string) (Schema, error) {
+	var resp Schema
+	path := "/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	err := c.do("GET", path, nil, &resp)
+	return resp, err
+}
+
+func (c *Client) do(method, path string, body, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.url+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var regErr struct {
+			Code    int    `json:"error_code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &regErr) == nil && regErr.Message != "" {
+			return fmt.Errorf("schema registry error %v: %v", regErr.Code, regErr.Message)
+		}
+		return fmt.Errorf("schema registry returned %v", resp.Status)
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// WireHeader returns the header prepended to messages encoded using the schema
+// registry wire format: a magic byte followed by the schema ID as 4-byte big
+// endian integer.
+func WireHeader(id int) []byte {
+	header := make([]byte, 5)
+	header[0] = magicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(id))
+	return header
+}
@@ -0,0 +1,101 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package schemaregistry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func newTestClient(t *testing.T, url string) *Client {
+	config, err := ReadConfig(common.MustNewConfigFrom(map[string]interface{}{
+		"url":      url,
+		"subject":  "test-value",
+		"username": "user",
+		"password": "secret",
+	}))
+	require.NoError(t, err)
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	return client
+}
+
+func TestRegister(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/subjects/test-value/versions", r.URL.Path)
+		assert.Equal(t, contentType, r.Header.Get("Content-Type"))
+
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "secret", pass)
+
+		body, _ := ioutil.ReadAll(r.Body)
+		var req map[string]string
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, `"string"`, req["schema"])
+
+		w.Write([]byte(`{"id":42}`))
+	}))
+	defer server.Close()
+
+	id, err := newTestClient(t, server.URL).Register("test-value", `"string"`)
+	require.NoError(t, err)
+	assert.Equal(t, 42, id)
+}
+
+func TestLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/subjects/test-value/versions/latest", r.URL.Path)
+		w.Write([]byte(`{"subject":"test-value","id":7,"version":3,"schema":"\"string\""}`))
+	}))
+	defer server.Close()
+
+	schema, err := newTestClient(t, server.URL).Latest("test-value")
+	require.NoError(t, err)
+	assert.Equal(t, Schema{ID: 7, Version: 3, Schema: `"string"`}, schema)
+}
+
+func TestRegistryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code":40401,"message":"Subject not found."}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server.URL).Latest("test-value")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Subject not found.")
+}
+
+func TestWireHeader(t *testing.T) {
+	assert.Equal(t, []byte{0, 0, 0, 1, 2}, WireHeader(258))
+}
@@ -0,0 +1,169 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package codec
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// The conversion functions in this file are used by schema based codecs to
+// convert event values to the types required by the schema.
+
+// ToInt64 converts numeric values and numeric strings to int64.
+func ToInt64(v interface{}) (int64, error) {
+	switch val := v.(type) {
+	case string:
+		return strconv.ParseInt(val, 10, 64)
+	case bool:
+		if val {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != float64(int64(f)) {
+			return 0, fmt.Errorf("value %v is not an integer", f)
+		}
+		return int64(f), nil
+	}
+	return 0, fmt.Errorf("can not convert %T to an integer", v)
+}
+
+// ToFloat64 converts numeric values and numeric strings to float64.
+func ToFloat64(v interface{}) (float64, error) {
+	if s, ok := v.(string); ok {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+	return 0, fmt.Errorf("can not convert %T to a number", v)
+}
+
+// ToBool converts booleans and boolean strings to bool.
+func ToBool(v interface{}) (bool, error) {
+	switch val := v.(type) {
+	case bool:
+		return val, nil
+	case string:
+		return strconv.ParseBool(val)
+	}
+	return false, fmt.Errorf("can not convert %T to a boolean", v)
+}
+
+// ToString converts strings, timestamps and numbers to string. Timestamps are
+// formatted like the json codec does.
+func ToString(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case time.Time:
+		return common.Time(val).String(), nil
+	case common.Time:
+		return val.String(), nil
+	case fmt.Stringer:
+		return val.String(), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	}
+	return "", fmt.Errorf("can not convert %T to a string", v)
+}
+
+// ToTime converts timestamps and RFC3339 formatted strings to time.Time.
+func ToTime(v interface{}) (time.Time, error) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, nil
+	case common.Time:
+		return time.Time(val), nil
+	case string:
+		return time.Parse(time.RFC3339Nano, val)
+	}
+	return time.Time{}, fmt.Errorf("can not convert %T to a timestamp", v)
+}
+
+// ToMap converts objects with string keys to a map.
+func ToMap(v interface{}) (map[string]interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return val, nil
+	case common.MapStr:
+		return val, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("can not convert %T to an object", v)
+	}
+
+	m := make(map[string]interface{}, rv.Len())
+	for _, key := range rv.MapKeys() {
+		m[key.String()] = rv.MapIndex(key).Interface()
+	}
+	return m, nil
+}
+
+// ToSlice converts arrays and slices to a slice of values.
+func ToSlice(v interface{}) ([]interface{}, error) {
+	if s, ok := v.([]interface{}); ok {
+		return s, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("can not convert %T to an array", v)
+	}
+
+	s := make([]interface{}, rv.Len())
+	for i := range s {
+		s[i] = rv.Index(i).Interface()
+	}
+	return s, nil
+}
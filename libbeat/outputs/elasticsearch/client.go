@@ -60,6 +60,9 @@ type Client struct {
 	proxyURL         *url.URL
 
 	observer outputs.Observer
+
+	// optional destination for events rejected by Elasticsearch
+	deadLetter deadLetterQueue
 }
 
 // ClientSettings contains the settings for a client.
@@ -355,24 +358,31 @@ func (client *Client) publishEvents(
 
 	// check response for transient errors
 	var failedEvents []publisher.Event
+	var rejectedEvents []rejectedEvent
 	var stats bulkResultStats
 	if status != 200 {
 		failedEvents = data
 		stats.fails = len(failedEvents)
 	} else {
 		client.json.init(result.raw)
-		failedEvents, stats = bulkCollectPublishFails(&client.json, data)
+		failedEvents, rejectedEvents, stats = bulkCollectPublishFails(&client.json, data)
+	}
+
+	deadLetter := 0
+	if client.deadLetter != nil && len(rejectedEvents) > 0 {
+		deadLetter = client.deadLetter.store(client, rejectedEvents)
 	}
 
 	failed := len(failedEvents)
 	if st := client.observer; st != nil {
-		dropped := stats.nonIndexable
+		dropped := stats.nonIndexable - deadLetter
 		duplicates := stats.duplicates
-		acked := len(data) - failed - dropped - duplicates
+		acked := len(data) - failed - stats.nonIndexable - duplicates
 
 		st.Acked(acked)
 		st.Failed(failed)
 		st.Dropped(dropped)
+		st.DeadLetter(deadLetter)
 		st.Duplicate(duplicates)
 		st.ErrTooMany(stats.tooMany)
 	}
@@ -476,14 +486,15 @@ func getPipeline(event *beat.Event, pipelineSel *outil.Selector) (string, error)
 // bulkCollectPublishFails checks per item errors returning all events
 // to be tried again due to error code returned for that items. If indexing an
 // event failed due to some error in the event itself (e.g. does not respect mapping),
-// the event will be dropped.
+// the event will be dropped. Dropped events are returned separately together
+// with the reported error, so they can be stored in a dead letter queue.
 func bulkCollectPublishFails(
 	reader *jsonReader,
 	data []publisher.Event,
-) ([]publisher.Event, bulkResultStats) {
+) ([]publisher.Event, []rejectedEvent, bulkResultStats) {
 	if err := reader.expectDict(); err != nil {
 		logp.Err("Failed to parse bulk response: expected JSON object")
-		return nil, bulkResultStats{}
+		return nil, nil, bulkResultStats{}
 	}
 
 	// find 'items' field in response
@@ -491,12 +502,12 @@ func bulkCollectPublishFails(
 		kind, name, err := reader.nextFieldName()
 		if err != nil {
 			logp.Err("Failed to parse bulk response")
-			return nil, bulkResultStats{}
+			return nil, nil, bulkResultStats{}
 		}
 
 		if kind == dictEnd {
 			logp.Err("Failed to parse bulk response: no 'items' field in response")
-			return nil, bulkResultStats{}
+			return nil, nil, bulkResultStats{}
 		}
 
 		// found items array -> continue
@@ -510,16 +521,17 @@ func bulkCollectPublishFails(
 	// check items field is an array
 	if err := reader.expectArray(); err != nil {
 		logp.Err("Failed to parse bulk response: expected items array")
-		return nil, bulkResultStats{}
+		return nil, nil, bulkResultStats{}
 	}
 
 	count := len(data)
 	failed := data[:0]
+	var rejected []rejectedEvent
 	stats := bulkResultStats{}
 	for i := 0; i < count; i++ {
 		status, msg, err := itemStatus(reader)
 		if err != nil {
-			return nil, bulkResultStats{}
+			return nil, nil, bulkResultStats{}
 		}
 
 		if status < 300 {
@@ -541,6 +553,11 @@ func bulkCollectPublishFails(
 				// hard failure, don't collect
 				logp.Warn("Cannot index event %#v (status=%v): %s", data[i], status, msg)
 				stats.nonIndexable++
+				rejected = append(rejected, rejectedEvent{
+					event:  data[i],
+					status: status,
+					reason: append([]byte(nil), msg...),
+				})
 				continue
 			}
 		}
@@ -550,7 +567,7 @@ func bulkCollectPublishFails(
 		failed = append(failed, data[i])
 	}
 
-	return failed, stats
+	return failed, rejected, stats
 }
 
 func itemStatus(reader *jsonReader) (int, []byte, error) {
@@ -698,6 +715,16 @@ func (client *Client) Connect() error {
 	return client.Connection.Connect()
 }
 
+// Close closes the connection and the dead letter queue of the client.
+func (client *Client) Close() error {
+	if client.deadLetter != nil {
+		if err := client.deadLetter.Close(); err != nil {
+			logp.Err("Failed to close the dead letter queue: %v", err)
+		}
+	}
+	return client.Connection.Close()
+}
+
 // Connect connects the client. It runs a GET request against the root URL of
 // the configured host, updates the known Elasticsearch version and calls
 // globally configured handlers.
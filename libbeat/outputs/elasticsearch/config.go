@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
 )
 
@@ -42,6 +43,7 @@ type elasticsearchConfig struct {
 	MaxRetries       int               `config:"max_retries"`
 	Timeout          time.Duration     `config:"timeout"`
 	Backoff          Backoff           `config:"backoff"`
+	DeadLetter       *common.Config    `config:"dead_letter"`
 }
 
 type Backoff struct {
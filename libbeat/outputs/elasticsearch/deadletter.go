@@ -0,0 +1,294 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/file"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/publisher"
+)
+
+// deadLetterQueue stores events that have been rejected by Elasticsearch with
+// a non-retryable error (e.g. mapping conflicts or ingest pipeline failures),
+// so they can be inspected and replayed later instead of being dropped.
+type deadLetterQueue interface {
+	// store writes the rejected events to the dead letter destination and
+	// returns the number of events stored successfully.
+	store(client *Client, events []rejectedEvent) int
+
+	// Close releases the resources held by the dead letter queue. The queue
+	// is shared by all clients of the output and Close is called whenever a
+	// client is closed, so storing events after Close must still succeed.
+	Close() error
+}
+
+// rejectedEvent is an event Elasticsearch refused to index, together with the
+// item status and error returned in the bulk response.
+type rejectedEvent struct {
+	event  publisher.Event
+	status int
+	reason []byte
+}
+
+type deadLetterConfig struct {
+	File  *common.Config            `config:"file"`
+	Index *fmtstr.EventFormatString `config:"index"`
+}
+
+type deadLetterFileConfig struct {
+	Path          string `config:"path" validate:"required"`
+	Filename      string `config:"filename"`
+	RotateEveryKb uint   `config:"rotate_every_kb" validate:"min=1"`
+	NumberOfFiles uint   `config:"number_of_files"`
+	Permissions   uint32 `config:"permissions"`
+}
+
+var defaultDeadLetterFileConfig = deadLetterFileConfig{
+	NumberOfFiles: 7,
+	RotateEveryKb: 10 * 1024,
+	Permissions:   0600,
+}
+
+func (c *deadLetterConfig) Validate() error {
+	if c.File != nil && c.Index != nil {
+		return errors.New("dead_letter can either be a file or an index, not both")
+	}
+	if c.File == nil && c.Index == nil {
+		return errors.New("dead_letter requires either file or index to be configured")
+	}
+	return nil
+}
+
+func (c *deadLetterFileConfig) Validate() error {
+	if c.NumberOfFiles < 2 || c.NumberOfFiles > file.MaxBackupsLimit {
+		return fmt.Errorf("The number_of_files to keep should be between 2 and %v",
+			file.MaxBackupsLimit)
+	}
+	return nil
+}
+
+// newDeadLetterQueue creates the dead letter destination configured in the
+// `dead_letter` section of the output. It returns nil if the section is not
+// configured or disabled.
+func newDeadLetterQueue(info beat.Info, cfg *common.Config) (deadLetterQueue, error) {
+	if cfg == nil || !cfg.Enabled() {
+		return nil, nil
+	}
+
+	var config deadLetterConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	if config.Index != nil {
+		logp.Info("Dead letter queue enabled, rejected events are indexed into a separate index")
+		return &indexDeadLetterQueue{index: config.Index}, nil
+	}
+
+	fileConfig := defaultDeadLetterFileConfig
+	if err := config.File.Unpack(&fileConfig); err != nil {
+		return nil, err
+	}
+	return newFileDeadLetterQueue(info, fileConfig)
+}
+
+// makeDeadLetterEvent wraps a rejected event into a new event, holding the
+// original event serialized as JSON and the error reported by Elasticsearch.
+// The original event is not indexed as is, as it would most likely be rejected
+// again for the same reason.
+func makeDeadLetterEvent(enc *jsonEncoder, r *rejectedEvent) (beat.Event, error) {
+	if err := enc.Marshal(&r.event.Content); err != nil {
+		return beat.Event{}, err
+	}
+	original := string(bytes.TrimRight(enc.buf.Bytes(), "\n"))
+
+	errFields := common.MapStr{}
+	var reason struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(r.reason, &reason); err == nil && reason.Reason != "" {
+		errFields["type"] = reason.Type
+		errFields["message"] = reason.Reason
+	} else {
+		errFields["message"] = string(r.reason)
+	}
+
+	return beat.Event{
+		Timestamp: time.Now(),
+		Fields: common.MapStr{
+			"event": common.MapStr{
+				"original": original,
+			},
+			"error": errFields,
+			"http": common.MapStr{
+				"response": common.MapStr{
+					"status_code": r.status,
+				},
+			},
+		},
+	}, nil
+}
+
+// fileDeadLetterQueue writes rejected events as JSON lines into a rotating
+// file. The queue is shared by all clients of the output.
+type fileDeadLetterQueue struct {
+	rotator *file.Rotator
+
+	mu  sync.Mutex
+	enc *jsonEncoder
+}
+
+func newFileDeadLetterQueue(info beat.Info, c deadLetterFileConfig) (*fileDeadLetterQueue, error) {
+	filename := c.Filename
+	if filename == "" {
+		filename = info.Beat + "-dead-letter"
+	}
+	path := filepath.Join(c.Path, filename)
+
+	rotator, err := file.NewFileRotator(
+		path,
+		file.MaxSizeBytes(c.RotateEveryKb*1024),
+		file.MaxBackups(c.NumberOfFiles),
+		file.Permissions(os.FileMode(c.Permissions)),
+		file.RotateOnStartup(false),
+		file.WithLogger(logp.NewLogger("rotator").With(logp.Namespace("rotator"))),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	logp.Info("Dead letter queue enabled, rejected events are written to %v", path)
+
+	return &fileDeadLetterQueue{
+		rotator: rotator,
+		enc:     newJSONEncoder(nil, false),
+	}, nil
+}
+
+func (q *fileDeadLetterQueue) store(_ *Client, events []rejectedEvent) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stored := 0
+	for i := range events {
+		event, err := makeDeadLetterEvent(q.enc, &events[i])
+		if err != nil {
+			logp.Err("Failed to encode rejected event for the dead letter queue: %v", err)
+			continue
+		}
+
+		if err := q.enc.Marshal(&event); err != nil {
+			logp.Err("Failed to encode dead letter event: %v", err)
+			continue
+		}
+
+		if _, err := q.rotator.Write(q.enc.buf.Bytes()); err != nil {
+			logp.Err("Writing event to the dead letter queue failed with: %v", err)
+			continue
+		}
+		stored++
+	}
+	return stored
+}
+
+// Close closes the current file. The file is opened again in append mode if
+// more events are stored.
+func (q *fileDeadLetterQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.rotator.Close()
+}
+
+// indexDeadLetterQueue indexes rejected events into a separate index, using
+// the connection of the client the events have been rejected by.
+type indexDeadLetterQueue struct {
+	index *fmtstr.EventFormatString
+}
+
+func (q *indexDeadLetterQueue) store(client *Client, events []rejectedEvent) int {
+	body := client.encoder
+	body.Reset()
+
+	enc := newJSONEncoder(nil, false)
+	data := make([]publisher.Event, 0, len(events))
+	for i := range events {
+		event, err := makeDeadLetterEvent(enc, &events[i])
+		if err != nil {
+			logp.Err("Failed to encode rejected event for the dead letter queue: %v", err)
+			continue
+		}
+
+		index, err := q.index.Run(&events[i].event.Content)
+		if err != nil {
+			logp.Err("Failed to select dead letter index: %v", err)
+			continue
+		}
+
+		meta := bulkEventMeta{Index: index}
+		if client.GetVersion().Major < 7 {
+			meta.DocType = defaultEventType
+		}
+		if err := body.Add(bulkIndexAction{meta}, &event); err != nil {
+			logp.Err("Failed to encode dead letter event: %v", err)
+			continue
+		}
+		data = append(data, publisher.Event{Content: event})
+	}
+
+	if len(data) == 0 {
+		return 0
+	}
+
+	requ := client.bulkRequ
+	requ.Reset(body)
+	status, result, err := client.sendBulkRequest(requ)
+	if err != nil {
+		logp.Err("Failed to index events into the dead letter index: %v", err)
+		return 0
+	}
+	if status != 200 {
+		return 0
+	}
+
+	client.json.init(result.raw)
+	failed, _, stats := bulkCollectPublishFails(&client.json, data)
+	if len(failed) > 0 || stats.nonIndexable > 0 {
+		logp.Err("Failed to index %v events into the dead letter index",
+			len(failed)+stats.nonIndexable)
+	}
+	return stats.acked + stats.duplicates
+}
+
+// Close does nothing, the events are indexed using the connection of the
+// client.
+func (q *indexDeadLetterQueue) Close() error {
+	return nil
+}
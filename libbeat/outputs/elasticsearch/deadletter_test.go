@@ -0,0 +1,227 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package elasticsearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs/outest"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+)
+
+const rejectingBulkResponse = `{"items": [
+  {"create": {"status": 201}},
+  {"create": {"status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse field [count]"}}}
+]}`
+
+func newRejectingServer(t *testing.T, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		*requests = append(*requests, string(body))
+
+		if strings.HasPrefix(string(body), `{"index":{"_index":"dead-letter"`) {
+			w.Write([]byte(`{"items": [{"index": {"status": 201}}]}`))
+			return
+		}
+		w.Write([]byte(rejectingBulkResponse))
+	}))
+}
+
+func publishRejected(t *testing.T, url string, dlq deadLetterQueue) *outest.Batch {
+	client, err := NewClient(ClientSettings{
+		URL:   url,
+		Index: outil.MakeSelector(outil.ConstSelectorExpr("test")),
+	}, nil)
+	require.NoError(t, err)
+	client.deadLetter = dlq
+
+	batch := outest.NewBatch(
+		beat.Event{Fields: common.MapStr{"count": 1}},
+		beat.Event{Fields: common.MapStr{"count": "many"}},
+	)
+	err = client.Publish(batch)
+	require.NoError(t, err)
+	return batch
+}
+
+func TestDeadLetterFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dead-letter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var requests []string
+	server := newRejectingServer(t, &requests)
+	defer server.Close()
+
+	dlq, err := newDeadLetterQueue(beat.Info{Beat: "testbeat"}, common.MustNewConfigFrom(common.MapStr{
+		"file.path": dir,
+	}))
+	require.NoError(t, err)
+
+	batch := publishRejected(t, server.URL, dlq)
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+
+	f, err := os.Open(filepath.Join(dir, "testbeat-dead-letter"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines []common.MapStr
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var m common.MapStr
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &m))
+		lines = append(lines, m)
+	}
+	require.Len(t, lines, 1)
+
+	record := lines[0]
+	original, _ := record.GetValue("event.original")
+	assert.Contains(t, original, `"count":"many"`)
+	errType, _ := record.GetValue("error.type")
+	assert.Equal(t, "mapper_parsing_exception", errType)
+	errMsg, _ := record.GetValue("error.message")
+	assert.Equal(t, "failed to parse field [count]", errMsg)
+	status, _ := record.GetValue("http.response.status_code")
+	assert.Equal(t, float64(400), status)
+}
+
+type closeRecorder struct {
+	deadLetterQueue
+	closed int
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed++
+	return r.deadLetterQueue.Close()
+}
+
+func TestDeadLetterClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dead-letter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var requests []string
+	server := newRejectingServer(t, &requests)
+	defer server.Close()
+
+	dlq, err := newDeadLetterQueue(beat.Info{Beat: "testbeat"}, common.MustNewConfigFrom(common.MapStr{
+		"file.path": dir,
+	}))
+	require.NoError(t, err)
+	recorder := &closeRecorder{deadLetterQueue: dlq}
+
+	client, err := NewClient(ClientSettings{URL: server.URL}, nil)
+	require.NoError(t, err)
+	client.deadLetter = recorder
+	require.NoError(t, client.Close())
+	assert.Equal(t, 1, recorder.closed)
+
+	// events stored after Close are appended to the file
+	publishRejected(t, server.URL, dlq)
+	require.NoError(t, dlq.Close())
+	publishRejected(t, server.URL, dlq)
+	require.NoError(t, dlq.Close())
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "testbeat-dead-letter"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(content), "\n"))
+}
+
+func TestDeadLetterIndex(t *testing.T) {
+	var requests []string
+	server := newRejectingServer(t, &requests)
+	defer server.Close()
+
+	dlq, err := newDeadLetterQueue(beat.Info{}, common.MustNewConfigFrom(common.MapStr{
+		"index": "dead-letter",
+	}))
+	require.NoError(t, err)
+
+	batch := publishRejected(t, server.URL, dlq)
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+
+	require.Len(t, requests, 2)
+	lines := strings.Split(strings.TrimSpace(requests[1]), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `{"index":{"_index":"dead-letter"`)
+	assert.Contains(t, lines[1], `"status_code":400`)
+}
+
+func TestDeadLetterConfig(t *testing.T) {
+	tests := map[string]struct {
+		config   common.MapStr
+		enabled  bool
+		hasError bool
+	}{
+		"not configured": {},
+		"disabled": {
+			config: common.MapStr{"enabled": false, "index": "dead-letter"},
+		},
+		"index": {
+			config:  common.MapStr{"index": "dead-letter"},
+			enabled: true,
+		},
+		"file and index": {
+			config:   common.MapStr{"index": "dead-letter", "file.path": "/tmp"},
+			hasError: true,
+		},
+		"no destination": {
+			config:   common.MapStr{"enabled": true},
+			hasError: true,
+		},
+		"file without path": {
+			config:   common.MapStr{"file.filename": "dlq"},
+			hasError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var cfg *common.Config
+			if test.config != nil {
+				cfg = common.MustNewConfigFrom(test.config)
+			}
+
+			dlq, err := newDeadLetterQueue(beat.Info{}, cfg)
+			if test.hasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.enabled, dlq != nil)
+		})
+	}
+}
@@ -180,6 +180,11 @@ func makeES(
 		params = nil
 	}
 
+	deadLetter, err := newDeadLetterQueue(beat, config.DeadLetter)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
 	clients := make([]outputs.NetworkClient, len(hosts))
 	for i, host := range hosts {
 		esURL, err := common.MakeURL(config.Protocol, config.Path, host, 9200)
@@ -188,8 +193,7 @@ func makeES(
 			return outputs.Fail(err)
 		}
 
-		var client outputs.NetworkClient
-		client, err = NewClient(ClientSettings{
+		esClient, err := NewClient(ClientSettings{
 			URL:              esURL,
 			Index:            index,
 			Pipeline:         pipeline,
@@ -209,9 +213,9 @@ func makeES(
 		if err != nil {
 			return outputs.Fail(err)
 		}
+		esClient.deadLetter = deadLetter
 
-		client = outputs.WithBackoff(client, config.Backoff.Init, config.Backoff.Max)
-		clients[i] = client
+		clients[i] = outputs.WithBackoff(esClient, config.Backoff.Init, config.Backoff.Max)
 	}
 
 	return outputs.SuccessNet(config.LoadBalance, config.BulkMaxSize, config.MaxRetries, clients)
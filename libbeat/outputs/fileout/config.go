@@ -18,19 +18,24 @@
 package fileout
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/elastic/beats/libbeat/common/file"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
 	"github.com/elastic/beats/libbeat/outputs/codec"
 )
 
 type config struct {
-	Path          string       `config:"path"`
-	Filename      string       `config:"filename"`
-	RotateEveryKb uint         `config:"rotate_every_kb" validate:"min=1"`
-	NumberOfFiles uint         `config:"number_of_files"`
-	Codec         codec.Config `config:"codec"`
-	Permissions   uint32       `config:"permissions"`
+	Path           string        `config:"path"`
+	Filename       string        `config:"filename"`
+	RotateEveryKb  uint          `config:"rotate_every_kb" validate:"min=1"`
+	NumberOfFiles  uint          `config:"number_of_files"`
+	Codec          codec.Config  `config:"codec"`
+	Permissions    uint32        `config:"permissions"`
+	RotateInterval time.Duration `config:"rotate_interval"`
+	Compress       bool          `config:"compress"`
 }
 
 var (
@@ -47,5 +52,19 @@ func (c *config) Validate() error {
 			file.MaxBackupsLimit)
 	}
 
+	if c.RotateInterval != 0 && c.RotateInterval < time.Second {
+		return errors.New("The rotate_interval must be at least 1s")
+	}
+
+	if c.Filename != "" {
+		fs, err := fmtstr.CompileEvent(c.Filename)
+		if err != nil {
+			return fmt.Errorf("Invalid filename pattern: %v", err)
+		}
+		if fs.NumFields() > 0 {
+			return errors.New("The filename can only contain date placeholders")
+		}
+	}
+
 	return nil
 }
@@ -18,18 +18,29 @@
 package fileout
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/file"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/outputs"
 	"github.com/elastic/beats/libbeat/outputs/codec"
 	"github.com/elastic/beats/libbeat/publisher"
 )
 
+const (
+	defaultWaitRetry    = 1 * time.Second
+	defaultMaxWaitRetry = 60 * time.Second
+)
+
 func init() {
 	outputs.RegisterType("file", makeFileout)
 }
@@ -40,8 +51,19 @@ type fileOutput struct {
 	observer outputs.Observer
 	rotator  *file.Rotator
 	codec    codec.Codec
+	config   config
+
+	// pattern is set if the filename contains date placeholders. The file
+	// path is then re-evaluated for every batch. dated matches the names of
+	// the files written for any date, including rotated files.
+	pattern *fmtstr.EventFormatString
+	dated   *regexp.Regexp
+	now     func() time.Time
 }
 
+// placeholder matches the date placeholders of a filename.
+var placeholder = regexp.MustCompile(`%\{[^}]*\}`)
+
 // makeFileout instantiates a new file output instance.
 func makeFileout(
 	_ outputs.IndexManager,
@@ -60,15 +82,23 @@ func makeFileout(
 	fo := &fileOutput{
 		beat:     beat,
 		observer: observer,
+		now:      time.Now,
 	}
 	if err := fo.init(beat, config); err != nil {
 		return outputs.Fail(err)
 	}
 
+	// Connecting the codec fails fast if its service is unavailable, back off
+	// before retrying.
+	if _, ok := fo.codec.(codec.Connector); ok {
+		return outputs.Success(-1, 0, outputs.WithBackoff(fo, defaultWaitRetry, defaultMaxWaitRetry))
+	}
 	return outputs.Success(-1, 0, fo)
 }
 
 func (out *fileOutput) init(beat beat.Info, c config) error {
+	out.config = c
+
 	var path string
 	if c.Filename != "" {
 		path = filepath.Join(c.Path, c.Filename)
@@ -76,32 +106,168 @@ func (out *fileOutput) init(beat beat.Info, c config) error {
 		path = filepath.Join(c.Path, out.beat.Beat)
 	}
 
-	out.filePath = path
-
 	var err error
-	out.rotator, err = file.NewFileRotator(
+	if c.Filename != "" {
+		pattern, err := fmtstr.CompileEvent(path)
+		if err != nil {
+			return err
+		}
+		if !pattern.IsConst() {
+			out.pattern = pattern
+			out.dated = datedFilesRegexp(c.Filename)
+			if path, err = out.currentPath(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = out.openRotator(path); err != nil {
+		return err
+	}
+	out.removeOldFiles()
+
+	out.codec, err = codec.CreateEncoder(beat, c.Codec)
+	if err != nil {
+		return err
+	}
+
+	logp.Info("Initialized file output. "+
+		"path=%v max_size_bytes=%v max_backups=%v permissions=%v rotate_interval=%v compress=%v",
+		path, c.RotateEveryKb*1024, c.NumberOfFiles, os.FileMode(c.Permissions),
+		c.RotateInterval, c.Compress)
+
+	return nil
+}
+
+func (out *fileOutput) openRotator(path string) error {
+	rotator, err := file.NewFileRotator(
 		path,
-		file.MaxSizeBytes(c.RotateEveryKb*1024),
-		file.MaxBackups(c.NumberOfFiles),
-		file.Permissions(os.FileMode(c.Permissions)),
+		file.MaxSizeBytes(out.config.RotateEveryKb*1024),
+		file.MaxBackups(out.config.NumberOfFiles),
+		file.Permissions(os.FileMode(out.config.Permissions)),
+		file.Interval(out.config.RotateInterval),
+		file.Compress(out.config.Compress),
 		file.WithLogger(logp.NewLogger("rotator").With(logp.Namespace("rotator"))),
 	)
 	if err != nil {
 		return err
 	}
 
-	out.codec, err = codec.CreateEncoder(beat, c.Codec)
+	out.filePath = path
+	out.rotator = rotator
+	return nil
+}
+
+// currentPath evaluates the filename pattern using the current time.
+func (out *fileOutput) currentPath() (string, error) {
+	return out.pattern.Run(&beat.Event{Timestamp: out.now().UTC()})
+}
+
+// switchFile moves writing to a new file if the filename pattern evaluates
+// to a new path. The previous file is rotated, so it gets compressed if
+// compression is enabled.
+func (out *fileOutput) switchFile() error {
+	if out.pattern == nil {
+		return nil
+	}
+
+	path, err := out.currentPath()
 	if err != nil {
 		return err
 	}
+	if path == out.filePath {
+		return nil
+	}
 
-	logp.Info("Initialized file output. "+
-		"path=%v max_size_bytes=%v max_backups=%v permissions=%v",
-		path, c.RotateEveryKb*1024, c.NumberOfFiles, os.FileMode(c.Permissions))
+	if out.config.Compress {
+		err = out.rotator.Rotate()
+	} else {
+		err = out.rotator.Close()
+	}
+	if err != nil {
+		logp.Warn("Failed to close file %v: %v", out.filePath, err)
+	}
+
+	logp.Info("Switching file output to path=%v", path)
+	if err := out.openRotator(path); err != nil {
+		return err
+	}
 
+	out.removeOldFiles()
 	return nil
 }
 
+// datedFilesRegexp returns a regular expression matching the names of the
+// files written by the filename pattern for any date, including the rotated
+// and compressed files. It returns nil if the directory of the files depends on
+// the date.
+func datedFilesRegexp(filename string) *regexp.Regexp {
+	if placeholder.MatchString(filepath.Dir(filename)) {
+		return nil
+	}
+
+	name := filepath.Base(filename)
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range placeholder.FindAllStringIndex(name, -1) {
+		expr.WriteString(regexp.QuoteMeta(name[last:loc[0]]))
+		expr.WriteString(".+")
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(name[last:]))
+	expr.WriteString(".*$")
+	return regexp.MustCompile(expr.String())
+}
+
+// removeOldFiles removes the files written for previous dates, oldest first,
+// such that at most number_of_files files are kept in total.
+func (out *fileOutput) removeOldFiles() {
+	if out.dated == nil {
+		return
+	}
+
+	dir := filepath.Dir(out.filePath)
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		logp.Warn("Failed to list files in %v: %v", dir, err)
+		return
+	}
+
+	var files []os.FileInfo
+	for _, info := range infos {
+		path := filepath.Join(dir, info.Name())
+		if info.Mode().IsRegular() && path != out.filePath && out.dated.MatchString(info.Name()) {
+			files = append(files, info)
+		}
+	}
+
+	// The current file is kept in addition to the most recent files.
+	keep := int(out.config.NumberOfFiles) - 1
+	if len(files) <= keep {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if ti, tj := files[i].ModTime(), files[j].ModTime(); !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return files[i].Name() > files[j].Name()
+	})
+	for _, info := range files[keep:] {
+		path := filepath.Join(dir, info.Name())
+		if err := os.Remove(path); err != nil {
+			logp.Warn("Failed to remove old file %v: %v", path, err)
+		}
+	}
+}
+
+// Connect connects the codec. Events are only published once the codec is
+// connected.
+func (out *fileOutput) Connect() error {
+	return codec.Connect(out.codec)
+}
+
 // Implement Outputer
 func (out *fileOutput) Close() error {
 	return out.rotator.Close()
@@ -116,6 +282,10 @@ func (out *fileOutput) Publish(
 	events := batch.Events()
 	st.NewBatch(len(events))
 
+	if err := out.switchFile(); err != nil {
+		logp.Err("Failed to switch to new file: %v", err)
+	}
+
 	dropped := 0
 	for i := range events {
 		event := &events[i]
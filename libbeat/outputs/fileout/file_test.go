@@ -18,3 +18,158 @@
 // +build !integration
 
 package fileout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	_ "github.com/elastic/beats/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/libbeat/outputs/outest"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		settings map[string]interface{}
+		valid    bool
+	}{
+		"default":         {map[string]interface{}{}, true},
+		"interval":        {map[string]interface{}{"rotate_interval": "1h"}, true},
+		"short interval":  {map[string]interface{}{"rotate_interval": "10ms"}, false},
+		"date pattern":    {map[string]interface{}{"filename": "events-%{+yyyy-MM-dd}"}, true},
+		"field in name":   {map[string]interface{}{"filename": "events-%{[host.name]}"}, false},
+		"invalid pattern": {map[string]interface{}{"filename": "events-%{+yyyy"}, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaultConfig
+			err := common.MustNewConfigFrom(test.settings).Unpack(&cfg)
+			assert.Equal(t, test.valid, err == nil, "unexpected result: %v", err)
+		})
+	}
+}
+
+func TestFilenamePattern(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		compress := compress
+		name := "plain"
+		if compress {
+			name = "compressed"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "fileout")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			now := time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC)
+
+			cfg := defaultConfig
+			cfg.Path = dir
+			cfg.Filename = "events-%{+yyyy-MM-dd}.ndjson"
+			cfg.Compress = compress
+
+			out := &fileOutput{
+				beat:     beat.Info{Beat: "testbeat", Version: "1.2.3"},
+				observer: outputs.NewNilObserver(),
+				now:      func() time.Time { return now },
+			}
+			require.NoError(t, out.init(out.beat, cfg))
+			defer out.Close()
+
+			publish := func() {
+				batch := outest.NewBatch(beat.Event{
+					Timestamp: now,
+					Fields:    common.MapStr{"message": "test"},
+				})
+				require.NoError(t, out.Publish(batch))
+			}
+
+			publish()
+			now = now.Add(24 * time.Hour)
+			publish()
+
+			expected := []string{"events-2019-01-02.ndjson", "events-2019-01-03.ndjson"}
+			if compress {
+				expected[0] = "events-2019-01-02.ndjson.1.gz"
+			}
+			assertDirContents(t, dir, expected...)
+		})
+	}
+}
+
+func TestFilenamePatternRetention(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		compress := compress
+		name := "plain"
+		if compress {
+			name = "compressed"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "fileout")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			other := filepath.Join(dir, "other.ndjson")
+			require.NoError(t, ioutil.WriteFile(other, []byte("keep"), 0600))
+
+			now := time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC)
+
+			cfg := defaultConfig
+			cfg.Path = dir
+			cfg.Filename = "events-%{+yyyy-MM-dd}.ndjson"
+			cfg.NumberOfFiles = 2
+			cfg.Compress = compress
+
+			out := &fileOutput{
+				beat:     beat.Info{Beat: "testbeat", Version: "1.2.3"},
+				observer: outputs.NewNilObserver(),
+				now:      func() time.Time { return now },
+			}
+			require.NoError(t, out.init(out.beat, cfg))
+			defer out.Close()
+
+			// number_of_files applies to the files of all dates.
+			for i := 0; i < 3; i++ {
+				batch := outest.NewBatch(beat.Event{
+					Timestamp: now,
+					Fields:    common.MapStr{"message": "test"},
+				})
+				require.NoError(t, out.Publish(batch))
+				now = now.Add(24 * time.Hour)
+			}
+
+			expected := []string{"other.ndjson", "events-2019-01-03.ndjson", "events-2019-01-04.ndjson"}
+			if compress {
+				expected[1] = "events-2019-01-03.ndjson.1.gz"
+			}
+			assertDirContents(t, dir, expected...)
+		})
+	}
+}
+
+func assertDirContents(t *testing.T, dir string, expected ...string) {
+	t.Helper()
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f.Name()))
+	}
+	sort.Strings(names)
+	sort.Strings(expected)
+	assert.Equal(t, expected, names)
+}
@@ -0,0 +1,373 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/codec"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/publisher"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+type client struct {
+	url     string
+	method  string
+	headers map[string]string
+
+	username, password string
+	bearerToken        string
+
+	http      *http.Client
+	tlsConfig *transport.TLSConfig
+	timeout   time.Duration
+
+	index            string
+	codec            codec.Codec
+	batchFormat      string
+	compressionLevel int
+
+	observer outputs.Observer
+}
+
+// clientSettings contains the settings for a client.
+type clientSettings struct {
+	URL                string
+	Method             string
+	Proxy              *url.URL
+	ProxyDisable       bool
+	TLS                *transport.TLSConfig
+	Username, Password string
+	BearerToken        string
+	Parameters         map[string]string
+	Headers            map[string]string
+	Timeout            time.Duration
+	CompressionLevel   int
+	BatchFormat        string
+	Index              string
+	Codec              codec.Codec
+	Observer           outputs.Observer
+}
+
+var (
+	errTooManyRequests = errors.New("endpoint responded with 429 Too Many Requests")
+	errTempFailure     = errors.New("temporary http send failure")
+)
+
+func newClient(s clientSettings) (*client, error) {
+	var proxy func(*http.Request) (*url.URL, error)
+	if !s.ProxyDisable {
+		proxy = http.ProxyFromEnvironment
+		if s.Proxy != nil {
+			proxy = http.ProxyURL(s.Proxy)
+		}
+	}
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse http output URL: %v", err)
+	}
+	if u.User != nil {
+		s.Username = u.User.Username()
+		s.Password, _ = u.User.Password()
+		u.User = nil
+	}
+	if len(s.Parameters) > 0 {
+		values := u.Query()
+		for key, val := range s.Parameters {
+			values.Add(key, val)
+		}
+		u.RawQuery = values.Encode()
+	}
+
+	logp.Info("HTTP output url: %s", u)
+
+	var dialer, tlsDialer transport.Dialer
+
+	dialer = transport.NetDialer(s.Timeout)
+	tlsDialer, err = transport.TLSDialer(dialer, s.TLS, s.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	observer := s.Observer
+	if observer == nil {
+		observer = outputs.NewNilObserver()
+	} else {
+		dialer = transport.StatsDialer(dialer, observer)
+		tlsDialer = transport.StatsDialer(tlsDialer, observer)
+	}
+
+	return &client{
+		url:         u.String(),
+		method:      s.Method,
+		headers:     s.Headers,
+		username:    s.Username,
+		password:    s.Password,
+		bearerToken: s.BearerToken,
+		http: &http.Client{
+			Transport: &http.Transport{
+				Dial:    dialer.Dial,
+				DialTLS: tlsDialer.Dial,
+				Proxy:   proxy,
+			},
+			Timeout: s.Timeout,
+		},
+		tlsConfig:        s.TLS,
+		timeout:          s.Timeout,
+		index:            s.Index,
+		codec:            s.Codec,
+		batchFormat:      s.BatchFormat,
+		compressionLevel: s.CompressionLevel,
+		observer:         observer,
+	}, nil
+}
+
+// Connect is a no-op. Connections to the endpoint are established on demand
+// when publishing a batch.
+func (c *client) Connect() error {
+	return nil
+}
+
+func (c *client) Close() error {
+	if t, ok := c.http.Transport.(*http.Transport); ok {
+		t.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (c *client) Publish(batch publisher.Batch) error {
+	events := batch.Events()
+	rest, err := c.publishEvents(events)
+	if len(rest) == 0 {
+		batch.ACK()
+	} else {
+		batch.RetryEvents(rest)
+	}
+	return err
+}
+
+// publishEvents encodes all events into a single request body and sends it to
+// the configured endpoint. On error a slice with all events that have not
+// been accepted by the endpoint is returned.
+func (c *client) publishEvents(data []publisher.Event) ([]publisher.Event, error) {
+	st := c.observer
+	st.NewBatch(len(data))
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	body, data, err := c.encodeEvents(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	begin := time.Now()
+	status, resp, err := c.send(body)
+	if err != nil && status == 0 {
+		logp.Err("Failed to send events to %v: %v", c.url, err)
+		st.WriteError(err)
+		st.Failed(len(data))
+		return data, err
+	}
+	debugf("HTTP request of %v events took %v", len(data), time.Since(begin))
+
+	switch {
+	case status < 300:
+		st.Acked(len(data))
+		return nil, nil
+	case status == http.StatusTooManyRequests:
+		st.ErrTooMany(len(data))
+		return data, errTooManyRequests
+	case status >= 500:
+		logp.Warn("Endpoint %v responded with %v: %s", c.url, status, resp)
+		st.Failed(len(data))
+		return data, errTempFailure
+	default:
+		// Client errors will not be resolved by retrying the request. Drop the
+		// events, so the pipeline is not blocked by a single bad batch.
+		logp.Err("Dropping %v events, endpoint %v responded with %v: %s",
+			len(data), c.url, status, resp)
+		st.Dropped(len(data))
+		return nil, nil
+	}
+}
+
+// encodeEvents serializes the events using the configured codec. Events that
+// cannot be encoded are dropped. The returned slice contains the encoded
+// events only, reusing the backing memory of the input slice.
+func (c *client) encodeEvents(data []publisher.Event) (*bytes.Buffer, []publisher.Event, error) {
+	buf := bytes.NewBuffer(nil)
+
+	var w io.Writer = buf
+	var gz *gzip.Writer
+	if c.compressionLevel > 0 {
+		var err error
+		gz, err = gzip.NewWriterLevel(buf, c.compressionLevel)
+		if err != nil {
+			return nil, nil, err
+		}
+		w = gz
+	}
+
+	if c.batchFormat == batchFormatArray {
+		w.Write([]byte{'['})
+	}
+
+	okEvents := data[:0]
+	dropped := 0
+	for i := range data {
+		event := &data[i]
+
+		serialized, err := c.codec.Encode(c.index, &event.Content)
+		if err != nil {
+			if event.Guaranteed() {
+				logp.Critical("Failed to serialize the event: %v", err)
+			} else {
+				logp.Warn("Failed to serialize the event: %v", err)
+			}
+			logp.Debug("http", "Failed event: %v", event)
+
+			dropped++
+			continue
+		}
+
+		switch {
+		case c.batchFormat == batchFormatLines:
+			w.Write(serialized)
+			w.Write([]byte{'\n'})
+		case len(okEvents) > 0:
+			w.Write([]byte{','})
+			fallthrough
+		default:
+			w.Write(serialized)
+		}
+		okEvents = append(okEvents, *event)
+	}
+
+	if c.batchFormat == batchFormatArray {
+		w.Write([]byte{']'})
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	c.observer.Dropped(dropped)
+	return buf, okEvents, nil
+}
+
+func (c *client) send(body *bytes.Buffer) (int, []byte, error) {
+	req, err := http.NewRequest(c.method, c.url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if c.batchFormat == batchFormatArray {
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	} else {
+		req.Header.Set("Content-Type", "application/x-ndjson; charset=UTF-8")
+	}
+	if c.compressionLevel > 0 {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+
+	// The stlib will override the value in the header based on the configured `Host`
+	// on the request which default to the current machine.
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	c.observer.WriteBytes(body.Len())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	obj, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return status, nil, err
+	}
+	c.observer.ReadBytes(len(obj))
+
+	return status, obj, nil
+}
+
+func (c *client) Test(d testing.Driver) {
+	d.Run("http: "+c.url, func(d testing.Driver) {
+		u, err := url.Parse(c.url)
+		d.Fatal("parse url", err)
+
+		address := u.Hostname()
+		if u.Port() != "" {
+			address += ":" + u.Port()
+		}
+		d.Run("connection", func(d testing.Driver) {
+			netDialer := transport.TestNetDialer(d, c.timeout)
+			_, err = netDialer.Dial("tcp", address)
+			d.Fatal("dial up", err)
+		})
+
+		if u.Scheme != "https" {
+			d.Warn("TLS", "secure connection disabled")
+		} else {
+			d.Run("TLS", func(d testing.Driver) {
+				netDialer := transport.NetDialer(c.timeout)
+				tlsDialer, err := transport.TestTLSDialer(d, netDialer, c.tlsConfig, c.timeout)
+				d.Fatal("build TLS dialer", err)
+				_, err = tlsDialer.Dial("tcp", address)
+				d.Fatal("dial up", err)
+			})
+		}
+	})
+}
+
+func (c *client) String() string {
+	return "http(" + c.url + ")"
+}
@@ -0,0 +1,270 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package httpout
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/outputs/codec/format"
+	"github.com/elastic/beats/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/libbeat/outputs/outest"
+)
+
+type request struct {
+	header http.Header
+	body   string
+}
+
+func startServer(t *testing.T, status int) (*httptest.Server, <-chan request) {
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader := r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			reader = gz
+		}
+
+		body, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Error(err)
+		}
+		requests <- request{header: r.Header, body: string(body)}
+		w.WriteHeader(status)
+	}))
+	return server, requests
+}
+
+func newTestClient(t *testing.T, url string, s clientSettings) *client {
+	s.URL = url
+	if s.Method == "" {
+		s.Method = "POST"
+	}
+	if s.BatchFormat == "" {
+		s.BatchFormat = batchFormatLines
+	}
+	if s.Codec == nil {
+		s.Codec = format.New(fmtstr.MustCompileEvent("%{[message]}"))
+	}
+	s.Timeout = 5 * time.Second
+
+	c, err := newClient(s)
+	require.NoError(t, err)
+	return c
+}
+
+func makeEvents(msgs ...string) []beat.Event {
+	events := make([]beat.Event, len(msgs))
+	for i, msg := range msgs {
+		events[i] = beat.Event{Fields: common.MapStr{"message": msg}}
+	}
+	return events
+}
+
+func TestPublish(t *testing.T) {
+	tests := map[string]struct {
+		settings clientSettings
+		body     string
+		header   map[string]string
+	}{
+		"newline delimited": {
+			body:   "a\nb\n",
+			header: map[string]string{"Content-Type": "application/x-ndjson; charset=UTF-8"},
+		},
+		"json array": {
+			settings: clientSettings{
+				BatchFormat: batchFormatArray,
+				Codec:       json.New("1.2.3", json.Config{}),
+			},
+			body: `[{"@timestamp":"0001-01-01T00:00:00.000Z","@metadata":{"beat":"test","type":"_doc","version":"1.2.3"},"message":"a"},` +
+				`{"@timestamp":"0001-01-01T00:00:00.000Z","@metadata":{"beat":"test","type":"_doc","version":"1.2.3"},"message":"b"}]`,
+			header: map[string]string{"Content-Type": "application/json; charset=UTF-8"},
+		},
+		"gzip compressed": {
+			settings: clientSettings{CompressionLevel: 5},
+			body:     "a\nb\n",
+			header:   map[string]string{"Content-Encoding": "gzip"},
+		},
+		"basic auth": {
+			settings: clientSettings{Username: "user", Password: "secret"},
+			body:     "a\nb\n",
+			header:   map[string]string{"Authorization": "Basic dXNlcjpzZWNyZXQ="},
+		},
+		"bearer auth": {
+			settings: clientSettings{BearerToken: "token"},
+			body:     "a\nb\n",
+			header:   map[string]string{"Authorization": "Bearer token"},
+		},
+		"custom headers": {
+			settings: clientSettings{Headers: map[string]string{"X-Tenant": "ops"}},
+			body:     "a\nb\n",
+			header:   map[string]string{"X-Tenant": "ops"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, requests := startServer(t, http.StatusOK)
+			defer server.Close()
+
+			settings := test.settings
+			settings.Index = "test"
+			c := newTestClient(t, server.URL, settings)
+			defer c.Close()
+
+			batch := outest.NewBatch(makeEvents("a", "b")...)
+			err := c.Publish(batch)
+			require.NoError(t, err)
+
+			req := <-requests
+			assert.Equal(t, test.body, req.body)
+			for k, v := range test.header {
+				assert.Equal(t, v, req.header.Get(k))
+			}
+
+			require.Len(t, batch.Signals, 1)
+			assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+		})
+	}
+}
+
+func TestPublishResponseStatus(t *testing.T) {
+	tests := map[string]struct {
+		status int
+		fail   bool
+		signal outest.BatchSignalTag
+	}{
+		"accepted":          {status: http.StatusAccepted, signal: outest.BatchACK},
+		"server error":      {status: http.StatusServiceUnavailable, fail: true, signal: outest.BatchRetryEvents},
+		"too many requests": {status: http.StatusTooManyRequests, fail: true, signal: outest.BatchRetryEvents},
+		"bad request":       {status: http.StatusBadRequest, signal: outest.BatchACK},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, _ := startServer(t, test.status)
+			defer server.Close()
+
+			c := newTestClient(t, server.URL, clientSettings{})
+			defer c.Close()
+
+			batch := outest.NewBatch(makeEvents("a", "b")...)
+			err := c.Publish(batch)
+			if test.fail {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			require.Len(t, batch.Signals, 1)
+			assert.Equal(t, test.signal, batch.Signals[0].Tag)
+			if test.signal == outest.BatchRetryEvents {
+				assert.Len(t, batch.Signals[0].Events, 2)
+			}
+		})
+	}
+}
+
+func TestPublishConnectionFailure(t *testing.T) {
+	server, _ := startServer(t, http.StatusOK)
+	url := server.URL
+	server.Close()
+
+	c := newTestClient(t, url, clientSettings{})
+	batch := outest.NewBatch(makeEvents("a")...)
+	err := c.Publish(batch)
+	assert.Error(t, err)
+
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchRetryEvents, batch.Signals[0].Tag)
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config common.MapStr
+		fail   bool
+	}{
+		"defaults": {
+			config: common.MapStr{},
+		},
+		"unsupported method": {
+			config: common.MapStr{"method": "GET"},
+			fail:   true,
+		},
+		"array with format codec": {
+			config: common.MapStr{
+				"batch_format":        "array",
+				"codec.format.string": "%{[message]}",
+			},
+			fail: true,
+		},
+		"bearer and basic auth": {
+			config: common.MapStr{"bearer_token": "abc", "username": "user"},
+			fail:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := common.MustNewConfigFrom(test.config)
+			config := defaultConfig
+			err := cfg.Unpack(&config)
+			if test.fail {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMakeURL(t *testing.T) {
+	tests := map[string]struct {
+		protocol, path, host string
+		expected             string
+	}{
+		"plain host":     {host: "collector", expected: "http://collector:80"},
+		"https protocol": {protocol: "https", host: "collector", expected: "https://collector:443"},
+		"https scheme":   {host: "https://collector/ingest", expected: "https://collector:443/ingest"},
+		"explicit port":  {host: "collector:8080", path: "/ingest", expected: "http://collector:8080/ingest"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			url, err := makeURL(test.protocol, test.path, test.host)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, url)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpout
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/codec"
+)
+
+func init() {
+	outputs.RegisterType("http", makeHTTP)
+}
+
+var debugf = logp.MakeDebug("http")
+
+func makeHTTP(
+	_ outputs.IndexManager,
+	beat beat.Info,
+	observer outputs.Observer,
+	cfg *common.Config,
+) (outputs.Group, error) {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return outputs.Fail(err)
+	}
+
+	hosts, err := outputs.ReadHostList(cfg)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	var proxyURL *url.URL
+	if !config.ProxyDisable {
+		proxyURL, err = parseProxyURL(config.ProxyURL)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+		if proxyURL != nil {
+			logp.Info("Using proxy URL: %s", proxyURL)
+		}
+	}
+
+	clients := make([]outputs.NetworkClient, len(hosts))
+	for i, host := range hosts {
+		hostURL, err := makeURL(config.Protocol, config.Path, host)
+		if err != nil {
+			logp.Err("Invalid host param set: %s, Error: %v", host, err)
+			return outputs.Fail(err)
+		}
+
+		enc, err := codec.CreateEncoder(beat, config.Codec)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+
+		var client outputs.NetworkClient
+		client, err = newClient(clientSettings{
+			URL:              hostURL,
+			Method:           strings.ToUpper(config.Method),
+			Proxy:            proxyURL,
+			ProxyDisable:     config.ProxyDisable,
+			TLS:              tlsConfig,
+			Username:         config.Username,
+			Password:         config.Password,
+			BearerToken:      config.BearerToken,
+			Parameters:       config.Params,
+			Headers:          config.Headers,
+			Timeout:          config.Timeout,
+			CompressionLevel: config.CompressionLevel,
+			BatchFormat:      config.BatchFormat,
+			Index:            beat.Beat,
+			Codec:            enc,
+			Observer:         observer,
+		})
+		if err != nil {
+			return outputs.Fail(err)
+		}
+
+		client = outputs.WithBackoff(client, config.Backoff.Init, config.Backoff.Max)
+		clients[i] = client
+	}
+
+	return outputs.SuccessNet(config.LoadBalance, config.BulkMaxSize, config.MaxRetries, clients)
+}
+
+// makeURL builds the endpoint URL for a configured host. The default port
+// depends on the scheme, as collectors are commonly exposed on the well known
+// HTTP/HTTPS ports.
+func makeURL(protocol, path, host string) (string, error) {
+	port := 80
+	if protocol == "https" || strings.HasPrefix(host, "https://") {
+		port = 443
+	}
+	return common.MakeURL(protocol, path, host, port)
+}
@@ -41,6 +41,7 @@ type client struct {
 	hosts    []string
 	topic    outil.Selector
 	key      *fmtstr.EventFormatString
+	headers  []header
 	index    string
 	codec    codec.Codec
 	config   sarama.Config
@@ -70,6 +71,7 @@ func newKafkaClient(
 	hosts []string,
 	index string,
 	key *fmtstr.EventFormatString,
+	headers []header,
 	topic outil.Selector,
 	writer codec.Codec,
 	cfg *sarama.Config,
@@ -79,6 +81,7 @@ func newKafkaClient(
 		hosts:    hosts,
 		topic:    topic,
 		key:      key,
+		headers:  headers,
 		index:    index,
 		codec:    writer,
 		config:   *cfg,
@@ -92,6 +95,11 @@ func (c *client) Connect() error {
 
 	debugf("connect: %v", c.hosts)
 
+	if err := codec.Connect(c.codec); err != nil {
+		logp.Err("Kafka connect fails with: %v", err)
+		return err
+	}
+
 	// try to connect
 	producer, err := sarama.NewAsyncProducer(c.hosts, &c.config)
 	if err != nil {
@@ -211,6 +219,21 @@ func (c *client) getEventMessage(data *publisher.Event) (*message, error) {
 		}
 	}
 
+	if len(c.headers) > 0 {
+		msg.headers = make([]sarama.RecordHeader, 0, len(c.headers))
+		for _, h := range c.headers {
+			value, err := h.Value.RunBytes(event)
+			if err != nil {
+				debugf("Skipping header %v: %v", h.Key, err)
+				continue
+			}
+			msg.headers = append(msg.headers, sarama.RecordHeader{
+				Key:   []byte(h.Key),
+				Value: value,
+			})
+		}
+	}
+
 	return msg, nil
 }
 
@@ -0,0 +1,76 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/publisher"
+)
+
+func TestGetEventMessageHeaders(t *testing.T) {
+	headers := []header{
+		{Key: "trace-id", Value: fmtstr.MustCompileEvent("%{[trace.id]}")},
+		{Key: "tenant", Value: fmtstr.MustCompileEvent("%{[tenant]}")},
+		{Key: "source", Value: fmtstr.MustCompileEvent("beats")},
+	}
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V1_0_0_0
+	c, err := newKafkaClient(
+		outputs.NewNilObserver(),
+		[]string{"localhost:9092"},
+		"test",
+		nil,
+		headers,
+		outil.MakeSelector(outil.ConstSelectorExpr("topic")),
+		json.New("1.2.3", json.Config{}),
+		cfg,
+	)
+	require.NoError(t, err)
+
+	msg, err := c.getEventMessage(&publisher.Event{
+		Content: beat.Event{
+			Fields: common.MapStr{
+				"trace": common.MapStr{"id": "abc"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// headers referencing missing fields are skipped
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("trace-id"), Value: []byte("abc")},
+		{Key: []byte("source"), Value: []byte("beats")},
+	}, msg.headers)
+
+	msg.initProducerMessage()
+	assert.Equal(t, msg.headers, msg.msg.Headers)
+}
@@ -42,10 +42,12 @@ type kafkaConfig struct {
 	Timeout            time.Duration             `config:"timeout"             validate:"min=1"`
 	Metadata           metaConfig                `config:"metadata"`
 	Key                *fmtstr.EventFormatString `config:"key"`
+	Headers            []header                  `config:"headers"`
 	Partition          map[string]*common.Config `config:"partition"`
 	KeepAlive          time.Duration             `config:"keep_alive"          validate:"min=0"`
 	MaxMessageBytes    *int                      `config:"max_message_bytes"   validate:"min=1"`
 	RequiredACKs       *int                      `config:"required_acks"       validate:"min=-1"`
+	Idempotent         bool                      `config:"idempotent"`
 	BrokerTimeout      time.Duration             `config:"broker_timeout"      validate:"min=1"`
 	Compression        string                    `config:"compression"`
 	CompressionLevel   int                       `config:"compression_level"`
@@ -57,9 +59,15 @@ type kafkaConfig struct {
 	ChanBufferSize     int                       `config:"channel_buffer_size" validate:"min=1"`
 	Username           string                    `config:"username"`
 	Password           string                    `config:"password"`
+	SASL               kafka.SASLConfig          `config:"sasl"`
 	Codec              codec.Config              `config:"codec"`
 }
 
+type header struct {
+	Key   string                    `config:"key"   validate:"required"`
+	Value *fmtstr.EventFormatString `config:"value" validate:"required"`
+}
+
 type metaConfig struct {
 	Retry       metaRetryConfig `config:"retry"`
 	RefreshFreq time.Duration   `config:"refresh_frequency" validate:"min=0"`
@@ -131,10 +139,29 @@ func (c *kafkaConfig) Validate() error {
 		return err
 	}
 
+	// record headers and the idempotent producer have been added to kafka
+	// with version 0.11.0.0
+	version, _ := c.Version.Get()
+	if len(c.Headers) > 0 && !version.IsAtLeast(sarama.V0_11_0_0) {
+		return fmt.Errorf("headers require kafka version 0.11 or newer")
+	}
+	if c.Idempotent {
+		if !version.IsAtLeast(sarama.V0_11_0_0) {
+			return fmt.Errorf("idempotent producer requires kafka version 0.11 or newer")
+		}
+		if c.RequiredACKs != nil && *c.RequiredACKs != int(sarama.WaitForAll) {
+			return fmt.Errorf("idempotent producer requires required_acks to be -1")
+		}
+	}
+
 	if c.Username != "" && c.Password == "" {
 		return fmt.Errorf("password must be set when username is configured")
 	}
 
+	if err := c.SASL.Validate(c.Username, c.Password); err != nil {
+		return err
+	}
+
 	if c.Compression == "gzip" {
 		lvl := c.CompressionLevel
 		if lvl != sarama.CompressionLevelDefault && !(0 <= lvl && lvl <= 9) {
@@ -171,10 +198,8 @@ func newSaramaConfig(config *kafkaConfig) (*sarama.Config, error) {
 		k.Net.TLS.Config = tls.BuildModuleConfig("")
 	}
 
-	if config.Username != "" {
-		k.Net.SASL.Enable = true
-		k.Net.SASL.User = config.Username
-		k.Net.SASL.Password = config.Password
+	if err := config.SASL.ConfigureSarama(k, config.Username, config.Password); err != nil {
+		return nil, err
 	}
 
 	// configure metadata update properties
@@ -191,6 +216,16 @@ func newSaramaConfig(config *kafkaConfig) (*sarama.Config, error) {
 		k.Producer.RequiredAcks = sarama.RequiredAcks(*config.RequiredACKs)
 	}
 
+	// The idempotent producer ensures messages resent by the producer after
+	// a broker failure are written exactly once to the partition. This
+	// requires acknowledgement by all in-sync replicas and at most one
+	// in-flight request per broker, so message ordering is kept on retries.
+	if config.Idempotent {
+		k.Producer.Idempotent = true
+		k.Producer.RequiredAcks = sarama.WaitForAll
+		k.Net.MaxOpenRequests = 1
+	}
+
 	compressionMode, ok := compressionModes[strings.ToLower(config.Compression)]
 	if !ok {
 		return nil, fmt.Errorf("Unknown compression mode: '%v'", config.Compression)
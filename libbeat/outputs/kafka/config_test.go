@@ -20,6 +20,8 @@ package kafka
 import (
 	"testing"
 
+	"github.com/Shopify/sarama"
+
 	"github.com/elastic/beats/libbeat/common"
 )
 
@@ -34,6 +36,27 @@ func TestConfigAcceptValid(t *testing.T) {
 			"compression": "lz4",
 			"version":     "1.0.0",
 		},
+		"sasl scram": common.MapStr{
+			"username":       "user",
+			"password":       "secret",
+			"sasl.mechanism": "SCRAM-SHA-512",
+		},
+		"headers with 0.11": common.MapStr{
+			"version": "0.11",
+			"headers": []common.MapStr{
+				{"key": "trace-id", "value": "%{[trace.id]}"},
+			},
+		},
+		"idempotent producer": common.MapStr{
+			"version":       "1.0.0",
+			"idempotent":    true,
+			"required_acks": -1,
+		},
+		"sasl oauthbearer": common.MapStr{
+			"version":          "2.0.0",
+			"sasl.mechanism":   "OAUTHBEARER",
+			"sasl.oauth.token": "abc",
+		},
 	}
 
 	for name, test := range tests {
@@ -51,3 +74,88 @@ func TestConfigAcceptValid(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigInvalid(t *testing.T) {
+	tests := map[string]common.MapStr{
+		"unknown sasl mechanism": common.MapStr{
+			"username":       "user",
+			"password":       "secret",
+			"sasl.mechanism": "CRAM-MD5",
+		},
+		"sasl scram without credentials": common.MapStr{
+			"sasl.mechanism": "SCRAM-SHA-256",
+		},
+		"sasl oauthbearer without token": common.MapStr{
+			"sasl.mechanism": "OAUTHBEARER",
+		},
+		"headers with 0.10": common.MapStr{
+			"version": "0.10",
+			"headers": []common.MapStr{
+				{"key": "trace-id", "value": "%{[trace.id]}"},
+			},
+		},
+		"header without value": common.MapStr{
+			"version": "1.0.0",
+			"headers": []common.MapStr{
+				{"key": "trace-id"},
+			},
+		},
+		"idempotent producer with 0.10": common.MapStr{
+			"version":    "0.10",
+			"idempotent": true,
+		},
+		"idempotent producer with required_acks 1": common.MapStr{
+			"version":       "1.0.0",
+			"idempotent":    true,
+			"required_acks": 1,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			c := common.MustNewConfigFrom(test)
+			c.SetString("hosts", 0, "localhost")
+			if _, err := readConfig(c); err == nil {
+				t.Fatal("Expected configuration to be invalid")
+			}
+		})
+	}
+}
+
+func TestIdempotentProducerConfig(t *testing.T) {
+	c := common.MustNewConfigFrom(common.MapStr{
+		"hosts":      []string{"localhost"},
+		"version":    "1.0.0",
+		"idempotent": true,
+	})
+	cfg, err := readConfig(c)
+	if err != nil {
+		t.Fatalf("Can not create test configuration: %v", err)
+	}
+
+	k, err := newSaramaConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failure creating sarama config: %v", err)
+	}
+	if !k.Producer.Idempotent {
+		t.Error("Expected idempotent producer to be enabled")
+	}
+	if k.Producer.RequiredAcks != sarama.WaitForAll {
+		t.Errorf("Expected required acks to be WaitForAll, got %v", k.Producer.RequiredAcks)
+	}
+	if k.Net.MaxOpenRequests != 1 {
+		t.Errorf("Expected max open requests to be 1, got %v", k.Net.MaxOpenRequests)
+	}
+	if !k.Version.IsAtLeast(sarama.V0_11_0_0) {
+		t.Errorf("Expected version to be at least 0.11, got %v", k.Version)
+	}
+	if k.Producer.Retry.Max < 1 {
+		t.Errorf("Expected at least one retry, got %v", k.Producer.Retry.Max)
+	}
+
+	// sarama rejects idempotent producers not meeting its requirements.
+	if err := k.Validate(); err != nil {
+		t.Errorf("Expected sarama config to be valid: %v", err)
+	}
+}
@@ -85,12 +85,12 @@ func makeKafka(
 		return outputs.Fail(err)
 	}
 
-	codec, err := codec.CreateEncoder(beat, config.Codec)
+	enc, err := codec.CreateEncoder(beat, config.Codec)
 	if err != nil {
 		return outputs.Fail(err)
 	}
 
-	client, err := newKafkaClient(observer, hosts, beat.IndexPrefix, config.Key, topic, codec, libCfg)
+	client, err := newKafkaClient(observer, hosts, beat.IndexPrefix, config.Key, config.Headers, topic, enc, libCfg)
 	if err != nil {
 		return outputs.Fail(err)
 	}
@@ -99,5 +99,11 @@ func makeKafka(
 	if config.MaxRetries < 0 {
 		retry = -1
 	}
+
+	// Connecting the codec fails fast if its service is unavailable, back off
+	// before retrying.
+	if _, ok := enc.(codec.Connector); ok {
+		return outputs.Success(config.BulkMaxSize, retry, outputs.WithBackoff(client, defaultWaitRetry, defaultMaxWaitRetry))
+	}
 	return outputs.Success(config.BulkMaxSize, retry, client)
 }
@@ -33,6 +33,7 @@ type Stats struct {
 	active     *monitoring.Uint // events sent and waiting for ACK/fail from output
 	duplicates *monitoring.Uint // events sent and waiting for ACK/fail from output
 	dropped    *monitoring.Uint // total number of invalid events dropped by the output
+	deadLetter *monitoring.Uint // total number of events stored in a dead letter destination
 	tooMany    *monitoring.Uint // total number of too many requests replies from output
 
 	//
@@ -56,6 +57,7 @@ func NewStats(reg *monitoring.Registry) *Stats {
 		failed:     monitoring.NewUint(reg, "events.failed"),
 		dropped:    monitoring.NewUint(reg, "events.dropped"),
 		duplicates: monitoring.NewUint(reg, "events.duplicates"),
+		deadLetter: monitoring.NewUint(reg, "events.dead_letter"),
 		active:     monitoring.NewUint(reg, "events.active"),
 		tooMany:    monitoring.NewUint(reg, "events.toomany"),
 
@@ -112,6 +114,16 @@ func (s *Stats) Dropped(n int) {
 	}
 }
 
+// DeadLetter updates the active and dead letter event metrics. Events stored
+// in a dead letter destination have been rejected by the output, but are not
+// lost.
+func (s *Stats) DeadLetter(n int) {
+	if s != nil {
+		s.active.Sub(uint64(n))
+		s.deadLetter.Add(uint64(n))
+	}
+}
+
 // Cancelled updates the active event metrics.
 func (s *Stats) Cancelled(n int) {
 	if s != nil {
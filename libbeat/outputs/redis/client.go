@@ -51,6 +51,7 @@ type client struct {
 	dataType redisDataType
 	db       int
 	key      outil.Selector
+	stream   streamSettings
 	password string
 	publish  publishFn
 	codec    codec.Codec
@@ -62,6 +63,7 @@ type redisDataType uint16
 const (
 	redisListType redisDataType = iota
 	redisChannelType
+	redisStreamType
 )
 
 func newClient(
@@ -69,7 +71,7 @@ func newClient(
 	observer outputs.Observer,
 	timeout time.Duration,
 	pass string,
-	db int, key outil.Selector, dt redisDataType,
+	db int, key outil.Selector, dt redisDataType, stream streamSettings,
 	index string, codec codec.Codec,
 ) *client {
 	return &client{
@@ -81,13 +83,19 @@ func newClient(
 		db:       db,
 		dataType: dt,
 		key:      key,
+		stream:   stream,
 		codec:    codec,
 	}
 }
 
 func (c *client) Connect() error {
 	debugf("connect")
-	err := c.Client.Connect()
+	err := codec.Connect(c.codec)
+	if err != nil {
+		return err
+	}
+
+	err = c.Client.Connect()
 	if err != nil {
 		return err
 	}
@@ -159,8 +167,11 @@ func (c *client) String() string {
 func (c *client) makePublish(
 	conn redis.Conn,
 ) (publishFn, error) {
-	if c.dataType == redisChannelType {
+	switch c.dataType {
+	case redisChannelType:
 		return c.makePublishPUBLISH(conn)
+	case redisStreamType:
+		return c.makePublishXADD(conn)
 	}
 	return c.makePublishRPUSH(conn)
 }
@@ -18,6 +18,7 @@
 package redis
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -39,9 +40,17 @@ type redisConfig struct {
 	Codec       codec.Config          `config:"codec"`
 	Db          int                   `config:"db"`
 	DataType    string                `config:"datatype"`
+	Stream      streamConfig          `config:"stream"`
 	Backoff     backoff               `config:"backoff"`
 }
 
+type streamConfig struct {
+	MaxLen      int    `config:"max_len" validate:"min=0"`
+	Approximate bool   `config:"approximate"`
+	Encoding    string `config:"encoding"`
+	Field       string `config:"field"`
+}
+
 type backoff struct {
 	Init time.Duration
 	Max  time.Duration
@@ -56,6 +65,12 @@ var (
 		TLS:         nil,
 		Db:          0,
 		DataType:    "list",
+		Stream: streamConfig{
+			MaxLen:      0,
+			Approximate: true,
+			Encoding:    "codec",
+			Field:       "event",
+		},
 		Backoff: backoff{
 			Init: 1 * time.Second,
 			Max:  60 * time.Second,
@@ -65,10 +80,30 @@ var (
 
 func (c *redisConfig) Validate() error {
 	switch c.DataType {
-	case "", "list", "channel":
+	case "", "list", "channel", "stream":
 	default:
 		return fmt.Errorf("redis data type %v not supported", c.DataType)
 	}
 
+	if c.DataType == "stream" {
+		if err := c.Stream.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *streamConfig) validate() error {
+	switch c.Encoding {
+	case "", "codec":
+		if c.Field == "" {
+			return errors.New("stream.field must be set when using the codec encoding")
+		}
+	case "fields":
+	default:
+		return fmt.Errorf("redis stream encoding %v not supported", c.Encoding)
+	}
+
 	return nil
 }
@@ -37,6 +37,10 @@ func TestValidate(t *testing.T) {
 		{"Invalid Datatype", redisConfig{Key: "test", DataType: "something"}, false},
 		{"List Datatype", redisConfig{Key: "test", DataType: "list"}, true},
 		{"Channel Datatype", redisConfig{Key: "test", DataType: "channel"}, true},
+		{"Stream Datatype", redisConfig{Key: "test", DataType: "stream", Stream: defaultConfig.Stream}, true},
+		{"Stream fields encoding", redisConfig{Key: "test", DataType: "stream", Stream: streamConfig{Encoding: "fields"}}, true},
+		{"Stream codec without field", redisConfig{Key: "test", DataType: "stream", Stream: streamConfig{Encoding: "codec"}}, false},
+		{"Stream invalid encoding", redisConfig{Key: "test", DataType: "stream", Stream: streamConfig{Encoding: "xml"}}, false},
 	}
 
 	for _, test := range tests {
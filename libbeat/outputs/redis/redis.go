@@ -86,10 +86,21 @@ func makeRedis(
 		dataType = redisListType
 	case "channel":
 		dataType = redisChannelType
+	case "stream":
+		dataType = redisStreamType
 	default:
 		return outputs.Fail(errors.New("Bad Redis data type"))
 	}
 
+	stream := streamSettings{
+		maxLen:      config.Stream.MaxLen,
+		approximate: config.Stream.Approximate,
+		field:       config.Stream.Field,
+	}
+	if config.Stream.Encoding == "fields" {
+		stream.encoding = streamEncodeFields
+	}
+
 	key, err := outil.BuildSelectorFromConfig(cfg, outil.Settings{
 		Key:              "key",
 		MultiKey:         "keys",
@@ -130,7 +141,7 @@ func makeRedis(
 		}
 
 		client := newClient(conn, observer, config.Timeout,
-			config.Password, config.Db, key, dataType, config.Index, enc)
+			config.Password, config.Db, key, dataType, stream, config.Index, enc)
 		clients[i] = newBackoffClient(client, config.Backoff.Init, config.Backoff.Max)
 	}
 
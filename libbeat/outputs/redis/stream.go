@@ -0,0 +1,187 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redis
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/garyburd/redigo/redis"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/publisher"
+)
+
+type streamEncoding uint8
+
+const (
+	streamEncodeCodec streamEncoding = iota
+	streamEncodeFields
+)
+
+// streamSettings configures how events are added to a Redis stream.
+type streamSettings struct {
+	maxLen      int
+	approximate bool
+	encoding    streamEncoding
+	field       string
+}
+
+func (c *client) makePublishXADD(conn redis.Conn) (publishFn, error) {
+	return c.publishEventsStream(conn), nil
+}
+
+// publishEventsStream pipelines one XADD command per event. Entries are
+// appended with an auto-generated ID, so consumer groups reading the stream
+// get every event that was acknowledged by Redis at least once.
+func (c *client) publishEventsStream(conn redis.Conn) publishFn {
+	return func(key outil.Selector, data []publisher.Event) ([]publisher.Event, error) {
+		okEvents := data[:0]
+		dropped := 0
+		for i := range data {
+			event := &data[i].Content
+
+			eventKey, err := key.Select(event)
+			if err != nil {
+				logp.Err("Failed to set redis key: %v", err)
+				dropped++
+				continue
+			}
+
+			fields, err := c.encodeStreamEntry(event)
+			if err != nil {
+				logp.Err("Encoding event failed with error: %v", err)
+				logp.Debug("redis", "Failed event: %v", event)
+				dropped++
+				continue
+			}
+
+			args := c.streamArgs(eventKey, fields)
+			if err := conn.Send("XADD", args...); err != nil {
+				logp.Err("Failed to execute XADD: %v", err)
+				c.observer.Dropped(dropped)
+				return append(okEvents, data[i:]...), err
+			}
+			okEvents = append(okEvents, data[i])
+		}
+		c.observer.Dropped(dropped)
+
+		if len(okEvents) == 0 {
+			return nil, nil
+		}
+
+		if err := conn.Flush(); err != nil {
+			return okEvents, err
+		}
+
+		failed := okEvents[:0]
+		var lastErr error
+		for i := range okEvents {
+			_, err := conn.Receive()
+			if err != nil {
+				if _, ok := err.(redis.Error); ok {
+					logp.Err("Failed to XADD event to stream with %v", err)
+					failed = append(failed, okEvents[i])
+					lastErr = err
+				} else {
+					logp.Err("Failed to XADD multiple events to stream with %v", err)
+					failed = append(failed, okEvents[i:]...)
+					lastErr = err
+					break
+				}
+			}
+		}
+
+		c.observer.Acked(len(okEvents) - len(failed))
+		if len(failed) == 0 {
+			return nil, nil
+		}
+		return failed, lastErr
+	}
+}
+
+// streamArgs builds the arguments for XADD, including the optional MAXLEN
+// trimming clause.
+func (c *client) streamArgs(key string, fields []interface{}) []interface{} {
+	args := make([]interface{}, 0, len(fields)+5)
+	args = append(args, key)
+	if c.stream.maxLen > 0 {
+		args = append(args, "MAXLEN")
+		if c.stream.approximate {
+			args = append(args, "~")
+		}
+		args = append(args, c.stream.maxLen)
+	}
+	args = append(args, "*")
+	return append(args, fields...)
+}
+
+// encodeStreamEntry returns the field/value pairs of the stream entry for
+// an event. With the codec encoding the complete event is stored in a single
+// field. With the fields encoding every event field is stored as its own
+// stream entry field, using the flattened field name as key.
+func (c *client) encodeStreamEntry(event *beat.Event) ([]interface{}, error) {
+	if c.stream.encoding == streamEncodeCodec {
+		serialized, err := c.codec.Encode(c.index, event)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, len(serialized))
+		copy(buf, serialized)
+		return []interface{}{c.stream.field, buf}, nil
+	}
+
+	return encodeStreamFields(event)
+}
+
+func encodeStreamFields(event *beat.Event) ([]interface{}, error) {
+	flat := event.Fields.Flatten()
+
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]interface{}, 0, 2*len(keys)+2)
+	fields = append(fields, "@timestamp", common.Time(event.Timestamp).String())
+	for _, k := range keys {
+		value, err := encodeStreamValue(flat[k])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, k, value)
+	}
+	return fields, nil
+}
+
+// encodeStreamValue stores strings as is and JSON encodes all other values.
+func encodeStreamValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		return val, nil
+	default:
+		return json.Marshal(val)
+	}
+}
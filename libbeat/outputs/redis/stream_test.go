@@ -0,0 +1,167 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package redis
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/codec/json"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/publisher"
+)
+
+type command struct {
+	name string
+	args []interface{}
+}
+
+type mockConn struct {
+	sent    []command
+	replies []error
+}
+
+func (c *mockConn) Close() error { return nil }
+func (c *mockConn) Err() error   { return nil }
+func (c *mockConn) Flush() error { return nil }
+
+func (c *mockConn) Do(name string, args ...interface{}) (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *mockConn) Send(name string, args ...interface{}) error {
+	c.sent = append(c.sent, command{name, args})
+	return nil
+}
+
+func (c *mockConn) Receive() (interface{}, error) {
+	if len(c.replies) == 0 {
+		return "1-0", nil
+	}
+	err := c.replies[0]
+	c.replies = c.replies[1:]
+	if err != nil {
+		return nil, err
+	}
+	return "1-0", nil
+}
+
+func testStreamClient(stream streamSettings) *client {
+	key := outil.MakeSelector(outil.ConstSelectorExpr("beats"))
+	return newClient(nil, outputs.NewNilObserver(), time.Second, "", 0, key,
+		redisStreamType, stream, "testbeat", json.New("1.2.3", json.Config{}))
+}
+
+func testStreamEvents() []publisher.Event {
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []publisher.Event{
+		{Content: beat.Event{Timestamp: ts, Fields: common.MapStr{"message": "hello"}}},
+		{Content: beat.Event{Timestamp: ts, Fields: common.MapStr{"message": "world"}}},
+	}
+}
+
+func TestStreamArgs(t *testing.T) {
+	tests := map[string]struct {
+		stream   streamSettings
+		expected []interface{}
+	}{
+		"no trimming": {
+			stream:   streamSettings{},
+			expected: []interface{}{"beats", "*", "f", "v"},
+		},
+		"exact trimming": {
+			stream:   streamSettings{maxLen: 100},
+			expected: []interface{}{"beats", "MAXLEN", 100, "*", "f", "v"},
+		},
+		"approximate trimming": {
+			stream:   streamSettings{maxLen: 100, approximate: true},
+			expected: []interface{}{"beats", "MAXLEN", "~", 100, "*", "f", "v"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := testStreamClient(test.stream)
+			args := c.streamArgs("beats", []interface{}{"f", "v"})
+			assert.Equal(t, test.expected, args)
+		})
+	}
+}
+
+func TestEncodeStreamFields(t *testing.T) {
+	event := &beat.Event{
+		Timestamp: time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+		Fields: common.MapStr{
+			"message": "hello",
+			"host":    common.MapStr{"name": "test"},
+			"count":   42,
+			"tags":    []string{"a", "b"},
+		},
+	}
+
+	fields, err := encodeStreamFields(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []interface{}{
+		"@timestamp", "2019-01-02T03:04:05.000Z",
+		"count", []byte("42"),
+		"host.name", "test",
+		"message", "hello",
+		"tags", []byte(`["a","b"]`),
+	}, fields)
+}
+
+func TestPublishStream(t *testing.T) {
+	conn := &mockConn{}
+	c := testStreamClient(streamSettings{maxLen: 10, approximate: true, field: "event"})
+
+	rest, err := c.publishEventsStream(conn)(c.key, testStreamEvents())
+	assert.NoError(t, err)
+	assert.Nil(t, rest)
+
+	if assert.Len(t, conn.sent, 2) {
+		for _, cmd := range conn.sent {
+			assert.Equal(t, "XADD", cmd.name)
+			assert.Equal(t, []interface{}{"beats", "MAXLEN", "~", 10, "*", "event"}, cmd.args[:6])
+			assert.Contains(t, string(cmd.args[6].([]byte)), `"message":"`)
+		}
+	}
+}
+
+func TestPublishStreamRetryFailed(t *testing.T) {
+	conn := &mockConn{replies: []error{nil, redis.Error("OOM command not allowed")}}
+	c := testStreamClient(streamSettings{field: "event"})
+
+	events := testStreamEvents()
+	rest, err := c.publishEventsStream(conn)(c.key, events)
+	assert.Error(t, err)
+	if assert.Len(t, rest, 1) {
+		assert.Equal(t, "world", rest[0].Content.Fields["message"])
+	}
+}
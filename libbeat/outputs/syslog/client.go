@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslog
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/publisher"
+)
+
+type framing uint8
+
+const (
+	// framingNone sends every message as is. Used with UDP, where each
+	// message is sent in its own datagram.
+	framingNone framing = iota
+
+	// framingOctetCounted prefixes every message with its length (RFC 6587).
+	framingOctetCounted
+
+	// framingNewline terminates every message with a newline character
+	// (RFC 6587 non-transparent framing).
+	framingNewline
+)
+
+type client struct {
+	*transport.Client
+	observer outputs.Observer
+	timeout  time.Duration
+	framing  framing
+	format   *formatter
+	buf      bytes.Buffer
+}
+
+func newClient(
+	tc *transport.Client,
+	observer outputs.Observer,
+	timeout time.Duration,
+	framing framing,
+	format *formatter,
+) *client {
+	return &client{
+		Client:   tc,
+		observer: observer,
+		timeout:  timeout,
+		framing:  framing,
+		format:   format,
+	}
+}
+
+func (c *client) Publish(batch publisher.Batch) error {
+	events := batch.Events()
+	c.observer.NewBatch(len(events))
+
+	dropped := 0
+	for i := range events {
+		msg, err := c.format.Format(&events[i].Content)
+		if err != nil {
+			logp.Err("Failed to format syslog message: %v", err)
+			dropped++
+			continue
+		}
+
+		if err := c.send(msg); err != nil {
+			logp.Err("Failed to send syslog message: %v", err)
+			rest := events[i:]
+			c.observer.Dropped(dropped)
+			c.observer.Acked(i - dropped)
+			c.observer.Failed(len(rest))
+			batch.RetryEvents(rest)
+			return err
+		}
+	}
+
+	c.observer.Dropped(dropped)
+	c.observer.Acked(len(events) - dropped)
+	batch.ACK()
+	return nil
+}
+
+func (c *client) send(msg []byte) error {
+	c.buf.Reset()
+	switch c.framing {
+	case framingOctetCounted:
+		c.buf.WriteString(strconv.Itoa(len(msg)))
+		c.buf.WriteByte(' ')
+		c.buf.Write(msg)
+	case framingNewline:
+		c.buf.Write(msg)
+		c.buf.WriteByte('\n')
+	default:
+		c.buf.Write(msg)
+	}
+
+	if c.timeout > 0 {
+		if err := c.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+			return err
+		}
+	}
+	_, err := c.Write(c.buf.Bytes())
+	return err
+}
+
+func (c *client) String() string {
+	return "syslog(" + c.Client.String() + ")"
+}
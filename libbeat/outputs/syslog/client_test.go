@@ -0,0 +1,150 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/outest"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+func makeEvents(msgs ...string) []beat.Event {
+	events := make([]beat.Event, len(msgs))
+	for i, msg := range msgs {
+		events[i] = beat.Event{
+			Timestamp: time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+			Fields:    common.MapStr{"message": msg},
+		}
+	}
+	return events
+}
+
+func newTestClient(t *testing.T, network, addr string, framing framing) *client {
+	conn, err := transport.NewClient(&transport.Config{Timeout: time.Second}, network, addr, defaultPort)
+	require.NoError(t, err)
+
+	f := testFormatter("rfc3164", map[string]string{"message": "%{[message]}"})
+	c := newClient(conn, outputs.NewNilObserver(), time.Second, framing, f)
+	require.NoError(t, c.Connect())
+	return c
+}
+
+func TestPublishTCP(t *testing.T) {
+	tests := map[string]struct {
+		framing  framing
+		expected string
+	}{
+		"octet counted": {
+			framing: framingOctetCounted,
+			expected: "38 <14>Jan  2 03:04:05 myhost testbeat: a" +
+				"38 <14>Jan  2 03:04:05 myhost testbeat: b",
+		},
+		"newline": {
+			framing: framingNewline,
+			expected: "<14>Jan  2 03:04:05 myhost testbeat: a\n" +
+				"<14>Jan  2 03:04:05 myhost testbeat: b\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer l.Close()
+
+			received := make(chan string, 1)
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					received <- ""
+					return
+				}
+				defer conn.Close()
+
+				buf := make([]byte, len(test.expected))
+				_, err = io.ReadFull(bufio.NewReader(conn), buf)
+				received <- string(buf)
+			}()
+
+			c := newTestClient(t, "tcp", l.Addr().String(), test.framing)
+			defer c.Close()
+
+			batch := outest.NewBatch(makeEvents("a", "b")...)
+			require.NoError(t, c.Publish(batch))
+			require.Len(t, batch.Signals, 1)
+			assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+
+			select {
+			case msg := <-received:
+				assert.Equal(t, test.expected, msg)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for messages")
+			}
+		})
+	}
+}
+
+func TestPublishUDP(t *testing.T) {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	c := newTestClient(t, "udp", l.LocalAddr().String(), framingNone)
+	defer c.Close()
+
+	batch := outest.NewBatch(makeEvents("a", "b")...)
+	require.NoError(t, c.Publish(batch))
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchACK, batch.Signals[0].Tag)
+
+	l.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	for _, expected := range []string{"a", "b"} {
+		n, _, err := l.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "<14>Jan  2 03:04:05 myhost testbeat: "+expected, string(buf[:n]))
+	}
+}
+
+func TestPublishRetryOnError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	c := newTestClient(t, "tcp", l.Addr().String(), framingNewline)
+	c.Close()
+
+	batch := outest.NewBatch(makeEvents("a", "b")...)
+	assert.Error(t, c.Publish(batch))
+	require.Len(t, batch.Signals, 1)
+	assert.Equal(t, outest.BatchRetryEvents, batch.Signals[0].Tag)
+	assert.Len(t, batch.Signals[0].Events, 2)
+}
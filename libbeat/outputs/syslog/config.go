@@ -0,0 +1,110 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslog
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/outputs/codec"
+)
+
+type syslogConfig struct {
+	Network     string            `config:"network"`
+	Framing     string            `config:"framing"`
+	Format      string            `config:"format"`
+	LoadBalance bool              `config:"loadbalance"`
+	Timeout     time.Duration     `config:"timeout"`
+	BulkMaxSize int               `config:"bulk_max_size"`
+	MaxRetries  int               `config:"max_retries"`
+	TLS         *tlscommon.Config `config:"ssl"`
+	Codec       codec.Config      `config:"codec"`
+	Backoff     backoff           `config:"backoff"`
+
+	Facility *fmtstr.EventFormatString `config:"facility"`
+	Severity *fmtstr.EventFormatString `config:"severity"`
+	Hostname *fmtstr.EventFormatString `config:"hostname"`
+	AppName  *fmtstr.EventFormatString `config:"appname"`
+	ProcID   *fmtstr.EventFormatString `config:"procid"`
+	MsgID    *fmtstr.EventFormatString `config:"msgid"`
+	Message  *fmtstr.EventFormatString `config:"message"`
+}
+
+type backoff struct {
+	Init time.Duration
+	Max  time.Duration
+}
+
+var (
+	defaultConfig = syslogConfig{
+		Network:     "udp",
+		Framing:     "octet_counted",
+		Format:      "rfc5424",
+		LoadBalance: false,
+		Timeout:     5 * time.Second,
+		BulkMaxSize: 2048,
+		MaxRetries:  3,
+		TLS:         nil,
+		Backoff: backoff{
+			Init: 1 * time.Second,
+			Max:  60 * time.Second,
+		},
+	}
+)
+
+func (c *syslogConfig) Validate() error {
+	switch c.Network {
+	case "udp":
+		if c.TLS.IsEnabled() {
+			return errors.New("TLS is only supported with the tcp network")
+		}
+	case "tcp":
+	default:
+		return fmt.Errorf("syslog network %v not supported", c.Network)
+	}
+
+	switch c.Framing {
+	case "octet_counted", "newline":
+	default:
+		return fmt.Errorf("syslog framing %v not supported", c.Framing)
+	}
+
+	switch c.Format {
+	case "rfc5424", "rfc3164":
+	default:
+		return fmt.Errorf("syslog format %v not supported", c.Format)
+	}
+
+	if c.Facility != nil && c.Facility.IsConst() {
+		s, _ := c.Facility.Run(nil)
+		if _, err := parseFacility(s); err != nil {
+			return err
+		}
+	}
+	if c.Severity != nil && c.Severity.IsConst() {
+		s, _ := c.Severity.Run(nil)
+		if _, err := parseSeverity(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/outputs/codec"
+)
+
+const (
+	nilValue = "-"
+
+	defaultFacility = 1 // user-level messages
+	defaultSeverity = 6 // informational
+
+	rfc5424TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+	rfc3164TimeFormat = time.Stamp
+)
+
+var facilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"ntp":      12,
+	"security": 13,
+	"console":  14,
+	"solaris":  15,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+var severities = map[string]int{
+	"emerg":         0,
+	"emergency":     0,
+	"alert":         1,
+	"crit":          2,
+	"critical":      2,
+	"err":           3,
+	"error":         3,
+	"warn":          4,
+	"warning":       4,
+	"notice":        5,
+	"info":          6,
+	"informational": 6,
+	"debug":         7,
+}
+
+// formatter converts events into RFC 5424 or RFC 3164 syslog messages.
+type formatter struct {
+	rfc3164  bool
+	location *time.Location
+
+	facility *fmtstr.EventFormatString
+	severity *fmtstr.EventFormatString
+	hostname *fmtstr.EventFormatString
+	appName  *fmtstr.EventFormatString
+	procID   *fmtstr.EventFormatString
+	msgID    *fmtstr.EventFormatString
+	message  *fmtstr.EventFormatString
+
+	defaultHostname string
+	defaultAppName  string
+
+	index string
+	codec codec.Codec
+}
+
+func newFormatter(beat beat.Info, config *syslogConfig, enc codec.Codec) *formatter {
+	return &formatter{
+		rfc3164:         config.Format == "rfc3164",
+		location:        time.Local,
+		facility:        config.Facility,
+		severity:        config.Severity,
+		hostname:        config.Hostname,
+		appName:         config.AppName,
+		procID:          config.ProcID,
+		msgID:           config.MsgID,
+		message:         config.Message,
+		defaultHostname: beat.Hostname,
+		defaultAppName:  beat.Beat,
+		index:           beat.Beat,
+		codec:           enc,
+	}
+}
+
+// Format serializes the event into a syslog message, without any transport
+// specific framing.
+func (f *formatter) Format(event *beat.Event) ([]byte, error) {
+	msg, err := f.formatMessage(event)
+	if err != nil {
+		return nil, err
+	}
+
+	facility := defaultFacility
+	if s := f.eval(f.facility, event, ""); s != "" {
+		if v, err := parseFacility(s); err == nil {
+			facility = v
+		} else {
+			debugf("Invalid facility, using default: %v", err)
+		}
+	}
+
+	severity := defaultSeverity
+	if s := f.eval(f.severity, event, ""); s != "" {
+		if v, err := parseSeverity(s); err == nil {
+			severity = v
+		} else {
+			debugf("Invalid severity, using default: %v", err)
+		}
+	}
+
+	hostname := f.eval(f.hostname, event, f.defaultHostname)
+	appName := f.eval(f.appName, event, f.defaultAppName)
+	procID := f.eval(f.procID, event, "")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>", facility*8+severity)
+	if f.rfc3164 {
+		buf.WriteString(event.Timestamp.In(f.location).Format(rfc3164TimeFormat))
+		buf.WriteByte(' ')
+		buf.WriteString(header(hostname, 255))
+		buf.WriteByte(' ')
+		buf.WriteString(tag(appName))
+		if procID != "" {
+			buf.WriteByte('[')
+			buf.WriteString(header(procID, 128))
+			buf.WriteByte(']')
+		}
+		buf.WriteString(": ")
+	} else {
+		msgID := f.eval(f.msgID, event, "")
+
+		buf.WriteString("1 ")
+		buf.WriteString(event.Timestamp.UTC().Format(rfc5424TimeFormat))
+		buf.WriteByte(' ')
+		buf.WriteString(header(hostname, 255))
+		buf.WriteByte(' ')
+		buf.WriteString(header(appName, 48))
+		buf.WriteByte(' ')
+		buf.WriteString(header(procID, 128))
+		buf.WriteByte(' ')
+		buf.WriteString(header(msgID, 32))
+		buf.WriteString(" - ")
+	}
+	buf.Write(msg)
+
+	return buf.Bytes(), nil
+}
+
+// formatMessage returns the MSG part. If no message format string is
+// configured, the complete event is encoded using the configured codec.
+func (f *formatter) formatMessage(event *beat.Event) ([]byte, error) {
+	if f.message != nil {
+		return f.message.RunBytes(event)
+	}
+
+	serialized, err := f.codec.Encode(f.index, event)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, len(serialized))
+	copy(buf, serialized)
+	return buf, nil
+}
+
+func (f *formatter) eval(fs *fmtstr.EventFormatString, event *beat.Event, fallback string) string {
+	if fs == nil {
+		return fallback
+	}
+
+	s, err := fs.Run(event)
+	if err != nil || s == "" {
+		return fallback
+	}
+	return s
+}
+
+// header sanitizes a header field, replacing characters that are not
+// printable US-ASCII and truncating it to max bytes. Empty values are
+// replaced by the NILVALUE.
+func header(s string, max int) string {
+	if s == "" {
+		return nilValue
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	return strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// tag builds a RFC 3164 TAG, which must be alphanumeric and at most 32
+// characters long.
+func tag(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') ||
+			r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, s)
+	if len(s) > 32 {
+		s = s[:32]
+	}
+	if s == "" {
+		return nilValue
+	}
+	return s
+}
+
+func parseFacility(s string) (int, error) {
+	return parseCode(s, facilities, 23, "facility")
+}
+
+func parseSeverity(s string) (int, error) {
+	return parseCode(s, severities, 7, "severity")
+}
+
+func parseCode(s string, names map[string]int, max int, kind string) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 || v > max {
+		return 0, fmt.Errorf("invalid syslog %v '%v'", kind, s)
+	}
+	return v, nil
+}
@@ -0,0 +1,162 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// +build !integration
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/outputs/codec/json"
+)
+
+func testEvent() *beat.Event {
+	return &beat.Event{
+		Timestamp: time.Date(2019, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		Fields: common.MapStr{
+			"message": "hello world",
+			"log": common.MapStr{
+				"level": "error",
+			},
+			"process": common.MapStr{
+				"name": "my app",
+				"pid":  1234,
+			},
+		},
+	}
+}
+
+func testFormatter(format string, settings map[string]string) *formatter {
+	config := defaultConfig
+	config.Format = format
+
+	formats := map[string]**fmtstr.EventFormatString{
+		"facility": &config.Facility,
+		"severity": &config.Severity,
+		"hostname": &config.Hostname,
+		"appname":  &config.AppName,
+		"procid":   &config.ProcID,
+		"msgid":    &config.MsgID,
+		"message":  &config.Message,
+	}
+	for name, value := range settings {
+		*formats[name] = fmtstr.MustCompileEvent(value)
+	}
+
+	info := beat.Info{Beat: "testbeat", Hostname: "myhost", Version: "1.2.3"}
+	f := newFormatter(info, &config, json.New(info.Version, json.Config{}))
+	f.location = time.UTC
+	return f
+}
+
+func TestFormat(t *testing.T) {
+	tests := map[string]struct {
+		format   string
+		settings map[string]string
+		expected string
+	}{
+		"rfc5424 defaults": {
+			format:   "rfc5424",
+			settings: map[string]string{"message": "%{[message]}"},
+			expected: "<14>1 2019-01-02T03:04:05.123456Z myhost testbeat - - - hello world",
+		},
+		"rfc5424 from event fields": {
+			format: "rfc5424",
+			settings: map[string]string{
+				"facility": "local3",
+				"severity": "%{[log.level]}",
+				"hostname": "web-01",
+				"appname":  "%{[process.name]}",
+				"procid":   "%{[process.pid]}",
+				"msgid":    "ID47",
+				"message":  "%{[message]}",
+			},
+			expected: "<155>1 2019-01-02T03:04:05.123456Z web-01 my_app 1234 ID47 - hello world",
+		},
+		"rfc5424 invalid severity uses default": {
+			format:   "rfc5424",
+			settings: map[string]string{"severity": "%{[message]}", "message": "%{[message]}"},
+			expected: "<14>1 2019-01-02T03:04:05.123456Z myhost testbeat - - - hello world",
+		},
+		"rfc5424 missing field uses default": {
+			format:   "rfc5424",
+			settings: map[string]string{"hostname": "%{[host.name]}", "message": "%{[message]}"},
+			expected: "<14>1 2019-01-02T03:04:05.123456Z myhost testbeat - - - hello world",
+		},
+		"rfc3164 defaults": {
+			format:   "rfc3164",
+			settings: map[string]string{"message": "%{[message]}"},
+			expected: "<14>Jan  2 03:04:05 myhost testbeat: hello world",
+		},
+		"rfc3164 with procid": {
+			format: "rfc3164",
+			settings: map[string]string{
+				"facility": "4",
+				"severity": "warning",
+				"appname":  "%{[process.name]}",
+				"procid":   "%{[process.pid]}",
+				"message":  "%{[message]}",
+			},
+			expected: "<36>Jan  2 03:04:05 myhost my_app[1234]: hello world",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := testFormatter(test.format, test.settings)
+			msg, err := f.Format(testEvent())
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.expected, string(msg))
+		})
+	}
+}
+
+func TestFormatCodec(t *testing.T) {
+	f := testFormatter("rfc5424", nil)
+	msg, err := f.Format(testEvent())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, string(msg), `<14>1 2019-01-02T03:04:05.123456Z myhost testbeat - - - {"@timestamp":`)
+	assert.Contains(t, string(msg), `"message":"hello world"`)
+}
+
+func TestParseCodes(t *testing.T) {
+	v, err := parseFacility("LOCAL7")
+	assert.NoError(t, err)
+	assert.Equal(t, 23, v)
+
+	v, err = parseSeverity("3")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v)
+
+	_, err = parseFacility("24")
+	assert.Error(t, err)
+
+	_, err = parseSeverity("verbose")
+	assert.Error(t, err)
+}
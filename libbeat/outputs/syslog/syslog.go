@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package syslog
+
+import (
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/codec"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+var debugf = logp.MakeDebug("syslog")
+
+const (
+	defaultPort    = 514
+	defaultTLSPort = 6514
+)
+
+func init() {
+	outputs.RegisterType("syslog", makeSyslog)
+}
+
+func makeSyslog(
+	_ outputs.IndexManager,
+	beat beat.Info,
+	observer outputs.Observer,
+	cfg *common.Config,
+) (outputs.Group, error) {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return outputs.Fail(err)
+	}
+
+	hosts, err := outputs.ReadHostList(cfg)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	tls, err := tlscommon.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return outputs.Fail(err)
+	}
+
+	port := defaultPort
+	if tls != nil {
+		port = defaultTLSPort
+	}
+
+	transp := &transport.Config{
+		Timeout: config.Timeout,
+		TLS:     tls,
+		Stats:   observer,
+	}
+
+	clients := make([]outputs.NetworkClient, len(hosts))
+	for i, host := range hosts {
+		enc, err := codec.CreateEncoder(beat, config.Codec)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+
+		conn, err := transport.NewClient(transp, config.Network, host, port)
+		if err != nil {
+			return outputs.Fail(err)
+		}
+
+		framing := framingNone
+		if config.Network == "tcp" {
+			framing = framingOctetCounted
+			if config.Framing == "newline" {
+				framing = framingNewline
+			}
+		}
+
+		client := newClient(conn, observer, config.Timeout, framing,
+			newFormatter(beat, &config, enc))
+		clients[i] = outputs.WithBackoff(client, config.Backoff.Init, config.Backoff.Max)
+	}
+
+	return outputs.SuccessNet(config.LoadBalance, config.BulkMaxSize, config.MaxRetries, clients)
+}
@@ -0,0 +1,229 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package actions
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/jsontransform"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/processors"
+	"github.com/elastic/beats/libbeat/processors/checks"
+	jsprocessor "github.com/elastic/beats/libbeat/processors/script/javascript/module/processor"
+)
+
+const (
+	decodeXMLName = "decode_xml"
+
+	// xmlTextKey is the key holding the text of elements having attributes or
+	// child elements.
+	xmlTextKey = "#text"
+)
+
+type decodeXML struct {
+	config xmlConfig
+	log    *logp.Logger
+}
+
+type xmlConfig struct {
+	Field            string   `config:"field" validate:"required"`
+	Target           *string  `config:"target_field"`
+	OverwriteKeys    bool     `config:"overwrite_keys"`
+	IgnoreAttributes bool     `config:"ignore_attributes"`
+	AttributePrefix  string   `config:"attribute_prefix"`
+	ToLower          bool     `config:"to_lower"`
+	IgnoreMissing    bool     `config:"ignore_missing"`
+	IgnoreFailure    bool     `config:"ignore_failure"`
+	TagOnFailure     []string `config:"tag_on_failure"`
+}
+
+func init() {
+	processors.RegisterPlugin(decodeXMLName,
+		checks.ConfigChecked(NewDecodeXML,
+			checks.RequireFields("field"),
+			checks.AllowedFields("field", "target_field", "overwrite_keys", "ignore_attributes",
+				"attribute_prefix", "to_lower", "ignore_missing", "ignore_failure", "tag_on_failure", "when")))
+	jsprocessor.RegisterPlugin("DecodeXML", NewDecodeXML)
+}
+
+// NewDecodeXML constructs a new decode_xml processor.
+func NewDecodeXML(c *common.Config) (processors.Processor, error) {
+	config := xmlConfig{
+		ToLower: true,
+	}
+
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, fmt.Errorf("fail to unpack the %s configuration: %s", decodeXMLName, err)
+	}
+
+	return &decodeXML{
+		config: config,
+		log:    logp.NewLogger(decodeXMLName),
+	}, nil
+}
+
+func (x *decodeXML) Run(event *beat.Event) (*beat.Event, error) {
+	err := x.decodeField(event)
+	if err != nil {
+		errMsg := fmt.Errorf("failed to decode xml field in processor: %v", err)
+		x.log.Debug(errMsg.Error())
+
+		event.PutValue("error.message", errMsg.Error())
+		if len(x.config.TagOnFailure) > 0 {
+			common.AddTags(event.Fields, x.config.TagOnFailure)
+		}
+		if !x.config.IgnoreFailure {
+			return event, err
+		}
+	}
+	return event, nil
+}
+
+func (x *decodeXML) String() string {
+	return fmt.Sprintf("%s=%+v", decodeXMLName, x.config.Field)
+}
+
+func (x *decodeXML) decodeField(event *beat.Event) error {
+	value, err := event.GetValue(x.config.Field)
+	if err != nil {
+		if x.config.IgnoreMissing && errors.Cause(err) == common.ErrKeyNotFound {
+			return nil
+		}
+		return fmt.Errorf("could not fetch xml value for key: %s, Error: %v", x.config.Field, err)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("invalid type for `field`, expecting a string received %T", value)
+	}
+
+	decoded, err := x.decode(text)
+	if err != nil {
+		return err
+	}
+
+	target := x.config.Field
+	if x.config.Target != nil {
+		target = *x.config.Target
+	}
+
+	if target == "" {
+		jsontransform.WriteJSONKeys(event, decoded, x.config.OverwriteKeys, false)
+		return nil
+	}
+
+	if _, err = event.PutValue(target, decoded); err != nil {
+		return fmt.Errorf("could not put value: %v, %v", target, err)
+	}
+	return nil
+}
+
+// decode converts an XML document to a map, keyed by the name of the root
+// element. Repeated elements are converted to arrays. Elements without
+// attributes and child elements are converted to strings.
+func (x *decodeXML) decode(text string) (common.MapStr, error) {
+	dec := xml.NewDecoder(strings.NewReader(text))
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return nil, errors.New("xml document without root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			root, err := x.decodeElement(dec, start)
+			if err != nil {
+				return nil, err
+			}
+			return common.MapStr{x.key(start.Name.Local): root}, nil
+		}
+	}
+}
+
+func (x *decodeXML) decodeElement(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	fields := common.MapStr{}
+	if !x.config.IgnoreAttributes {
+		for _, attr := range start.Attr {
+			if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+				continue
+			}
+			fields[x.config.AttributePrefix+x.key(attr.Name.Local)] = attr.Value
+		}
+	}
+
+	var text strings.Builder
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := x.decodeElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			addXMLChild(fields, x.key(t.Name.Local), child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(fields) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				fields[xmlTextKey] = content
+			}
+			return fields, nil
+		}
+	}
+}
+
+// addXMLChild adds a child element to fields, converting the value to an
+// array if an element of the same name already exists.
+func addXMLChild(fields common.MapStr, key string, child interface{}) {
+	existing, found := fields[key]
+	if !found {
+		fields[key] = child
+		return
+	}
+
+	if list, ok := existing.([]interface{}); ok {
+		fields[key] = append(list, child)
+		return
+	}
+	fields[key] = []interface{}{existing, child}
+}
+
+func (x *decodeXML) key(name string) string {
+	if x.config.ToLower {
+		return strings.ToLower(name)
+	}
+	return name
+}
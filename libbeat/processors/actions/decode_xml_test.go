@@ -0,0 +1,145 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestDecodeXML(t *testing.T) {
+	var testCases = []struct {
+		description string
+		config      common.MapStr
+		Input       common.MapStr
+		Output      common.MapStr
+		error       bool
+	}{
+		{
+			description: "decode into same field",
+			config:      common.MapStr{"field": "message"},
+			Input: common.MapStr{
+				"message": `<?xml version="1.0"?><catalog><book id="b1"><Author>William H. Gaddis</Author><title>The Recognitions</title></book></catalog>`,
+			},
+			Output: common.MapStr{
+				"message": common.MapStr{
+					"catalog": common.MapStr{
+						"book": common.MapStr{
+							"id":     "b1",
+							"author": "William H. Gaddis",
+							"title":  "The Recognitions",
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "repeated elements, attribute prefix and case",
+			config: common.MapStr{
+				"field":            "message",
+				"target_field":     "xml",
+				"attribute_prefix": "@",
+				"to_lower":         false,
+			},
+			Input: common.MapStr{
+				"message": `<items><Item lang="en">one</Item><Item>two</Item><Item>three</Item></items>`,
+			},
+			Output: common.MapStr{
+				"message": `<items><Item lang="en">one</Item><Item>two</Item><Item>three</Item></items>`,
+				"xml": common.MapStr{
+					"items": common.MapStr{
+						"Item": []interface{}{
+							common.MapStr{"@lang": "en", "#text": "one"},
+							"two",
+							"three",
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "ignore attributes and write to root",
+			config: common.MapStr{
+				"field":             "message",
+				"target_field":      "",
+				"ignore_attributes": true,
+			},
+			Input: common.MapStr{
+				"message": `<event xmlns="urn:test" type="login"><user>alice</user><empty/></event>`,
+			},
+			Output: common.MapStr{
+				"message": `<event xmlns="urn:test" type="login"><user>alice</user><empty/></event>`,
+				"event": common.MapStr{
+					"user":  "alice",
+					"empty": "",
+				},
+			},
+		},
+		{
+			description: "invalid xml",
+			config: common.MapStr{
+				"field":          "message",
+				"tag_on_failure": []string{"_xml_failure"},
+			},
+			Input: common.MapStr{
+				"message": `<open>`,
+			},
+			Output: common.MapStr{
+				"message": `<open>`,
+				"error":   common.MapStr{"message": "failed to decode xml field in processor: XML syntax error on line 1: unexpected EOF"},
+				"tags":    []string{"_xml_failure"},
+			},
+			error: true,
+		},
+		{
+			description: "missing field ignored",
+			config:      common.MapStr{"field": "message", "ignore_missing": true},
+			Input:       common.MapStr{"other": "value"},
+			Output:      common.MapStr{"other": "value"},
+		},
+		{
+			description: "missing field",
+			config:      common.MapStr{"field": "message", "ignore_failure": true},
+			Input:       common.MapStr{"other": "value"},
+			Output: common.MapStr{
+				"other": "value",
+				"error": common.MapStr{"message": "failed to decode xml field in processor: could not fetch xml value for key: message, Error: key not found"},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			p, err := NewDecodeXML(common.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: test.Input.Clone()})
+			if test.error {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.Output, event.Fields)
+		})
+	}
+}
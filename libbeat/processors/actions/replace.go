@@ -0,0 +1,190 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package actions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/processors"
+	"github.com/elastic/beats/libbeat/processors/checks"
+	jsprocessor "github.com/elastic/beats/libbeat/processors/script/javascript/module/processor"
+)
+
+type replaceString struct {
+	config   replaceStringConfig
+	replacer []fieldReplacer
+}
+
+type replaceStringConfig struct {
+	Fields        []replaceConfig `config:"fields"`
+	IgnoreMissing bool            `config:"ignore_missing"`
+	FailOnError   bool            `config:"fail_on_error"`
+}
+
+// replaceConfig describes how the value of a field is rewritten. Pattern and
+// replacement are only used by the replace operation.
+type replaceConfig struct {
+	Field       string `config:"field" validate:"required"`
+	Operation   string `config:"operation"`
+	Pattern     string `config:"pattern"`
+	Replacement string `config:"replacement"`
+}
+
+type fieldReplacer struct {
+	field   string
+	replace func(string) string
+}
+
+func init() {
+	processors.RegisterPlugin("replace",
+		checks.ConfigChecked(NewReplaceString,
+			checks.RequireFields("fields"),
+			checks.AllowedFields("fields", "ignore_missing", "fail_on_error", "when"),
+		),
+	)
+	jsprocessor.RegisterPlugin("Replace", NewReplaceString)
+}
+
+// NewReplaceString returns a new replace processor.
+func NewReplaceString(c *common.Config) (processors.Processor, error) {
+	config := replaceStringConfig{
+		IgnoreMissing: false,
+		FailOnError:   true,
+	}
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack the replace configuration: %s", err)
+	}
+
+	f := &replaceString{
+		config: config,
+	}
+	for _, field := range config.Fields {
+		replace, err := newStringReplacer(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replace configuration for field %s: %s", field.Field, err)
+		}
+		f.replacer = append(f.replacer, fieldReplacer{field: field.Field, replace: replace})
+	}
+	return f, nil
+}
+
+func newStringReplacer(c replaceConfig) (func(string) string, error) {
+	operation := strings.ToLower(c.Operation)
+	if operation == "" {
+		operation = "replace"
+	}
+	if operation != "replace" && c.Pattern != "" {
+		return nil, fmt.Errorf("pattern can only be used with the replace operation")
+	}
+
+	switch operation {
+	case "replace":
+		if c.Pattern == "" {
+			return nil, fmt.Errorf("pattern is required")
+		}
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string) string {
+			return re.ReplaceAllString(s, c.Replacement)
+		}, nil
+	case "lowercase":
+		return strings.ToLower, nil
+	case "uppercase":
+		return strings.ToUpper, nil
+	case "trim":
+		return strings.TrimSpace, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %s", c.Operation)
+	}
+}
+
+func (f *replaceString) Run(event *beat.Event) (*beat.Event, error) {
+	var backup common.MapStr
+	if f.config.FailOnError {
+		backup = event.Fields.Clone()
+	}
+
+	for _, r := range f.replacer {
+		err := f.replaceField(r, event.Fields)
+		if err != nil {
+			errMsg := fmt.Errorf("Failed to replace fields in replace processor: %s", err)
+			logp.Debug("replace", "%s", errMsg)
+			if f.config.FailOnError {
+				event.Fields = backup
+				event.PutValue("error.message", errMsg.Error())
+				return event, err
+			}
+		}
+	}
+
+	return event, nil
+}
+
+func (f *replaceString) replaceField(r fieldReplacer, fields common.MapStr) error {
+	value, err := fields.GetValue(r.field)
+	if err != nil {
+		if f.config.IgnoreMissing && errors.Cause(err) == common.ErrKeyNotFound {
+			return nil
+		}
+		return fmt.Errorf("could not fetch value for key: %s, Error: %s", r.field, err)
+	}
+
+	var replaced interface{}
+	switch v := value.(type) {
+	case string:
+		replaced = r.replace(v)
+	case []string:
+		list := make([]string, len(v))
+		for i, s := range v {
+			list[i] = r.replace(s)
+		}
+		replaced = list
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, elem := range v {
+			s, ok := elem.(string)
+			if !ok {
+				return fmt.Errorf("value of key %s contains a non-string element: %v", r.field, elem)
+			}
+			list[i] = r.replace(s)
+		}
+		replaced = list
+	default:
+		return fmt.Errorf("value of key %s is not a string: %v", r.field, value)
+	}
+
+	_, err = fields.Put(r.field, replaced)
+	if err != nil {
+		return fmt.Errorf("could not put value: %s: %v, %+v", r.field, replaced, err)
+	}
+	return nil
+}
+
+func (f *replaceString) String() string {
+	return "replace=" + fmt.Sprintf("%+v", f.config.Fields)
+}
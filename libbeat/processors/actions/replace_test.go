@@ -0,0 +1,134 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestReplaceRun(t *testing.T) {
+	var tests = map[string]struct {
+		Fields        []map[string]interface{}
+		IgnoreMissing bool
+		FailOnError   bool
+		Input         common.MapStr
+		Output        common.MapStr
+		error         bool
+	}{
+		"pattern with capture groups": {
+			Fields: []map[string]interface{}{
+				{"field": "url.path", "pattern": `^/api/v\d+/(\w+)/\d+$`, "replacement": "/api/$1/:id"},
+			},
+			Input: common.MapStr{
+				"url": common.MapStr{"path": "/api/v2/users/1234"},
+			},
+			Output: common.MapStr{
+				"url": common.MapStr{"path": "/api/users/:id"},
+			},
+		},
+		"operations applied in order": {
+			Fields: []map[string]interface{}{
+				{"field": "user.name", "operation": "trim"},
+				{"field": "user.name", "operation": "lowercase"},
+				{"field": "tags", "operation": "uppercase"},
+			},
+			Input: common.MapStr{
+				"user": common.MapStr{"name": "  John.Doe\t"},
+				"tags": []string{"prod", "eu"},
+			},
+			Output: common.MapStr{
+				"user": common.MapStr{"name": "john.doe"},
+				"tags": []string{"PROD", "EU"},
+			},
+		},
+		"ignore missing field": {
+			Fields: []map[string]interface{}{
+				{"field": "missing", "operation": "lowercase"},
+				{"field": "message", "pattern": "secret", "replacement": "***"},
+			},
+			IgnoreMissing: true,
+			Input:         common.MapStr{"message": "my secret"},
+			Output:        common.MapStr{"message": "my ***"},
+		},
+		"missing field fails and restores event": {
+			Fields: []map[string]interface{}{
+				{"field": "message", "operation": "uppercase"},
+				{"field": "missing", "operation": "lowercase"},
+			},
+			FailOnError: true,
+			Input:       common.MapStr{"message": "hello"},
+			Output: common.MapStr{
+				"message": "hello",
+				"error": common.MapStr{
+					"message": "Failed to replace fields in replace processor: could not fetch value for key: missing, Error: key not found",
+				},
+			},
+			error: true,
+		},
+		"non string value without fail_on_error": {
+			Fields: []map[string]interface{}{
+				{"field": "count", "operation": "trim"},
+				{"field": "message", "operation": "uppercase"},
+			},
+			Input:  common.MapStr{"count": 1, "message": "hello"},
+			Output: common.MapStr{"count": 1, "message": "HELLO"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewReplaceString(common.MustNewConfigFrom(map[string]interface{}{
+				"fields":         test.Fields,
+				"ignore_missing": test.IgnoreMissing,
+				"fail_on_error":  test.FailOnError,
+			}))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			event, err := p.Run(&beat.Event{Fields: test.Input})
+			if test.error {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.Output, event.Fields)
+		})
+	}
+}
+
+func TestReplaceInvalidConfig(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"missing pattern":        {"field": "message"},
+		"invalid pattern":        {"field": "message", "pattern": "("},
+		"unknown operation":      {"field": "message", "operation": "reverse"},
+		"pattern with lowercase": {"field": "message", "operation": "lowercase", "pattern": "a"},
+	}
+
+	for name, field := range tests {
+		_, err := NewReplaceString(common.MustNewConfigFrom(map[string]interface{}{
+			"fields": []map[string]interface{}{field},
+		}))
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,269 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregate
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/atomic"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+const (
+	procName = "aggregate"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID = atomic.MakeUint32(0)
+
+var errNotStarted = errors.New("aggregate processor is not running, it can " +
+	"only be used in the global processors configuration")
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+}
+
+type processor struct {
+	config
+	log *logp.Logger
+
+	mu          sync.Mutex
+	emit        processors.Emitter
+	groups      map[string]*group
+	windowStart time.Time
+	rnd         *rand.Rand
+
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	emitted  *monitoring.Int // Number of summary events emitted.
+	overflow *monitoring.Int // Number of events not aggregated because max_groups was reached.
+	now      func() time.Time
+
+	metricsName string // Name of the monitoring registry, removed on Close.
+}
+
+// group holds the statistics of the events of a group during a window.
+type group struct {
+	values common.MapStr // Values of the group_by fields.
+	count  int64
+	stats  map[string]*stats
+}
+
+// New constructs a new aggregate processor.
+func New(cfg *common.Config) (processors.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, errors.Wrap(err, "fail to unpack the "+procName+" processor configuration")
+	}
+
+	id := int(instanceID.Inc())
+	name := logName + "." + strconv.Itoa(id)
+	metrics := monitoring.Default.NewRegistry(name, monitoring.DoNotReport)
+	p := newAggregate(c, metrics, logp.NewLogger(logName).With("instance_id", id))
+	p.metricsName = name
+	return p, nil
+}
+
+func newAggregate(c config, metrics *monitoring.Registry, log *logp.Logger) *processor {
+	return &processor{
+		config:   c,
+		log:      log,
+		groups:   map[string]*group{},
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		emitted:  monitoring.NewInt(metrics, "emitted"),
+		overflow: monitoring.NewInt(metrics, "overflow"),
+		now:      time.Now,
+	}
+}
+
+// Start starts emitting summary events at the end of every period.
+func (p *processor) Start(emit processors.Emitter) {
+	p.mu.Lock()
+	p.emit = emit
+	p.windowStart = p.now()
+	p.done = make(chan struct{})
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.Period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.flush()
+			}
+		}
+	}()
+}
+
+// Stop stops the processor, emitting the summary of the current window.
+func (p *processor) Stop() {
+	p.mu.Lock()
+	done := p.done
+	p.mu.Unlock()
+	if done == nil {
+		return
+	}
+
+	close(done)
+	p.wg.Wait()
+	p.flush()
+
+	p.mu.Lock()
+	p.emit = nil
+	p.done = nil
+	p.mu.Unlock()
+}
+
+// Close stops the processor and removes its monitoring registry.
+func (p *processor) Close() error {
+	p.Stop()
+	if p.metricsName != "" {
+		monitoring.Default.Remove(p.metricsName)
+	}
+	return nil
+}
+
+// Run adds the event to the statistics of its group.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	values, key := p.groupValues(event)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.emit == nil {
+		return event, errNotStarted
+	}
+
+	g, found := p.groups[key]
+	if !found {
+		if len(p.groups) >= p.MaxGroups {
+			p.overflow.Inc()
+			return event, nil
+		}
+		g = &group{values: values, stats: map[string]*stats{}}
+		p.groups[key] = g
+	}
+
+	g.count++
+	for _, field := range p.Fields {
+		v, err := event.GetValue(field)
+		if err != nil {
+			continue
+		}
+		n, ok := toFloat(v)
+		if !ok {
+			continue
+		}
+
+		s := g.stats[field]
+		if s == nil {
+			s = &stats{}
+			g.stats[field] = s
+		}
+		s.add(n, p.SampleSize, p.rnd)
+	}
+
+	if p.DropEvents {
+		return nil, nil
+	}
+	return event, nil
+}
+
+// groupValues returns the values of the group_by fields and the key
+// identifying the group.
+func (p *processor) groupValues(event *beat.Event) (common.MapStr, string) {
+	values := common.MapStr{}
+	parts := make([]string, len(p.GroupBy))
+	for i, field := range p.GroupBy {
+		v, err := event.GetValue(field)
+		if err != nil {
+			// Distinguish missing fields from empty values.
+			parts[i] = "\x01"
+			continue
+		}
+		values[field] = v
+		parts[i] = fmt.Sprint(v)
+	}
+	return values, strings.Join(parts, "\x00")
+}
+
+// flush emits a summary event for every group seen in the current window and
+// starts a new window.
+func (p *processor) flush() {
+	p.mu.Lock()
+	groups, start, emit := p.groups, p.windowStart, p.emit
+	end := p.now()
+	p.groups = map[string]*group{}
+	p.windowStart = end
+	p.mu.Unlock()
+
+	if emit == nil {
+		return
+	}
+
+	for _, g := range groups {
+		emit(p.summary(g, start, end))
+		p.emitted.Inc()
+	}
+}
+
+func (p *processor) summary(g *group, start, end time.Time) *beat.Event {
+	event := &beat.Event{
+		Timestamp: start,
+		Fields:    common.MapStr{},
+	}
+
+	for field, v := range g.values {
+		event.PutValue(field, v)
+	}
+	event.PutValue("event.start", start)
+	event.PutValue("event.end", end)
+
+	summary := common.MapStr{"count": g.count}
+	for _, field := range p.Fields {
+		if s, found := g.stats[field]; found {
+			summary.Put(field, s.fields(p.Metrics, p.Percentiles))
+		}
+	}
+	event.PutValue(p.TargetField, summary)
+	return event
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("%v=[group_by=%v, fields=%v, metrics=%v, period=%v, drop_events=%v]",
+		procName, p.GroupBy, p.Fields, p.Metrics, p.Period, p.DropEvents)
+}
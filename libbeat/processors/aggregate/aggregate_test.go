@@ -0,0 +1,227 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregate
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+type testEmitter struct {
+	events []*beat.Event
+}
+
+func (e *testEmitter) emit(event *beat.Event) {
+	e.events = append(e.events, event)
+}
+
+// byHost sorts summary events by host.name.
+func (e *testEmitter) byHost() []*beat.Event {
+	sort.Slice(e.events, func(i, j int) bool {
+		a, _ := e.events[i].GetValue("host.name")
+		b, _ := e.events[j].GetValue("host.name")
+		return a.(string) < b.(string)
+	})
+	return e.events
+}
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) (*processor, *testEmitter) {
+	c := defaultConfig()
+	require.NoError(t, common.MustNewConfigFrom(settings).Unpack(&c))
+
+	p := newAggregate(c, monitoring.NewRegistry(), logp.NewLogger(logName))
+	emitter := &testEmitter{}
+	p.Start(emitter.emit)
+	return p, emitter
+}
+
+func testEvent(host string, bytes interface{}) *beat.Event {
+	return &beat.Event{Fields: common.MapStr{
+		"host": common.MapStr{"name": host},
+		"http": common.MapStr{"response": common.MapStr{"bytes": bytes}},
+	}}
+}
+
+func TestAggregate(t *testing.T) {
+	p, emitter := newTestProcessor(t, map[string]interface{}{
+		"group_by":    []string{"host.name"},
+		"fields":      []string{"http.response.bytes"},
+		"metrics":     []string{"sum", "min", "max", "avg", "percentiles"},
+		"percentiles": []float64{50, 99.9},
+		"period":      "1h",
+	})
+	defer p.Stop()
+
+	start := time.Date(2019, 11, 5, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+	p.windowStart = start
+	p.now = func() time.Time { return end }
+
+	for _, e := range []*beat.Event{
+		testEvent("web-1", 100),
+		testEvent("web-1", int64(300)),
+		testEvent("web-1", "not a number"),
+		testEvent("web-2", 50.5),
+	} {
+		event, err := p.Run(e)
+		require.NoError(t, err)
+		assert.NotNil(t, event)
+	}
+
+	p.flush()
+	events := emitter.byHost()
+	require.Len(t, events, 2)
+
+	assert.Equal(t, start, events[0].Timestamp)
+	assert.Equal(t, common.MapStr{
+		"host":  common.MapStr{"name": "web-1"},
+		"event": common.MapStr{"start": start, "end": end},
+		"aggregate": common.MapStr{
+			"count": int64(3),
+			"http": common.MapStr{"response": common.MapStr{"bytes": common.MapStr{
+				"count":       int64(2),
+				"sum":         400.0,
+				"min":         100.0,
+				"max":         300.0,
+				"avg":         200.0,
+				"percentiles": common.MapStr{"p50": 100.0, "p99_9": 300.0},
+			}}},
+		},
+	}, events[0].Fields)
+
+	web2, _ := events[1].GetValue("aggregate.http.response.bytes.sum")
+	assert.Equal(t, 50.5, web2)
+
+	// A new window is started after a flush.
+	p.flush()
+	assert.Len(t, emitter.events, 2)
+	assert.Equal(t, int64(2), p.emitted.Get())
+}
+
+func TestAggregateDropEvents(t *testing.T) {
+	p, emitter := newTestProcessor(t, map[string]interface{}{
+		"drop_events": true,
+		"max_groups":  1,
+		"group_by":    []string{"host.name"},
+		"period":      "1h",
+	})
+
+	event, err := p.Run(testEvent("web-1", 1))
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	// Events of groups over the limit are not aggregated nor dropped.
+	event, err = p.Run(testEvent("web-2", 1))
+	require.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, int64(1), p.overflow.Get())
+
+	// Stopping emits the summary of the current window.
+	p.Stop()
+	require.Len(t, emitter.events, 1)
+	count, _ := emitter.events[0].GetValue("aggregate.count")
+	assert.Equal(t, int64(1), count)
+
+	_, err = p.Run(testEvent("web-1", 1))
+	assert.Equal(t, errNotStarted, err)
+}
+
+func TestAggregatePeriod(t *testing.T) {
+	p, _ := newTestProcessor(t, map[string]interface{}{
+		"period": "10ms",
+	})
+	defer p.Stop()
+
+	emitted := make(chan *beat.Event, 1)
+	p.mu.Lock()
+	p.emit = func(e *beat.Event) {
+		select {
+		case emitted <- e:
+		default:
+		}
+	}
+	p.mu.Unlock()
+
+	_, err := p.Run(&beat.Event{Fields: common.MapStr{"message": "hello"}})
+	require.NoError(t, err)
+
+	select {
+	case e := <-emitted:
+		count, _ := e.GetValue("aggregate.count")
+		assert.Equal(t, int64(1), count)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no summary emitted")
+	}
+}
+
+func TestStatsPercentiles(t *testing.T) {
+	s := &stats{}
+	rnd := newAggregate(defaultConfig(), monitoring.NewRegistry(), logp.NewLogger(logName)).rnd
+	for i := 1; i <= 1000; i++ {
+		s.add(float64(i), 100, rnd)
+	}
+
+	assert.Equal(t, int64(1000), s.count)
+	assert.Len(t, s.samples, 100)
+	assert.Equal(t, 1.0, s.min)
+	assert.Equal(t, 1000.0, s.max)
+
+	// Percentiles are estimated from the sample.
+	p50 := s.percentiles([]float64{50})["p50"].(float64)
+	assert.InDelta(t, 500, p50, 200)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"invalid metric":     {"fields": []string{"a"}, "metrics": []string{"median"}},
+		"invalid percentile": {"percentiles": []float64{150}},
+		"empty target":       {"target_field": ""},
+		"invalid period":     {"period": "0s"},
+	}
+
+	for name, settings := range tests {
+		_, err := New(common.MustNewConfigFrom(settings))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestCloseRemovesMetrics(t *testing.T) {
+	proc, err := New(common.MustNewConfigFrom(map[string]interface{}{"period": "1h"}))
+	require.NoError(t, err)
+	p := proc.(*processor)
+	require.NotNil(t, monitoring.Default.Get(p.metricsName))
+
+	emitter := &testEmitter{}
+	p.Start(emitter.emit)
+	_, err = p.Run(&beat.Event{Fields: common.MapStr{"message": "hello"}})
+	require.NoError(t, err)
+
+	// Closing stops the processor, emitting the current window.
+	require.NoError(t, p.Close())
+	assert.Len(t, emitter.events, 1)
+	assert.Nil(t, monitoring.Default.Get(p.metricsName))
+}
@@ -0,0 +1,101 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type config struct {
+	GroupBy     []string      `config:"group_by"`                                 // Fields used to group events.
+	Fields      []string      `config:"fields"`                                   // Numeric fields to compute statistics on.
+	Metrics     []metric      `config:"metrics"`                                  // Statistics computed for each field.
+	Percentiles []float64     `config:"percentiles"`                              // Percentiles computed if the percentiles metric is enabled.
+	Period      time.Duration `config:"period" validate:"nonzero,positive"`       // Duration of the tumbling window.
+	TargetField string        `config:"target_field"`                             // Field holding the statistics in summary events.
+	DropEvents  bool          `config:"drop_events"`                              // Drop the aggregated events.
+	MaxGroups   int           `config:"max_groups" validate:"min=1"`              // Maximum number of groups per window.
+	SampleSize  int           `config:"percentiles_sample_size" validate:"min=1"` // Values kept per field to estimate percentiles.
+}
+
+func defaultConfig() config {
+	return config{
+		Period:      time.Minute,
+		TargetField: "aggregate",
+		MaxGroups:   10000,
+		SampleSize:  1000,
+	}
+}
+
+var defaultPercentiles = []float64{50, 95, 99}
+
+type metric uint8
+
+const (
+	metricSum metric = iota
+	metricMin
+	metricMax
+	metricAvg
+	metricPercentiles
+)
+
+var metricNames = map[metric]string{
+	metricSum:         "sum",
+	metricMin:         "min",
+	metricMax:         "max",
+	metricAvg:         "avg",
+	metricPercentiles: "percentiles",
+}
+
+func (m metric) String() string {
+	return metricNames[m]
+}
+
+// Unpack creates the metric from the given string.
+func (m *metric) Unpack(s string) error {
+	for k, name := range metricNames {
+		if strings.EqualFold(name, s) {
+			*m = k
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid aggregation metric [%s]", s)
+}
+
+// Validate validates the data contained in the config.
+func (c *config) Validate() error {
+	if c.TargetField == "" {
+		return errors.New("target_field must not be empty")
+	}
+	if len(c.Percentiles) == 0 {
+		c.Percentiles = defaultPercentiles
+	}
+	for _, p := range c.Percentiles {
+		if p <= 0 || p > 100 {
+			return errors.Errorf("percentile %v must be in the range (0, 100]", p)
+		}
+	}
+	if len(c.Fields) > 0 && len(c.Metrics) == 0 {
+		c.Metrics = []metric{metricSum, metricMin, metricMax, metricAvg}
+	}
+	return nil
+}
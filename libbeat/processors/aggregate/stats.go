@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregate
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// stats accumulates the values of a numeric field. Percentiles are estimated
+// from a uniform sample of the values, kept using reservoir sampling.
+type stats struct {
+	count    int64
+	sum      float64
+	min, max float64
+	samples  []float64
+}
+
+func (s *stats) add(v float64, sampleSize int, rnd *rand.Rand) {
+	s.count++
+	s.sum += v
+	if s.count == 1 || v < s.min {
+		s.min = v
+	}
+	if s.count == 1 || v > s.max {
+		s.max = v
+	}
+
+	if len(s.samples) < sampleSize {
+		s.samples = append(s.samples, v)
+	} else if i := rnd.Int63n(s.count); i < int64(sampleSize) {
+		s.samples[i] = v
+	}
+}
+
+// fields returns the requested metrics.
+func (s *stats) fields(metrics []metric, percentiles []float64) common.MapStr {
+	fields := common.MapStr{"count": s.count}
+	for _, m := range metrics {
+		switch m {
+		case metricSum:
+			fields["sum"] = s.sum
+		case metricMin:
+			fields["min"] = s.min
+		case metricMax:
+			fields["max"] = s.max
+		case metricAvg:
+			fields["avg"] = s.sum / float64(s.count)
+		case metricPercentiles:
+			fields["percentiles"] = s.percentiles(percentiles)
+		}
+	}
+	return fields
+}
+
+// percentiles computes the percentiles of the samples using the nearest rank
+// method. Keys are the percentiles prefixed with p, with dots replaced by
+// underscores, like p99_9.
+func (s *stats) percentiles(percentiles []float64) common.MapStr {
+	sorted := make([]float64, len(s.samples))
+	copy(sorted, s.samples)
+	sort.Float64s(sorted)
+
+	result := common.MapStr{}
+	for _, p := range percentiles {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		key := "p" + strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", -1)
+		result[key] = sorted[rank-1]
+	}
+	return result
+}
+
+// toFloat converts numeric values to float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
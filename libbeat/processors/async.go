@@ -0,0 +1,103 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package processors
+
+import (
+	"github.com/elastic/beats/libbeat/beat"
+)
+
+// Emitter publishes an event generated by a processor outside of Run.
+type Emitter func(event *beat.Event)
+
+// AsyncProcessor is implemented by processors that generate new events
+// asynchronously, like a summary of the events seen during a time window.
+// The processor can only emit events between calls to Start and Stop.
+type AsyncProcessor interface {
+	Processor
+
+	// Start starts the processor. Generated events are published with emit.
+	Start(emit Emitter)
+
+	// Stop stops the processor. Pending events are emitted before it returns.
+	Stop()
+}
+
+// Start starts the asynchronous processors in the list. The events emitted by
+// a processor are run through the processors that follow it in the list
+// before being passed to emit.
+func (procs *Processors) Start(emit Emitter) {
+	for i, p := range procs.List {
+		ap, ok := p.(AsyncProcessor)
+		if !ok {
+			continue
+		}
+
+		rest := NewList(procs.log)
+		rest.List = procs.List[i+1:]
+		ap.Start(func(event *beat.Event) {
+			event, err := rest.Run(event)
+			if err != nil {
+				rest.log.Debugw("Error in processor pipeline", "error", err)
+			}
+			if event != nil {
+				emit(event)
+			}
+		})
+	}
+}
+
+// Stop stops the asynchronous processors in the list. Processors are stopped
+// in order, so that events emitted while stopping a processor are still
+// processed by the ones that follow it.
+func (procs *Processors) Stop() {
+	for _, p := range procs.List {
+		if ap, ok := p.(AsyncProcessor); ok {
+			ap.Stop()
+		}
+	}
+}
+
+// Start starts the processor if it is asynchronous.
+func (r *WhenProcessor) Start(emit Emitter) {
+	if ap, ok := r.p.(AsyncProcessor); ok {
+		ap.Start(emit)
+	}
+}
+
+// Stop stops the processor if it is asynchronous.
+func (r *WhenProcessor) Stop() {
+	if ap, ok := r.p.(AsyncProcessor); ok {
+		ap.Stop()
+	}
+}
+
+// Start starts the asynchronous processors of both branches.
+func (p *IfThenElseProcessor) Start(emit Emitter) {
+	p.then.Start(emit)
+	if p.els != nil {
+		p.els.Start(emit)
+	}
+}
+
+// Stop stops the asynchronous processors of both branches.
+func (p *IfThenElseProcessor) Stop() {
+	p.then.Stop()
+	if p.els != nil {
+		p.els.Stop()
+	}
+}
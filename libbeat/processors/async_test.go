@@ -0,0 +1,104 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package processors_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/conditions"
+	"github.com/elastic/beats/libbeat/processors"
+	"github.com/elastic/beats/libbeat/processors/actions"
+)
+
+// asyncProcessor emits a copy of every event it sees when it is stopped.
+type asyncProcessor struct {
+	seen    []*beat.Event
+	emit    processors.Emitter
+	stopped bool
+}
+
+func (p *asyncProcessor) Run(event *beat.Event) (*beat.Event, error) {
+	p.seen = append(p.seen, event)
+	return event, nil
+}
+
+func (p *asyncProcessor) Start(emit processors.Emitter) { p.emit = emit }
+
+func (p *asyncProcessor) Stop() {
+	for _, event := range p.seen {
+		p.emit(&beat.Event{Fields: common.MapStr{"copy": event.Fields["message"]}})
+	}
+	p.stopped = true
+}
+
+func (p *asyncProcessor) String() string { return "async" }
+
+func TestProcessorsStartStop(t *testing.T) {
+	async := &asyncProcessor{}
+	procs := processors.NewList(nil)
+	procs.List = append(procs.List,
+		actions.NewAddTags("tags", []string{"before"}),
+		async,
+		actions.NewAddTags("tags", []string{"after"}),
+	)
+
+	var emitted []common.MapStr
+	procs.Start(func(event *beat.Event) {
+		emitted = append(emitted, event.Fields)
+	})
+
+	_, err := procs.Run(&beat.Event{Fields: common.MapStr{"message": "hello"}})
+	assert.NoError(t, err)
+
+	procs.Stop()
+	assert.True(t, async.stopped)
+
+	// Only processors after the asynchronous one are applied.
+	assert.Equal(t, []common.MapStr{
+		{"copy": "hello", "tags": []string{"after"}},
+	}, emitted)
+}
+
+func TestConditionalAsyncProcessors(t *testing.T) {
+	async := &asyncProcessor{}
+	var condConfig conditions.Config
+	err := common.MustNewConfigFrom(map[string]interface{}{
+		"equals.message": "hello",
+	}).Unpack(&condConfig)
+	assert.NoError(t, err)
+
+	cond, err := processors.NewConditionRule(condConfig, async)
+	assert.NoError(t, err)
+
+	procs := processors.NewList(nil)
+	procs.List = append(procs.List, cond)
+
+	var emitted int
+	procs.Start(func(event *beat.Event) { emitted++ })
+
+	procs.Run(&beat.Event{Fields: common.MapStr{"message": "hello"}})
+	procs.Run(&beat.Event{Fields: common.MapStr{"message": "skipped"}})
+	procs.Stop()
+
+	assert.True(t, async.stopped)
+	assert.Equal(t, 1, emitted)
+}
@@ -0,0 +1,61 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package processors
+
+// Closer is implemented by processors holding resources that must be released
+// once the processor is no longer used, like monitoring registries or
+// background goroutines.
+type Closer interface {
+	Close() error
+}
+
+// Close closes the processor if it implements Closer.
+func Close(p Processor) error {
+	if c, ok := p.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Close closes all processors in the list. Processors are closed in order,
+// the first error is returned.
+func (procs *Processors) Close() error {
+	var firstErr error
+	for _, p := range procs.List {
+		if err := Close(p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes the wrapped processor.
+func (r *WhenProcessor) Close() error {
+	return Close(r.p)
+}
+
+// Close closes the processors of both branches.
+func (p *IfThenElseProcessor) Close() error {
+	err := p.then.Close()
+	if p.els != nil {
+		if elsErr := p.els.Close(); err == nil {
+			err = elsErr
+		}
+	}
+	return err
+}
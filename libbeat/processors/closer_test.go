@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package processors_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/conditions"
+	"github.com/elastic/beats/libbeat/processors"
+	"github.com/elastic/beats/libbeat/processors/actions"
+)
+
+// closingProcessor records how often it is closed.
+type closingProcessor struct {
+	closed int
+	err    error
+}
+
+func (p *closingProcessor) Run(event *beat.Event) (*beat.Event, error) { return event, nil }
+
+func (p *closingProcessor) Close() error {
+	p.closed++
+	return p.err
+}
+
+func (p *closingProcessor) String() string { return "closing" }
+
+func TestProcessorsClose(t *testing.T) {
+	var condConfig conditions.Config
+	err := common.MustNewConfigFrom(map[string]interface{}{
+		"equals.message": "hello",
+	}).Unpack(&condConfig)
+	assert.NoError(t, err)
+
+	first := &closingProcessor{err: errors.New("first")}
+	conditional := &closingProcessor{}
+	last := &closingProcessor{err: errors.New("last")}
+
+	cond, err := processors.NewConditionRule(condConfig, conditional)
+	assert.NoError(t, err)
+
+	procs := processors.NewList(nil)
+	procs.List = append(procs.List,
+		first,
+		actions.NewAddTags("tags", []string{"unclosable"}),
+		cond,
+		last,
+	)
+
+	// All processors are closed, the first error is returned.
+	assert.EqualError(t, procs.Close(), "first")
+	assert.Equal(t, 1, first.closed)
+	assert.Equal(t, 1, conditional.closed)
+	assert.Equal(t, 1, last.closed)
+}
@@ -0,0 +1,187 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/libbeat/processors/script/javascript/module/processor"
+)
+
+const (
+	procName         = "decode_kv"
+	flagParsingError = "decode_kv_parsing_error"
+)
+
+type processor struct {
+	config  config
+	parser  parser
+	include map[string]struct{}
+	exclude map[string]struct{}
+}
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("DecodeKV", New)
+}
+
+// New constructs a new decode_kv processor.
+func New(c *common.Config) (processors.Processor, error) {
+	config := defaultConfig
+	if err := c.Unpack(&config); err != nil {
+		return nil, errors.Wrap(err, "fail to unpack the "+procName+" processor configuration")
+	}
+	return newDecodeKV(config), nil
+}
+
+func newDecodeKV(c config) *processor {
+	return &processor{
+		config: c,
+		parser: parser{
+			fieldSplit: c.FieldSplit,
+			valueSplit: c.ValueSplit,
+			quotes:     c.QuoteChars,
+		},
+		include: toSet(c.IncludeKeys),
+		exclude: toSet(c.ExcludeKeys),
+	}
+}
+
+func toSet(keys []string) map[string]struct{} {
+	if len(keys) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return set
+}
+
+// Run parses the key-value pairs in the configured field and writes them to
+// the target field. Keys that appear more than once are stored as arrays.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	v, err := event.GetValue(p.config.Field)
+	if err != nil {
+		if p.config.IgnoreMissing && errors.Cause(err) == common.ErrKeyNotFound {
+			return event, nil
+		}
+		return event, errors.Wrapf(err, "could not fetch value for field %v", p.config.Field)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return p.fail(event, fmt.Errorf("field is not a string, value: `%v`, field: `%s`", v, p.config.Field))
+	}
+
+	fields := p.decode(s)
+	if len(fields.keys) == 0 {
+		return p.fail(event, fmt.Errorf("no key-value pairs found in field %v", p.config.Field))
+	}
+
+	prefix := ""
+	if p.config.TargetField != "" {
+		prefix = p.config.TargetField + "."
+	}
+	for _, k := range fields.keys {
+		key := prefix + k
+		if !p.config.OverwriteKeys {
+			if _, err := event.GetValue(key); err == nil {
+				continue
+			}
+		}
+		if _, err := event.PutValue(key, fields.values[k]); err != nil {
+			return p.fail(event, errors.Wrapf(err, "failed to set field %v", key))
+		}
+	}
+	return event, nil
+}
+
+// decodedFields holds the decoded values keeping the order in which keys were
+// first seen, so that nested keys are written deterministically.
+type decodedFields struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (d *decodedFields) add(key, value string) {
+	existing, found := d.values[key]
+	if !found {
+		d.keys = append(d.keys, key)
+		d.values[key] = value
+		return
+	}
+
+	if list, ok := existing.([]string); ok {
+		d.values[key] = append(list, value)
+		return
+	}
+	d.values[key] = []string{existing.(string), value}
+}
+
+func (p *processor) decode(s string) *decodedFields {
+	fields := &decodedFields{values: map[string]interface{}{}}
+	for _, kv := range p.parser.parse(s) {
+		key := kv.key
+		if p.config.TrimKey != "" {
+			key = strings.Trim(key, p.config.TrimKey)
+		}
+		if key == "" || !p.keep(key) {
+			continue
+		}
+
+		value := kv.value
+		if p.config.TrimValue != "" {
+			value = strings.Trim(value, p.config.TrimValue)
+		}
+		fields.add(p.config.Prefix+key, value)
+	}
+	return fields
+}
+
+func (p *processor) keep(key string) bool {
+	if p.include != nil {
+		if _, found := p.include[key]; !found {
+			return false
+		}
+	}
+	_, excluded := p.exclude[key]
+	return !excluded
+}
+
+func (p *processor) fail(event *beat.Event, err error) (*beat.Event, error) {
+	if err := common.AddTagsWithKey(event.Fields, beat.FlagField, []string{flagParsingError}); err != nil {
+		return event, errors.Wrap(err, "cannot add new flag the event")
+	}
+
+	if p.config.IgnoreFailure {
+		return event, nil
+	}
+	return event, err
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("%v=[field=%v, target_field=%v, field_split=%q, value_split=%q]",
+		procName, p.config.Field, p.config.TargetField, p.config.FieldSplit, p.config.ValueSplit)
+}
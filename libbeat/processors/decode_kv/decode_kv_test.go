@@ -0,0 +1,132 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) *processor {
+	p, err := New(common.MustNewConfigFrom(settings))
+	require.NoError(t, err)
+	return p.(*processor)
+}
+
+func TestDecodeKV(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{
+		"target_field": "kv",
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{
+		"message": `ts=2019-11-05 level=warn msg="disk almost full" tag=a tag=b`,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{
+		"ts":    "2019-11-05",
+		"level": "warn",
+		"msg":   "disk almost full",
+		"tag":   []string{"a", "b"},
+	}, event.Fields["kv"])
+}
+
+func TestDecodeKVRootTarget(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{
+		"field":       "line",
+		"field_split": ";",
+		"value_split": ":",
+		"prefix":      "fw.",
+		"trim_key":    " ",
+		"trim_value":  " []",
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{
+		"line": "src: 10.0.0.1; dst: [10.0.0.2]; rule.name: allow-dns",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{
+		"src": "10.0.0.1",
+		"dst": "10.0.0.2",
+		"rule": common.MapStr{
+			"name": "allow-dns",
+		},
+	}, event.Fields["fw"])
+}
+
+func TestDecodeKVIncludeExclude(t *testing.T) {
+	input := "a=1 b=2 c=3"
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"target_field": "kv",
+		"include_keys": []string{"a", "b"},
+		"exclude_keys": []string{"b"},
+	})
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{"message": input}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{"a": "1"}, event.Fields["kv"])
+}
+
+func TestDecodeKVOverwriteKeys(t *testing.T) {
+	for overwrite, want := range map[bool]string{false: "original", true: "decoded"} {
+		p := newTestProcessor(t, map[string]interface{}{
+			"overwrite_keys": overwrite,
+		})
+		event, err := p.Run(&beat.Event{Fields: common.MapStr{
+			"message": "status=decoded other=1",
+			"status":  "original",
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, want, event.Fields["status"])
+		assert.Equal(t, "1", event.Fields["other"])
+	}
+}
+
+func TestDecodeKVFailures(t *testing.T) {
+	p := newTestProcessor(t, nil)
+
+	_, err := p.Run(&beat.Event{Fields: common.MapStr{}})
+	assert.Error(t, err)
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{"message": "no pairs here"}})
+	assert.Error(t, err)
+	flags, _ := event.GetValue(beat.FlagField)
+	assert.Equal(t, []string{flagParsingError}, flags)
+
+	p = newTestProcessor(t, map[string]interface{}{
+		"ignore_missing": true,
+		"ignore_failure": true,
+	})
+
+	_, err = p.Run(&beat.Event{Fields: common.MapStr{}})
+	assert.NoError(t, err)
+
+	_, err = p.Run(&beat.Event{Fields: common.MapStr{"message": 42}})
+	assert.NoError(t, err)
+}
+
+func TestDecodeKVInvalidConfig(t *testing.T) {
+	_, err := New(common.MustNewConfigFrom(map[string]interface{}{
+		"field_split": "=",
+	}))
+	assert.Error(t, err)
+}
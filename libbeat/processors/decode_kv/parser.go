@@ -0,0 +1,103 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv
+
+import (
+	"strings"
+)
+
+// pair is a key and value parsed from a key-value string.
+type pair struct {
+	key, value string
+}
+
+// parser splits key-value strings like `a=1 b="two words"` into pairs. Keys
+// and values can be enclosed in any of the quote characters, in which case
+// separators are not interpreted and a backslash escapes the next character.
+type parser struct {
+	fieldSplit string
+	valueSplit string
+	quotes     string
+}
+
+// parse returns the pairs found in s in the order they appear. Tokens without
+// a value separator are ignored.
+func (p *parser) parse(s string) []pair {
+	var pairs []pair
+	for len(s) > 0 {
+		if strings.HasPrefix(s, p.fieldSplit) {
+			s = s[len(p.fieldSplit):]
+			continue
+		}
+
+		var key string
+		key, s = p.token(s, true)
+		if !strings.HasPrefix(s, p.valueSplit) {
+			// Key without value, skip to the next field.
+			_, s = p.token(s, false)
+			continue
+		}
+		s = s[len(p.valueSplit):]
+
+		var value string
+		value, s = p.token(s, false)
+		pairs = append(pairs, pair{key: key, value: value})
+	}
+	return pairs
+}
+
+// token reads a key or value from the beginning of s and returns it with the
+// remaining input. Keys end at the value or field separator, values end at
+// the field separator only so they can contain the value separator.
+func (p *parser) token(s string, isKey bool) (string, string) {
+	if len(s) > 0 && strings.IndexByte(p.quotes, s[0]) >= 0 {
+		if tok, rest, ok := p.quoted(s); ok {
+			return tok, rest
+		}
+	}
+
+	end := strings.Index(s, p.fieldSplit)
+	if end < 0 {
+		end = len(s)
+	}
+	if isKey {
+		if i := strings.Index(s[:end], p.valueSplit); i >= 0 {
+			end = i
+		}
+	}
+	return s[:end], s[end:]
+}
+
+// quoted reads a token enclosed in the quote character s starts with. It
+// returns false if the closing quote is missing.
+func (p *parser) quoted(s string) (string, string, bool) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case c == quote:
+			return b.String(), s[i+1:], true
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", s, false
+}
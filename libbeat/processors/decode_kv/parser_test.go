@@ -0,0 +1,90 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser(t *testing.T) {
+	tests := map[string]struct {
+		parser parser
+		input  string
+		want   []pair
+	}{
+		"logfmt": {
+			parser: parser{fieldSplit: " ", valueSplit: "=", quotes: `"`},
+			input:  `level=info msg="request completed" status=200  duration=1.5ms`,
+			want: []pair{
+				{"level", "info"},
+				{"msg", "request completed"},
+				{"status", "200"},
+				{"duration", "1.5ms"},
+			},
+		},
+		"escaped quotes": {
+			parser: parser{fieldSplit: " ", valueSplit: "=", quotes: `"'`},
+			input:  `msg="say \"hi\"" user='a b'`,
+			want: []pair{
+				{"msg", `say "hi"`},
+				{"user", "a b"},
+			},
+		},
+		"value containing value separator": {
+			parser: parser{fieldSplit: "&", valueSplit: "=", quotes: `"`},
+			input:  `q=a=b&page=2`,
+			want: []pair{
+				{"q", "a=b"},
+				{"page", "2"},
+			},
+		},
+		"multi character separators": {
+			parser: parser{fieldSplit: ", ", valueSplit: ": ", quotes: `"`},
+			input:  `src: 10.0.0.1, dst: 10.0.0.2, action: allow`,
+			want: []pair{
+				{"src", "10.0.0.1"},
+				{"dst", "10.0.0.2"},
+				{"action", "allow"},
+			},
+		},
+		"keys without value and empty values": {
+			parser: parser{fieldSplit: " ", valueSplit: "=", quotes: `"`},
+			input:  `debug a= b="" c=1`,
+			want: []pair{
+				{"a", ""},
+				{"b", ""},
+				{"c", "1"},
+			},
+		},
+		"unterminated quote": {
+			parser: parser{fieldSplit: " ", valueSplit: "=", quotes: `"`},
+			input:  `a="x y`,
+			want: []pair{
+				{"a", `"x`},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.parser.parse(test.input))
+		})
+	}
+}
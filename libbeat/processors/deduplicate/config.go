@@ -0,0 +1,50 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deduplicate
+
+import (
+	"crypto/sha256"
+	"time"
+
+	"github.com/elastic/beats/libbeat/processors/fingerprint"
+)
+
+type config struct {
+	Fields        []string               `config:"fields" validate:"required"`  // Fields used to compute the key of events.
+	Method        fingerprint.HashMethod `config:"method"`                      // Hash function used to compute the key.
+	Window        time.Duration          `config:"window" validate:"min=1"`     // Time during which duplicates are dropped.
+	CacheSize     int                    `config:"cache.size" validate:"min=1"` // Maximum number of keys remembered.
+	IgnoreMissing bool                   `config:"ignore_missing"`              // Compute keys of events missing some fields.
+	Persist       persistConfig          `config:"persist"`                     // Persistence of keys across restarts.
+}
+
+type persistConfig struct {
+	Path          string        `config:"path"`                            // File storing the keys, disabled if empty.
+	FlushInterval time.Duration `config:"flush_interval" validate:"min=0"` // How often keys are written to the file.
+}
+
+func defaultConfig() config {
+	return config{
+		Method:    sha256.New,
+		Window:    10 * time.Minute,
+		CacheSize: 100000,
+		Persist: persistConfig{
+			FlushInterval: 10 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,291 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deduplicate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/atomic"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/paths"
+	"github.com/elastic/beats/libbeat/processors"
+	"github.com/elastic/beats/libbeat/processors/fingerprint"
+)
+
+const (
+	procName = "deduplicate"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID = atomic.MakeUint32(0)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+}
+
+type processor struct {
+	config
+	fields []string
+	log    *logp.Logger
+
+	mu    sync.Mutex
+	cache *lru.Cache // Keys of seen events, mapped to the time they were first seen.
+	dirty bool       // Set if the cache changed since the last flush.
+
+	// done stops the background flushing of the cache to the persisted store.
+	// It is only set if the processor owns the store.
+	done      chan struct{}
+	wg        sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	duplicates *monitoring.Int // Number of events dropped.
+	entries    *monitoring.Int // Number of keys in the cache.
+	now        func() time.Time
+
+	metricsName string // Name of the monitoring registry, removed on Close.
+}
+
+// New constructs a new deduplicate processor.
+func New(cfg *common.Config) (processors.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, errors.Wrap(err, "fail to unpack the "+procName+" processor configuration")
+	}
+	if c.Persist.Path != "" {
+		c.Persist.Path = paths.Resolve(paths.Data, c.Persist.Path)
+	}
+
+	id := int(instanceID.Inc())
+	name := logName + "." + strconv.Itoa(id)
+	metrics := monitoring.Default.NewRegistry(name, monitoring.DoNotReport)
+	p, err := newDeduplicate(c, metrics, logp.NewLogger(logName).With("instance_id", id))
+	if err != nil {
+		monitoring.Default.Remove(name)
+		return nil, err
+	}
+	p.metricsName = name
+	return p, nil
+}
+
+func newDeduplicate(c config, metrics *monitoring.Registry, log *logp.Logger) (*processor, error) {
+	cache, err := lru.New(c.CacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fields are sorted so keys are stable across restarts.
+	fields := common.MakeStringSet(c.Fields...).ToSlice()
+	sort.Strings(fields)
+
+	p := &processor{
+		config:     c,
+		fields:     fields,
+		log:        log,
+		cache:      cache,
+		duplicates: monitoring.NewInt(metrics, "duplicates"),
+		entries:    monitoring.NewInt(metrics, "entries"),
+		now:        time.Now,
+	}
+	return p, nil
+}
+
+// Run drops the event if an event with the same key was seen within the
+// configured window.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	p.start()
+
+	h := p.Method()
+	if err := fingerprint.WriteFields(h, event.Fields, p.fields, p.IgnoreMissing); err != nil {
+		return event, errors.Wrap(err, "failed to compute deduplication key")
+	}
+	key := string(h.Sum(nil))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if seen, found := p.cache.Get(key); found && now.Sub(seen.(time.Time)) < p.Window {
+		p.duplicates.Inc()
+		return nil, nil
+	}
+
+	p.cache.Add(key, now)
+	p.entries.Set(int64(p.cache.Len()))
+	p.dirty = true
+	return event, nil
+}
+
+// load adds the non expired keys from the persisted store to the cache.
+func (p *processor) load() error {
+	if p.Persist.Path == "" {
+		return nil
+	}
+
+	entries, err := readStore(p.Persist.Path)
+	if err != nil {
+		return err
+	}
+
+	now := p.now()
+	for _, e := range entries {
+		if now.Sub(e.Seen) < p.Window {
+			p.cache.Add(e.Key, e.Seen)
+		}
+	}
+	p.entries.Set(int64(p.cache.Len()))
+	p.log.Debugf("Loaded %d keys from %v", p.cache.Len(), p.Persist.Path)
+	return nil
+}
+
+// start loads the keys from the persisted store and starts flushing the cache
+// to it. It runs once, when the processor is started or on the first event, as
+// the processors of clients are not started. Only one processor can use a
+// store, persistence is disabled for the others.
+func (p *processor) start() {
+	p.startOnce.Do(func() {
+		if p.Persist.Path == "" {
+			return
+		}
+
+		if !stores.claim(p.Persist.Path) {
+			p.log.Errorf("Keys are not persisted, persist.path %v is already "+
+				"used by another %v processor", p.Persist.Path, procName)
+			return
+		}
+		if err := p.load(); err != nil {
+			stores.release(p.Persist.Path)
+			p.log.Errorf("Keys are not persisted: %v", err)
+			return
+		}
+		p.startFlusher()
+	})
+}
+
+// startFlusher writes the cache to the persisted store every flush interval
+// in the background, so events are not delayed by writing the store. Keys seen
+// since the last flush are lost if the processor is not stopped.
+func (p *processor) startFlusher() {
+	p.done = make(chan struct{})
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.Persist.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.flush()
+			}
+		}
+	}()
+}
+
+// Start implements processors.AsyncProcessor. It loads the persisted keys,
+// the processor never emits events.
+func (p *processor) Start(processors.Emitter) {
+	p.start()
+}
+
+// Stop stops the background flushing, writes the keys seen since the last
+// flush to the persisted store and releases the store. Keys seen afterwards
+// are not persisted.
+func (p *processor) Stop() {
+	p.stopOnce.Do(func() {
+		// Prevent a later start.
+		p.startOnce.Do(func() {})
+		if p.done == nil {
+			return
+		}
+
+		close(p.done)
+		p.wg.Wait()
+		p.flush()
+		stores.release(p.Persist.Path)
+	})
+}
+
+// Close stops the processor and removes its monitoring registry.
+func (p *processor) Close() error {
+	p.Stop()
+	if p.metricsName != "" {
+		monitoring.Default.Remove(p.metricsName)
+	}
+	return nil
+}
+
+// flush writes the cache to the persisted store if it changed since the last
+// flush. The store is written from a snapshot of the cache, without holding
+// the lock.
+func (p *processor) flush() {
+	entries, changed := p.snapshot()
+	if !changed {
+		return
+	}
+
+	if err := writeStore(p.Persist.Path, entries); err != nil {
+		p.log.Warnf("Failed to persist deduplication keys: %v", err)
+
+		p.mu.Lock()
+		p.dirty = true
+		p.mu.Unlock()
+	}
+}
+
+// snapshot returns the keys still within the window if the cache changed
+// since the last snapshot.
+func (p *processor) snapshot() ([]storeEntry, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.dirty {
+		return nil, false
+	}
+	p.dirty = false
+
+	now := p.now()
+	keys := p.cache.Keys()
+	entries := make([]storeEntry, 0, len(keys))
+	for _, k := range keys {
+		seen, found := p.cache.Peek(k)
+		if !found || now.Sub(seen.(time.Time)) >= p.Window {
+			continue
+		}
+		entries = append(entries, storeEntry{Key: k.(string), Seen: seen.(time.Time)})
+	}
+	return entries, true
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("%v=[fields=%v, window=%v, cache.size=%v, persist.path=%v]",
+		procName, p.fields, p.Window, p.CacheSize, p.Persist.Path)
+}
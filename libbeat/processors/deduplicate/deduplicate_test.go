@@ -0,0 +1,253 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deduplicate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}, clock *testClock) *processor {
+	c := defaultConfig()
+	require.NoError(t, common.MustNewConfigFrom(settings).Unpack(&c))
+
+	p, err := newDeduplicate(c, monitoring.NewRegistry(), logp.NewLogger(logName))
+	require.NoError(t, err)
+	p.now = clock.Now
+	require.NoError(t, p.load())
+	return p
+}
+
+func testEvent(message string) *beat.Event {
+	return &beat.Event{Fields: common.MapStr{
+		"message": message,
+		"log":     common.MapStr{"offset": 10},
+	}}
+}
+
+func TestDeduplicateWindow(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	p := newTestProcessor(t, map[string]interface{}{
+		"fields": []string{"message", "log.offset"},
+		"window": "1m",
+	}, clock)
+
+	event, err := p.Run(testEvent("hello"))
+	require.NoError(t, err)
+	assert.NotNil(t, event)
+
+	clock.now = clock.now.Add(30 * time.Second)
+	event, err = p.Run(testEvent("hello"))
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	event, err = p.Run(testEvent("world"))
+	require.NoError(t, err)
+	assert.NotNil(t, event)
+
+	// The window starts when the key is first seen.
+	clock.now = clock.now.Add(31 * time.Second)
+	event, err = p.Run(testEvent("hello"))
+	require.NoError(t, err)
+	assert.NotNil(t, event)
+
+	assert.Equal(t, int64(1), p.duplicates.Get())
+	assert.Equal(t, int64(2), p.entries.Get())
+}
+
+func TestDeduplicateCacheSize(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	p := newTestProcessor(t, map[string]interface{}{
+		"fields":     []string{"message"},
+		"cache.size": 1,
+	}, clock)
+
+	for _, msg := range []string{"a", "b", "a"} {
+		event, err := p.Run(testEvent(msg))
+		require.NoError(t, err)
+		assert.NotNil(t, event, msg)
+	}
+}
+
+func TestDeduplicateMissingFields(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	p := newTestProcessor(t, map[string]interface{}{
+		"fields": []string{"message", "missing"},
+	}, clock)
+
+	event, err := p.Run(testEvent("hello"))
+	assert.Error(t, err)
+	assert.NotNil(t, event)
+
+	p = newTestProcessor(t, map[string]interface{}{
+		"fields":         []string{"message", "missing"},
+		"ignore_missing": true,
+	}, clock)
+
+	event, err = p.Run(testEvent("hello"))
+	require.NoError(t, err)
+	assert.NotNil(t, event)
+
+	event, err = p.Run(testEvent("hello"))
+	require.NoError(t, err)
+	assert.Nil(t, event)
+}
+
+func TestDeduplicatePersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deduplicate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clock := &testClock{now: time.Now()}
+	settings := map[string]interface{}{
+		"fields":                 []string{"message"},
+		"window":                 "1m",
+		"persist.path":           filepath.Join(dir, "dedup.json"),
+		"persist.flush_interval": "10s",
+	}
+
+	p := newTestProcessor(t, settings, clock)
+	for _, msg := range []string{"old", "new"} {
+		_, err := p.Run(testEvent(msg))
+		require.NoError(t, err)
+		clock.now = clock.now.Add(40 * time.Second)
+	}
+	p.flush()
+	assert.FileExists(t, filepath.Join(dir, "dedup.json"))
+
+	// After a restart, only keys still within the window are remembered.
+	clock.now = clock.now.Add(-10 * time.Second)
+	p = newTestProcessor(t, settings, clock)
+	assert.Equal(t, int64(1), p.entries.Get())
+
+	event, err := p.Run(testEvent("new"))
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	event, err = p.Run(testEvent("old"))
+	require.NoError(t, err)
+	assert.NotNil(t, event)
+}
+
+func TestDeduplicateStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deduplicate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clock := &testClock{now: time.Now()}
+	settings := map[string]interface{}{
+		"fields":                 []string{"message"},
+		"persist.path":           filepath.Join(dir, "dedup.json"),
+		"persist.flush_interval": "1h",
+	}
+
+	p := newTestProcessor(t, settings, clock)
+	p.Start(nil)
+	_, err = p.Run(testEvent("hello"))
+	require.NoError(t, err)
+
+	// Stopping the processor writes the keys not flushed yet.
+	p.Stop()
+	p = newTestProcessor(t, settings, clock)
+	assert.Equal(t, int64(1), p.entries.Get())
+}
+
+func TestDeduplicateSharedStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deduplicate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clock := &testClock{now: time.Now()}
+	settings := map[string]interface{}{
+		"fields":                 []string{"message"},
+		"persist.path":           filepath.Join(dir, "dedup.json"),
+		"persist.flush_interval": "1h",
+	}
+
+	// Processors that are not started use the store on the first event.
+	first := newTestProcessor(t, settings, clock)
+	_, err = first.Run(testEvent("first"))
+	require.NoError(t, err)
+	assert.NotNil(t, first.done)
+
+	// The store is in use, the keys of the second processor are not persisted.
+	second := newTestProcessor(t, settings, clock)
+	_, err = second.Run(testEvent("second"))
+	require.NoError(t, err)
+	assert.Nil(t, second.done)
+
+	second.Stop()
+	first.Stop()
+	entries, err := readStore(filepath.Join(dir, "dedup.json"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// The store is released once the processor is stopped.
+	third := newTestProcessor(t, settings, clock)
+	third.Start(nil)
+	assert.NotNil(t, third.done)
+	third.Stop()
+}
+
+func TestCloseRemovesMetrics(t *testing.T) {
+	proc, err := New(common.MustNewConfigFrom(map[string]interface{}{
+		"fields": []string{"message"},
+	}))
+	require.NoError(t, err)
+	p := proc.(*processor)
+	require.NotNil(t, monitoring.Default.Get(p.metricsName))
+
+	require.NoError(t, p.Close())
+	assert.Nil(t, monitoring.Default.Get(p.metricsName))
+}
+
+func TestDeduplicateInvalidStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deduplicate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "dedup.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+
+	c := defaultConfig()
+	require.NoError(t, common.MustNewConfigFrom(map[string]interface{}{
+		"fields":       []string{"message"},
+		"persist.path": path,
+	}).Unpack(&c))
+
+	p, err := newDeduplicate(c, monitoring.NewRegistry(), logp.NewLogger(logName))
+	require.NoError(t, err)
+	assert.Error(t, p.load())
+}
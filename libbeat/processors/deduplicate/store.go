@@ -0,0 +1,117 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deduplicate
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/common/file"
+)
+
+// storeEntry is a key persisted to disk, with the time it was first seen.
+type storeEntry struct {
+	Key  string    `json:"key"`
+	Seen time.Time `json:"seen"`
+}
+
+// stores holds the paths of the stores in use. Processors sharing a store
+// would overwrite each other's keys.
+var stores = storeClaims{paths: map[string]bool{}}
+
+type storeClaims struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// claim reserves the store at path. It returns false if the store is already
+// in use.
+func (c *storeClaims) claim(path string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paths[path] {
+		return false
+	}
+	c.paths[path] = true
+	return true
+}
+
+// release makes the store at path available again.
+func (c *storeClaims) release(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.paths, path)
+}
+
+// readStore reads the keys persisted to path. Entries are returned from the
+// least to the most recently used. A missing file is not an error.
+func readStore(path string) ([]storeEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to open deduplication store")
+	}
+	defer f.Close()
+
+	var entries []storeEntry
+	if err := json.NewDecoder(f).Decode(&entries); err != nil {
+		return nil, errors.Wrapf(err, "failed to read deduplication store %v", path)
+	}
+
+	for i := range entries {
+		key, err := hex.DecodeString(entries[i].Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key in deduplication store %v", path)
+		}
+		entries[i].Key = string(key)
+	}
+	return entries, nil
+}
+
+// writeStore writes the keys to path, replacing the previous file only once
+// the new one is completely written.
+func writeStore(path string, entries []storeEntry) error {
+	encoded := make([]storeEntry, len(entries))
+	for i, e := range entries {
+		encoded[i] = storeEntry{Key: hex.EncodeToString([]byte(e.Key)), Seen: e.Seen}
+	}
+
+	tempFile := path + ".new"
+	f, err := os.OpenFile(tempFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create deduplication store")
+	}
+
+	encodeErr := json.NewEncoder(f).Encode(encoded)
+	syncErr := f.Sync()
+	closeErr := f.Close()
+	for _, err := range []error{encodeErr, syncErr, closeErr} {
+		if err != nil {
+			return errors.Wrap(err, "failed to write deduplication store")
+		}
+	}
+
+	return file.SafeFileRotate(path, tempFile)
+}
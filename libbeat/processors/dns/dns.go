@@ -48,6 +48,8 @@ type processor struct {
 	Config
 	resolver PTRResolver
 	log      *logp.Logger
+
+	metricsName string // Name of the monitoring registry, removed on Close.
 }
 
 // New constructs a new DNS processor.
@@ -61,21 +63,30 @@ func New(cfg *common.Config) (processors.Processor, error) {
 	var (
 		id      = int(instanceID.Inc())
 		log     = logp.NewLogger(logName).With("instance_id", id)
-		metrics = monitoring.Default.NewRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+		name    = logName + "." + strconv.Itoa(id)
+		metrics = monitoring.Default.NewRegistry(name, monitoring.DoNotReport)
 	)
 
 	log.Debugf("DNS processor config: %+v", c)
 	resolver, err := NewMiekgResolver(metrics, c.Timeout, c.Nameservers...)
 	if err != nil {
+		monitoring.Default.Remove(name)
 		return nil, err
 	}
 
 	cache, err := NewPTRLookupCache(metrics.NewRegistry("cache"), c.CacheConfig, resolver)
 	if err != nil {
+		monitoring.Default.Remove(name)
 		return nil, err
 	}
 
-	return &processor{Config: c, resolver: cache, log: log}, nil
+	return &processor{Config: c, resolver: cache, log: log, metricsName: name}, nil
+}
+
+// Close removes the monitoring registry of the processor.
+func (p *processor) Close() error {
+	monitoring.Default.Remove(p.metricsName)
+	return nil
 }
 
 func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
@@ -24,16 +24,17 @@ import (
 	"strings"
 )
 
-type encodingMethod func([]byte) string
+// EncodingMethod encodes a hash sum as a string.
+type EncodingMethod func([]byte) string
 
-var encodings = map[string]encodingMethod{
+var encodings = map[string]EncodingMethod{
 	"hex":    hex.EncodeToString,
 	"base32": base32.StdEncoding.EncodeToString,
 	"base64": base64.StdEncoding.EncodeToString,
 }
 
-// Unpack creates the encodingMethod from the given string
-func (e *encodingMethod) Unpack(str string) error {
+// Unpack creates the EncodingMethod from the given string
+func (e *EncodingMethod) Unpack(str string) error {
 	str = strings.ToLower(str)
 
 	m, found := encodings[str]
@@ -85,10 +85,17 @@ func (p *fingerprint) String() string {
 }
 
 func (p *fingerprint) writeFields(to io.Writer, eventFields common.MapStr) error {
-	for _, k := range p.fields {
+	return WriteFields(to, eventFields, p.fields, p.config.IgnoreMissing)
+}
+
+// WriteFields writes the values of the given fields to the writer in the
+// format hashed by the fingerprint processor. Only scalar values can be
+// written. Missing fields are skipped if ignoreMissing is set.
+func WriteFields(to io.Writer, eventFields common.MapStr, fields []string, ignoreMissing bool) error {
+	for _, k := range fields {
 		v, err := eventFields.GetValue(k)
 		if err != nil {
-			if p.config.IgnoreMissing {
+			if ignoreMissing {
 				continue
 			}
 			return makeErrMissingField(k, err)
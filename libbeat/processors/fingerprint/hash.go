@@ -26,9 +26,10 @@ import (
 	"strings"
 )
 
-type hashMethod func() hash.Hash
+// HashMethod creates the hash function used to fingerprint or hash values.
+type HashMethod func() hash.Hash
 
-var hashes = map[string]hashMethod{
+var hashes = map[string]HashMethod{
 	"md5":    md5.New,
 	"sha1":   sha1.New,
 	"sha256": sha256.New,
@@ -36,8 +37,8 @@ var hashes = map[string]hashMethod{
 	"sha512": sha512.New,
 }
 
-// Unpack creates the hashMethod from the given string
-func (f *hashMethod) Unpack(str string) error {
+// Unpack creates the HashMethod from the given string
+func (f *HashMethod) Unpack(str string) error {
 	str = strings.ToLower(str)
 
 	m, found := hashes[str]
@@ -0,0 +1,74 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+type config struct {
+	Database       string        `config:"database"`                         // Path to a City or Country database.
+	ASNDatabase    string        `config:"asn_database"`                     // Path to an ASN database.
+	Fields         common.MapStr `config:"fields"`                           // Mapping of source IP fields to target objects.
+	Language       string        `config:"language"`                         // Language of the names added to the event.
+	CacheSize      int           `config:"cache.size" validate:"min=0"`      // Number of lookups to cache.
+	ReloadInterval time.Duration `config:"reload_interval" validate:"min=0"` // How often to check the databases for changes.
+	TagOnFailure   []string      `config:"tag_on_failure"`                   // Tags to append when a failure occurs.
+	fieldsFlat     map[string]string
+}
+
+var defaultConfig = config{
+	Language:       "en",
+	CacheSize:      10000,
+	ReloadInterval: time.Minute,
+}
+
+// defaultFields are the ECS IP fields enriched if no fields are configured.
+var defaultFields = map[string]string{
+	"client.ip":      "client",
+	"destination.ip": "destination",
+	"server.ip":      "server",
+	"source.ip":      "source",
+}
+
+// Validate validates the data contained in the config.
+func (c *config) Validate() error {
+	if c.Database == "" && c.ASNDatabase == "" {
+		return errors.New("at least one of database or asn_database must be configured")
+	}
+
+	if len(c.Fields) == 0 {
+		c.fieldsFlat = defaultFields
+		return nil
+	}
+
+	c.fieldsFlat = map[string]string{}
+	for k, v := range c.Fields.Flatten() {
+		target, ok := v.(string)
+		if !ok {
+			return errors.Errorf("target field for geoip lookup of %v "+
+				"must be a string but got %T", k, v)
+		}
+		c.fieldsFlat[k] = target
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/atomic"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/libbeat/processors/script/javascript/module/processor"
+	"github.com/elastic/beats/libbeat/processors/util"
+)
+
+const (
+	procName = "geoip"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID = atomic.MakeUint32(0)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("GeoIP", New)
+}
+
+type processor struct {
+	config
+	log   *logp.Logger
+	cache *lru.Cache
+
+	mu        sync.RWMutex
+	databases []*databaseFile
+	reloader  *util.Reloader
+
+	hits   *monitoring.Int
+	misses *monitoring.Int
+	now    func() time.Time
+
+	metricsName string // Name of the monitoring registry, removed on Close.
+}
+
+// databaseFile is a database loaded from disk. The file is reloaded if its
+// size or modification time change.
+type databaseFile struct {
+	path    string
+	db      *database
+	size    int64
+	modTime time.Time
+}
+
+// result holds the enrichment data of an IP address.
+type result struct {
+	geo common.MapStr
+	as  common.MapStr
+}
+
+// New constructs a new geoip processor.
+func New(cfg *common.Config) (processors.Processor, error) {
+	c := defaultConfig
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, errors.Wrap(err, "fail to unpack the "+procName+" processor configuration")
+	}
+
+	id := int(instanceID.Inc())
+	name := logName + "." + strconv.Itoa(id)
+	metrics := monitoring.Default.NewRegistry(name, monitoring.DoNotReport)
+	p, err := newGeoIP(c, metrics, logp.NewLogger(logName).With("instance_id", id))
+	if err != nil {
+		monitoring.Default.Remove(name)
+		return nil, err
+	}
+	p.metricsName = name
+	return p, nil
+}
+
+func newGeoIP(c config, metrics *monitoring.Registry, log *logp.Logger) (*processor, error) {
+	p := &processor{
+		config: c,
+		log:    log,
+		hits:   monitoring.NewInt(metrics, "cache.hits"),
+		misses: monitoring.NewInt(metrics, "cache.misses"),
+		now:    time.Now,
+	}
+
+	for _, path := range []string{c.Database, c.ASNDatabase} {
+		if path == "" {
+			continue
+		}
+		f, err := loadDatabaseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		p.databases = append(p.databases, f)
+	}
+
+	if c.CacheSize > 0 {
+		var err error
+		if p.cache, err = lru.New(c.CacheSize); err != nil {
+			return nil, err
+		}
+	}
+
+	p.reloader = util.NewReloader(p.now())
+	return p, nil
+}
+
+func loadDatabaseFile(path string) (*databaseFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load geoip database")
+	}
+
+	db, err := openDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+	return &databaseFile{path: path, db: db, size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+// Close removes the monitoring registry of the processor.
+func (p *processor) Close() error {
+	if p.metricsName != "" {
+		monitoring.Default.Remove(p.metricsName)
+	}
+	return nil
+}
+
+// Run enriches the configured IP fields with geo and AS information.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	p.reloader.Run(p.now(), p.ReloadInterval, p.reload)
+
+	var tagOnce sync.Once
+	for field, target := range p.fieldsFlat {
+		v, err := event.GetValue(field)
+		if err != nil {
+			continue
+		}
+
+		ip, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		r, err := p.lookup(ip)
+		if err != nil {
+			p.log.Debugf("GeoIP lookup of %v value '%v' failed: %v", field, ip, err)
+			tagOnce.Do(func() { common.AddTags(event.Fields, p.TagOnFailure) })
+			continue
+		}
+
+		if r.geo != nil {
+			event.PutValue(target+".geo", r.geo.Clone())
+		}
+		if r.as != nil {
+			event.PutValue(target+".as", r.as.Clone())
+		}
+	}
+	return event, nil
+}
+
+func (p *processor) lookup(ip string) (*result, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.cache != nil {
+		if r, found := p.cache.Get(ip); found {
+			p.hits.Inc()
+			return r.(*result), nil
+		}
+		p.misses.Inc()
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, errors.New("invalid IP address")
+	}
+
+	r := &result{}
+	for _, f := range p.databases {
+		record, err := f.db.lookup(addr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %v", f.path)
+		}
+		if record == nil {
+			continue
+		}
+
+		if r.geo == nil {
+			r.geo = geoFields(record, p.Language)
+		}
+		if r.as == nil {
+			r.as = asFields(record)
+		}
+	}
+
+	if p.cache != nil {
+		p.cache.Add(ip, r)
+	}
+	return r, nil
+}
+
+// reload reloads database files that changed on disk. The files are loaded
+// without holding the lock, such that concurrent lookups continue to use the
+// previous databases until the new ones are swapped in.
+func (p *processor) reload() {
+	p.mu.RLock()
+	databases := append([]*databaseFile(nil), p.databases...)
+	p.mu.RUnlock()
+
+	updated := map[int]*databaseFile{}
+	for i, f := range databases {
+		info, err := os.Stat(f.path)
+		if err != nil || (info.Size() == f.size && info.ModTime().Equal(f.modTime)) {
+			continue
+		}
+
+		db, err := loadDatabaseFile(f.path)
+		if err != nil {
+			p.log.Warnf("Failed to reload geoip database, keeping the previous version: %v", err)
+			continue
+		}
+		p.log.Infof("Reloaded geoip database %v", f.path)
+		updated[i] = db
+	}
+
+	if len(updated) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, db := range updated {
+		p.databases[i] = db
+	}
+	if p.cache != nil {
+		p.cache.Purge()
+	}
+}
+
+// geoFields builds the ECS geo object from a City or Country database record.
+func geoFields(record map[string]interface{}, lang string) common.MapStr {
+	var geo util.GeoConfig
+
+	geo.ContinentName = name(record, "continent", lang)
+	geo.CountryISOCode = str(record, "country", "iso_code")
+	geo.CityName = name(record, "city", lang)
+
+	if subdivisions, ok := record["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+		if subdivision, ok := subdivisions[0].(map[string]interface{}); ok {
+			geo.RegionName = name(subdivision, "", lang)
+			if code := str(subdivision, "iso_code"); code != "" && geo.CountryISOCode != "" {
+				geo.RegionISOCode = geo.CountryISOCode + "-" + code
+			}
+		}
+	}
+
+	if location, ok := record["location"].(map[string]interface{}); ok {
+		lat, latOK := location["latitude"].(float64)
+		lon, lonOK := location["longitude"].(float64)
+		if latOK && lonOK {
+			geo.Location = strconv.FormatFloat(lat, 'f', -1, 64) + ", " + strconv.FormatFloat(lon, 'f', -1, 64)
+		}
+	}
+
+	fields, err := util.GeoConfigToMap(geo)
+	if err != nil || len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// asFields builds the ECS as object from an ASN database record.
+func asFields(record map[string]interface{}) common.MapStr {
+	number, hasNumber := record["autonomous_system_number"].(uint64)
+	org := str(record, "autonomous_system_organization")
+	if !hasNumber && org == "" {
+		return nil
+	}
+
+	as := common.MapStr{}
+	if hasNumber {
+		as["number"] = number
+	}
+	if org != "" {
+		as["organization"] = common.MapStr{"name": org}
+	}
+	return as
+}
+
+// name returns the localized name of the object at key, or of record itself
+// if key is empty.
+func name(record map[string]interface{}, key, lang string) string {
+	if key != "" {
+		obj, ok := record[key].(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		record = obj
+	}
+	return str(record, "names", lang)
+}
+
+// str returns the string found by following the path of keys in record.
+func str(record map[string]interface{}, path ...string) string {
+	var v interface{} = record
+	for _, key := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		v = m[key]
+	}
+	s, _ := v.(string)
+	return s
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("%v=[database=%v, asn_database=%v, fields=%v]",
+		procName, p.Database, p.ASNDatabase, p.fieldsFlat)
+}
@@ -0,0 +1,229 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+var cityRecord = map[string]interface{}{
+	"city":      map[string]interface{}{"names": map[string]interface{}{"en": "Berlin", "de": "Berlin"}},
+	"continent": map[string]interface{}{"names": map[string]interface{}{"en": "Europe", "de": "Europa"}},
+	"country": map[string]interface{}{
+		"iso_code": "DE",
+		"names":    map[string]interface{}{"en": "Germany", "de": "Deutschland"},
+	},
+	"location": map[string]interface{}{"latitude": 52.5, "longitude": 13.4},
+	"subdivisions": []interface{}{
+		map[string]interface{}{
+			"iso_code": "BE",
+			"names":    map[string]interface{}{"en": "Land Berlin"},
+		},
+	},
+}
+
+var asnRecord = map[string]interface{}{
+	"autonomous_system_number":       uint32(64496),
+	"autonomous_system_organization": "Example Networks",
+}
+
+type testDatabases struct {
+	city, asn string
+	cleanup   func()
+}
+
+func writeTestDatabases(t *testing.T) testDatabases {
+	dir, cleanup := tempDir(t)
+	dbs := testDatabases{
+		city:    filepath.Join(dir, "city.mmdb"),
+		asn:     filepath.Join(dir, "asn.mmdb"),
+		cleanup: cleanup,
+	}
+
+	writeTestDatabase(t, dbs.city, "GeoLite2-City", []testNetwork{
+		{"81.2.69.0/24", cityRecord},
+		{"2a02:c7f::/32", cityRecord},
+	})
+	writeTestDatabase(t, dbs.asn, "GeoLite2-ASN", []testNetwork{
+		{"81.2.0.0/16", asnRecord},
+	})
+	return dbs
+}
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) *processor {
+	c := defaultConfig
+	require.NoError(t, common.MustNewConfigFrom(settings).Unpack(&c))
+
+	p, err := newGeoIP(c, monitoring.NewRegistry(), logp.NewLogger(logName))
+	require.NoError(t, err)
+	return p
+}
+
+var expectedGeo = common.MapStr{
+	"continent_name":   "Europe",
+	"country_iso_code": "DE",
+	"region_name":      "Land Berlin",
+	"region_iso_code":  "DE-BE",
+	"city_name":        "Berlin",
+	"location":         "52.5, 13.4",
+}
+
+func TestEnrich(t *testing.T) {
+	dbs := writeTestDatabases(t)
+	defer dbs.cleanup()
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"database":     dbs.city,
+		"asn_database": dbs.asn,
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{
+		"source":      common.MapStr{"ip": "81.2.69.160"},
+		"destination": common.MapStr{"ip": "2a02:c7f:1::1"},
+		"client":      common.MapStr{"ip": "10.0.0.1"},
+	}})
+	require.NoError(t, err)
+
+	assert.Equal(t, common.MapStr{
+		"ip":  "81.2.69.160",
+		"geo": expectedGeo,
+		"as": common.MapStr{
+			"number":       uint64(64496),
+			"organization": common.MapStr{"name": "Example Networks"},
+		},
+	}, event.Fields["source"])
+	assert.Equal(t, common.MapStr{"ip": "2a02:c7f:1::1", "geo": expectedGeo}, event.Fields["destination"])
+	assert.Equal(t, common.MapStr{"ip": "10.0.0.1"}, event.Fields["client"])
+}
+
+func TestCustomFieldsAndLanguage(t *testing.T) {
+	dbs := writeTestDatabases(t)
+	defer dbs.cleanup()
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"database":       dbs.city,
+		"language":       "de",
+		"fields":         map[string]interface{}{"remote_addr": "remote"},
+		"tag_on_failure": []string{"_geoip_lookup_failure"},
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{"remote_addr": "81.2.69.1"}})
+	require.NoError(t, err)
+	v, err := event.GetValue("remote.geo.continent_name")
+	require.NoError(t, err)
+	assert.Equal(t, "Europa", v)
+
+	event, err = p.Run(&beat.Event{Fields: common.MapStr{"remote_addr": "not an ip"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"_geoip_lookup_failure"}, event.Fields["tags"])
+}
+
+func TestCache(t *testing.T) {
+	dbs := writeTestDatabases(t)
+	defer dbs.cleanup()
+
+	p := newTestProcessor(t, map[string]interface{}{"database": dbs.city})
+
+	for i := 0; i < 3; i++ {
+		event, err := p.Run(&beat.Event{Fields: common.MapStr{"source": common.MapStr{"ip": "81.2.69.160"}}})
+		require.NoError(t, err)
+
+		// Modifying the event must not modify the cached value.
+		event.PutValue("source.geo.city_name", "modified")
+	}
+
+	assert.Equal(t, int64(1), p.misses.Get())
+	assert.Equal(t, int64(2), p.hits.Get())
+}
+
+func TestReload(t *testing.T) {
+	dbs := writeTestDatabases(t)
+	defer dbs.cleanup()
+
+	p := newTestProcessor(t, map[string]interface{}{"database": dbs.city})
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	lookupCity := func() interface{} {
+		event, err := p.Run(&beat.Event{Fields: common.MapStr{"source": common.MapStr{"ip": "81.2.69.160"}}})
+		require.NoError(t, err)
+		v, _ := event.GetValue("source.geo.city_name")
+		return v
+	}
+	assert.Equal(t, "Berlin", lookupCity())
+
+	writeTestDatabase(t, dbs.city, "GeoLite2-City", []testNetwork{
+		{"81.2.69.0/24", map[string]interface{}{
+			"city": map[string]interface{}{"names": map[string]interface{}{"en": "London"}},
+		}},
+	})
+
+	// The database is not checked before the reload interval has passed.
+	assert.Equal(t, "Berlin", lookupCity())
+
+	now = now.Add(p.ReloadInterval)
+	assert.Equal(t, "London", lookupCity())
+}
+
+func TestReloadConcurrentLookups(t *testing.T) {
+	dbs := writeTestDatabases(t)
+	defer dbs.cleanup()
+
+	p := newTestProcessor(t, map[string]interface{}{"database": dbs.city})
+	p.ReloadInterval = time.Nanosecond
+
+	writeTestDatabase(t, dbs.city, "GeoLite2-City", []testNetwork{
+		{"81.2.69.0/24", map[string]interface{}{
+			"city": map[string]interface{}{"names": map[string]interface{}{"en": "London"}},
+		}},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				event, err := p.Run(&beat.Event{Fields: common.MapStr{"source": common.MapStr{"ip": "81.2.69.160"}}})
+				assert.NoError(t, err)
+				v, _ := event.GetValue("source.geo.city_name")
+				assert.Contains(t, []interface{}{"Berlin", "London"}, v)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConfigValidation(t *testing.T) {
+	c := defaultConfig
+	assert.Error(t, common.MustNewConfigFrom(map[string]interface{}{}).Unpack(&c))
+
+	_, err := New(common.MustNewConfigFrom(map[string]interface{}{"database": "/does/not/exist.mmdb"}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,367 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// metadataStart marks the beginning of the metadata section of a MaxMind DB
+// file.
+var metadataStart = []byte("\xab\xcd\xefMaxMind.com")
+
+// dataSectionSeparatorSize is the size of the zero padding between the search
+// tree and the data section.
+const dataSectionSeparatorSize = 16
+
+// Data types of the MaxMind DB data section.
+const (
+	dataExtended = iota
+	dataPointer
+	dataString
+	dataDouble
+	dataBytes
+	dataUint16
+	dataUint32
+	dataMap
+	dataInt32
+	dataUint64
+	dataUint128
+	dataArray
+	dataContainer
+	dataEndMarker
+	dataBoolean
+	dataFloat
+)
+
+// database is a reader for the MaxMind DB file format, as used by the GeoIP2
+// and GeoLite2 databases.
+type database struct {
+	buf        []byte
+	data       []byte // data section
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+	dbType     string
+}
+
+func openDatabase(path string) (*database, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := newDatabase(buf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid MaxMind database %v", path)
+	}
+	return db, nil
+}
+
+func newDatabase(buf []byte) (*database, error) {
+	start := bytes.LastIndex(buf, metadataStart)
+	if start < 0 {
+		return nil, errors.New("metadata section not found")
+	}
+
+	metaStart := start + len(metadataStart)
+	d := decoder{buf: buf[metaStart:]}
+	raw, _, err := d.decode(0)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode metadata")
+	}
+	meta, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("metadata is not a map")
+	}
+
+	db := &database{buf: buf}
+	db.nodeCount = metaUint(meta, "node_count")
+	db.recordSize = metaUint(meta, "record_size")
+	db.ipVersion = metaUint(meta, "ip_version")
+	db.dbType, _ = meta["database_type"].(string)
+
+	switch db.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, errors.Errorf("unsupported record size %v", db.recordSize)
+	}
+
+	treeSize := db.nodeCount * db.recordSize / 4
+	dataStart := treeSize + dataSectionSeparatorSize
+	if dataStart > uint(start) {
+		return nil, errors.New("search tree exceeds file size")
+	}
+	db.data = buf[dataStart:start]
+
+	if db.ipVersion == 6 {
+		// IPv4 addresses are stored in the ::/96 subnet of IPv6 databases.
+		node := uint(0)
+		for i := 0; i < 96 && node < db.nodeCount; i++ {
+			node = db.record(node, 0)
+		}
+		db.ipv4Start = node
+	}
+	return db, nil
+}
+
+func metaUint(meta map[string]interface{}, key string) uint {
+	switch v := meta[key].(type) {
+	case uint64:
+		return uint(v)
+	}
+	return 0
+}
+
+// record returns the left (bit 0) or right (bit 1) record of a node.
+func (db *database) record(node uint, bit uint) uint {
+	b := db.buf
+	switch db.recordSize {
+	case 24:
+		off := node*6 + bit*3
+		return uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+	case 28:
+		off := node * 7
+		if bit == 0 {
+			return uint(b[off+3]&0xf0)<<20 | uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+		}
+		return uint(b[off+3]&0x0f)<<24 | uint(b[off+4])<<16 | uint(b[off+5])<<8 | uint(b[off+6])
+	default:
+		off := node*8 + bit*4
+		return uint(binary.BigEndian.Uint32(b[off:]))
+	}
+}
+
+// lookup returns the record stored for ip, or nil if ip is not in the
+// database.
+func (db *database) lookup(ip net.IP) (map[string]interface{}, error) {
+	node := uint(0)
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		node = db.ipv4Start
+	} else if db.ipVersion == 4 {
+		return nil, nil
+	}
+
+	bits := uint(len(ip) * 8)
+	for i := uint(0); i < bits && node < db.nodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-(i&7))) & 1
+		node = db.record(node, bit)
+	}
+
+	switch {
+	case node == db.nodeCount:
+		return nil, nil
+	case node < db.nodeCount:
+		return nil, errors.New("invalid node in search tree")
+	}
+
+	offset := node - db.nodeCount - dataSectionSeparatorSize
+	if offset >= uint(len(db.data)) {
+		return nil, errors.New("invalid data section offset")
+	}
+
+	d := decoder{buf: db.data}
+	v, _, err := d.decode(offset)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := v.(map[string]interface{})
+	return m, nil
+}
+
+// decoder decodes values of the MaxMind DB data section. Pointers are
+// resolved relative to the start of buf.
+type decoder struct {
+	buf []byte
+}
+
+var errDataTruncated = errors.New("unexpected end of data section")
+
+func (d *decoder) decode(offset uint) (interface{}, uint, error) {
+	typ, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ == dataPointer {
+		ptr, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, _, err := d.decode(ptr)
+		return v, next, err
+	}
+
+	switch typ {
+	case dataMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			k, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			v, next, err := d.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+			offset = next
+		}
+		return m, offset, nil
+
+	case dataArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			v, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+			offset = next
+		}
+		return a, offset, nil
+
+	case dataBoolean:
+		return size != 0, offset, nil
+	}
+
+	end := offset + size
+	if end > uint(len(d.buf)) {
+		return nil, 0, errDataTruncated
+	}
+	b := d.buf[offset:end]
+
+	switch typ {
+	case dataString:
+		return string(b), end, nil
+	case dataBytes:
+		return append([]byte(nil), b...), end, nil
+	case dataDouble:
+		if size != 8 {
+			return nil, 0, errors.Errorf("invalid size %v of double", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), end, nil
+	case dataFloat:
+		if size != 4 {
+			return nil, 0, errors.Errorf("invalid size %v of float", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), end, nil
+	case dataUint16, dataUint32, dataUint64:
+		if size > 8 {
+			return nil, 0, errors.Errorf("invalid size %v of unsigned integer", size)
+		}
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return n, end, nil
+	case dataInt32:
+		if size > 4 {
+			return nil, 0, errors.Errorf("invalid size %v of int32", size)
+		}
+		var n uint32
+		for _, c := range b {
+			n = n<<8 | uint32(c)
+		}
+		if size == 4 {
+			return int64(int32(n)), end, nil
+		}
+		return int64(n), end, nil
+	case dataUint128:
+		return append([]byte(nil), b...), end, nil
+	}
+	return nil, 0, errors.Errorf("unsupported data type %v", typ)
+}
+
+// control decodes the control byte at offset and returns the type, the
+// payload size and the offset of the payload.
+func (d *decoder) control(offset uint) (typ uint, size uint, next uint, err error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, errDataTruncated
+	}
+	ctrl := d.buf[offset]
+	offset++
+
+	typ = uint(ctrl >> 5)
+	if typ == dataExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, errDataTruncated
+		}
+		typ = 7 + uint(d.buf[offset])
+		offset++
+	}
+
+	size = uint(ctrl & 0x1f)
+	if typ == dataPointer || size < 29 {
+		return typ, size, offset, nil
+	}
+
+	n := size - 28
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, 0, errDataTruncated
+	}
+	var ext uint
+	for _, c := range d.buf[offset : offset+n] {
+		ext = ext<<8 | uint(c)
+	}
+	switch n {
+	case 1:
+		size = 29 + ext
+	case 2:
+		size = 285 + ext
+	case 3:
+		size = 65821 + ext
+	}
+	return typ, size, offset + n, nil
+}
+
+// pointer decodes a pointer whose control byte carried the bits in size.
+func (d *decoder) pointer(size uint, offset uint) (uint, uint, error) {
+	n := (size>>3)&0x3 + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, errDataTruncated
+	}
+
+	var ptr uint
+	if n < 4 {
+		ptr = size & 0x7
+	}
+	for _, c := range d.buf[offset : offset+n] {
+		ptr = ptr<<8 | uint(c)
+	}
+
+	switch n {
+	case 2:
+		ptr += 2048
+	case 3:
+		ptr += 526336
+	}
+	return ptr, offset + n, nil
+}
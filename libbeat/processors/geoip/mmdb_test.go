@@ -0,0 +1,251 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "geoip")
+	require.NoError(t, err)
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// testNetwork is a network and the record stored for it in a test database.
+type testNetwork struct {
+	cidr   string
+	record map[string]interface{}
+}
+
+// writeTestDatabase writes an IPv6 MaxMind DB file with 24 bit records. IPv4
+// networks are stored in the ::/96 subnet.
+func writeTestDatabase(t *testing.T, path, dbType string, networks []testNetwork) {
+	var (
+		nodes   = [][2]int{{-1, -1}}
+		data    bytes.Buffer
+		offsets []int
+	)
+
+	const dataRef = -2 // records below dataRef reference data entry dataRef-i
+
+	for i, network := range networks {
+		_, ipNet, err := net.ParseCIDR(network.cidr)
+		require.NoError(t, err)
+
+		ip, ones := ipNet.IP.To16(), 0
+		if ipNet.IP.To4() != nil {
+			ip = append(make(net.IP, 12), ipNet.IP.To4()...)
+			ones, _ = ipNet.Mask.Size()
+			ones += 96
+		} else {
+			ones, _ = ipNet.Mask.Size()
+		}
+
+		node := 0
+		for bit := 0; bit < ones; bit++ {
+			b := int(ip[bit/8]>>(7-uint(bit%8))) & 1
+			if bit == ones-1 {
+				nodes[node][b] = dataRef - i
+				break
+			}
+			if nodes[node][b] < 0 {
+				nodes = append(nodes, [2]int{-1, -1})
+				nodes[node][b] = len(nodes) - 1
+			}
+			node = nodes[node][b]
+		}
+
+		offsets = append(offsets, data.Len())
+		encodeTestValue(&data, network.record)
+	}
+
+	var out bytes.Buffer
+	nodeCount := len(nodes)
+	for _, node := range nodes {
+		for _, rec := range node {
+			v := nodeCount
+			switch {
+			case rec >= 0:
+				v = rec
+			case rec <= dataRef:
+				v = nodeCount + dataSectionSeparatorSize + offsets[dataRef-rec]
+			}
+			out.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+	out.Write(make([]byte, dataSectionSeparatorSize))
+	out.Write(data.Bytes())
+	out.Write(metadataStart)
+	encodeTestValue(&out, map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(6),
+		"database_type":               dbType,
+		"binary_format_major_version": uint16(2),
+	})
+
+	require.NoError(t, ioutil.WriteFile(path, out.Bytes(), 0600))
+}
+
+func encodeTestControl(buf *bytes.Buffer, typ, size int) {
+	ctrl := byte(0)
+	if typ <= 7 {
+		ctrl = byte(typ << 5)
+	}
+	if size < 29 {
+		ctrl |= byte(size)
+	} else {
+		ctrl |= 29
+	}
+	buf.WriteByte(ctrl)
+	if typ > 7 {
+		buf.WriteByte(byte(typ - 7))
+	}
+	if size >= 29 {
+		buf.WriteByte(byte(size - 29))
+	}
+}
+
+func encodeTestValue(buf *bytes.Buffer, v interface{}) {
+	writeUint := func(typ int, n uint64) {
+		var tmp [8]byte
+		binary.BigEndian.PutUint64(tmp[:], n)
+		b := bytes.TrimLeft(tmp[:], "\x00")
+		encodeTestControl(buf, typ, len(b))
+		buf.Write(b)
+	}
+
+	switch val := v.(type) {
+	case string:
+		encodeTestControl(buf, dataString, len(val))
+		buf.WriteString(val)
+	case float64:
+		encodeTestControl(buf, dataDouble, 8)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(val))
+	case uint16:
+		writeUint(dataUint16, uint64(val))
+	case uint32:
+		writeUint(dataUint32, uint64(val))
+	case uint64:
+		writeUint(dataUint64, val)
+	case bool:
+		size := 0
+		if val {
+			size = 1
+		}
+		encodeTestControl(buf, dataBoolean, size)
+	case []interface{}:
+		encodeTestControl(buf, dataArray, len(val))
+		for _, item := range val {
+			encodeTestValue(buf, item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		encodeTestControl(buf, dataMap, len(val))
+		for _, k := range keys {
+			encodeTestValue(buf, k)
+			encodeTestValue(buf, val[k])
+		}
+	default:
+		panic("unsupported test value")
+	}
+}
+
+func TestDatabaseLookup(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	path := filepath.Join(dir, "test.mmdb")
+	writeTestDatabase(t, path, "Test", []testNetwork{
+		{"10.1.0.0/16", map[string]interface{}{"name": "ipv4", "flag": true}},
+		{"2001:db8::/32", map[string]interface{}{
+			"name":  "ipv6",
+			"list":  []interface{}{uint32(1), "two"},
+			"float": 1.5,
+		}},
+	})
+
+	db, err := openDatabase(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Test", db.dbType)
+
+	record, err := db.lookup(net.ParseIP("10.1.2.3"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "ipv4", "flag": true}, record)
+
+	record, err = db.lookup(net.ParseIP("2001:db8::1"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":  "ipv6",
+		"list":  []interface{}{uint64(1), "two"},
+		"float": 1.5,
+	}, record)
+
+	for _, ip := range []string{"10.2.0.1", "192.168.0.1", "2001:db9::1"} {
+		record, err = db.lookup(net.ParseIP(ip))
+		require.NoError(t, err)
+		assert.Nil(t, record, ip)
+	}
+}
+
+func TestDecoderPointer(t *testing.T) {
+	// A map whose value is a pointer to the string at offset 0.
+	buf := []byte{
+		0x43, 'f', 'o', 'o',
+		0xe1, 0x43, 'k', 'e', 'y', 0x20, 0x00,
+	}
+	d := decoder{buf: buf}
+	v, next, err := d.decode(4)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"key": "foo"}, v)
+	assert.Equal(t, uint(len(buf)), next)
+}
+
+func TestDecoderLongString(t *testing.T) {
+	s := string(bytes.Repeat([]byte("a"), 300))
+	var buf bytes.Buffer
+	buf.Write([]byte{0x5e, 0x00, 300 - 285})
+	buf.WriteString(s)
+
+	d := decoder{buf: buf.Bytes()}
+	v, _, err := d.decode(0)
+	require.NoError(t, err)
+	assert.Equal(t, s, v)
+}
+
+func TestInvalidDatabase(t *testing.T) {
+	_, err := newDatabase([]byte("not a database"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,230 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// patternRef matches references to patterns: %{NAME}, %{NAME:field} or
+	// %{NAME:field:type}.
+	patternRef = regexp.MustCompile(`%\{(\w+)(?::([^:}]+))?(?::(\w+))?\}`)
+
+	// namedGroup matches named capture groups in the Oniguruma (?<name>re) and
+	// the RE2 (?P<name>re) syntax.
+	namedGroup = regexp.MustCompile(`\(\?P?<([\w.@\[\]-]+)>`)
+)
+
+// valueType is the type a captured value is converted to.
+type valueType uint8
+
+const (
+	typeString valueType = iota
+	typeInt
+	typeLong
+	typeFloat
+	typeBoolean
+)
+
+var valueTypes = map[string]valueType{
+	"string":  typeString,
+	"int":     typeInt,
+	"long":    typeLong,
+	"float":   typeFloat,
+	"double":  typeFloat,
+	"boolean": typeBoolean,
+	"bool":    typeBoolean,
+}
+
+type capture struct {
+	field string
+	typ   valueType
+}
+
+// matcher is a compiled grok expression.
+type matcher struct {
+	raw      string
+	re       *regexp.Regexp
+	captures []*capture // Indexed by sub-expression. Nil for unnamed groups.
+}
+
+// readPatterns reads pattern definitions from r into defs. Each line holds the
+// pattern name followed by whitespace and the expression. Empty lines and
+// lines starting with # are ignored.
+func readPatterns(r io.Reader, defs map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return errors.Errorf("line %v: missing expression for pattern %v", lineNo, line)
+		}
+		defs[line[:i]] = strings.TrimSpace(line[i:])
+	}
+	return scanner.Err()
+}
+
+// compile expands all pattern references in expr using defs and compiles the
+// resulting regular expression.
+func compile(expr string, defs map[string]string) (*matcher, error) {
+	c := &compiler{defs: defs, groups: map[string]*capture{}}
+
+	re, err := c.expand(expr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	regex, err := regexp.Compile(re)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compile grok pattern '%v'", expr)
+	}
+
+	m := &matcher{raw: expr, re: regex, captures: make([]*capture, regex.NumSubexp()+1)}
+	for i, name := range regex.SubexpNames() {
+		m.captures[i] = c.groups[name]
+	}
+	return m, nil
+}
+
+type compiler struct {
+	defs   map[string]string
+	groups map[string]*capture
+}
+
+// group registers a capture and returns the name of its regexp group. Field
+// names can contain characters not allowed in group names, so the groups are
+// numbered instead.
+func (c *compiler) group(field string, typ valueType) string {
+	name := "grok" + strconv.Itoa(len(c.groups))
+	c.groups[name] = &capture{field: normalizeField(field), typ: typ}
+	return name
+}
+
+func (c *compiler) expand(expr string, stack []string) (string, error) {
+	expr = namedGroup.ReplaceAllStringFunc(expr, func(s string) string {
+		field := namedGroup.FindStringSubmatch(s)[1]
+		return "(?P<" + c.group(field, typeString) + ">"
+	})
+
+	var buf strings.Builder
+	last := 0
+	for _, loc := range patternRef.FindAllStringSubmatchIndex(expr, -1) {
+		buf.WriteString(expr[last:loc[0]])
+		last = loc[1]
+
+		name := expr[loc[2]:loc[3]]
+		for _, parent := range stack {
+			if parent == name {
+				return "", errors.Errorf("recursive reference to pattern %v", name)
+			}
+		}
+
+		def, found := c.defs[name]
+		if !found {
+			return "", errors.Errorf("pattern %v is not defined", name)
+		}
+
+		re, err := c.expand(def, append(stack, name))
+		if err != nil {
+			return "", err
+		}
+
+		if loc[4] < 0 {
+			buf.WriteString("(?:" + re + ")")
+			continue
+		}
+
+		typ := typeString
+		if loc[6] >= 0 {
+			var ok bool
+			if typ, ok = valueTypes[expr[loc[6]:loc[7]]]; !ok {
+				return "", errors.Errorf("unsupported type '%v' in %v", expr[loc[6]:loc[7]], expr[loc[0]:loc[1]])
+			}
+		}
+		buf.WriteString("(?P<" + c.group(expr[loc[4]:loc[5]], typ) + ">" + re + ")")
+	}
+	buf.WriteString(expr[last:])
+
+	return buf.String(), nil
+}
+
+// normalizeField converts field references in the [a][b] syntax to dotted
+// field names.
+func normalizeField(field string) string {
+	if !strings.HasPrefix(field, "[") {
+		return field
+	}
+	field = strings.TrimSuffix(strings.TrimPrefix(field, "["), "]")
+	return strings.Replace(field, "][", ".", -1)
+}
+
+// match applies the expression to s and returns the captured values by field
+// name. If a field is captured more than once, the first match wins.
+func (m *matcher) match(s string) (map[string]interface{}, bool, error) {
+	loc := m.re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return nil, false, nil
+	}
+
+	fields := map[string]interface{}{}
+	for i, c := range m.captures {
+		if c == nil || loc[2*i] < 0 {
+			continue
+		}
+		if _, exists := fields[c.field]; exists {
+			continue
+		}
+
+		v, err := convert(s[loc[2*i]:loc[2*i+1]], c.typ)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to convert field %v", c.field)
+		}
+		fields[c.field] = v
+	}
+	return fields, true, nil
+}
+
+func convert(s string, typ valueType) (interface{}, error) {
+	switch typ {
+	case typeInt:
+		return strconv.Atoi(s)
+	case typeLong:
+		return strconv.ParseInt(s, 10, 64)
+	case typeFloat:
+		return strconv.ParseFloat(s, 64)
+	case typeBoolean:
+		return strconv.ParseBool(s)
+	}
+	return s, nil
+}
+
+func (m *matcher) String() string {
+	return fmt.Sprintf("%q", m.raw)
+}
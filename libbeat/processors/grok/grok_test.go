@@ -0,0 +1,173 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadDefaultPatterns(t *testing.T) map[string]string {
+	defs := map[string]string{}
+	require.NoError(t, readPatterns(strings.NewReader(defaultPatterns), defs))
+	return defs
+}
+
+func TestDefaultPatternsCompile(t *testing.T) {
+	defs := loadDefaultPatterns(t)
+	for name := range defs {
+		_, err := compile("%{"+name+"}", defs)
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestDefaultPatterns(t *testing.T) {
+	cases := []struct {
+		pattern string
+		input   string
+		match   string
+	}{
+		{"IPV4", "10.0.1.255", "10.0.1.255"},
+		{"IPV6", "2001:db8::ff00:42:8329", "2001:db8::ff00:42:8329"},
+		{"IPV6", "::1", "::1"},
+		{"IP", "fe80::1%eth0", "fe80::1%eth0"},
+		{"HOSTNAME", "www.elastic.co", "www.elastic.co"},
+		{"MAC", "00:1a:2b:3c:4d:5e", "00:1a:2b:3c:4d:5e"},
+		{"NUMBER", "-12.5", "-12.5"},
+		{"UUID", "123e4567-e89b-12d3-a456-426655440000", "123e4567-e89b-12d3-a456-426655440000"},
+		{"EMAILADDRESS", "john.doe@example.com", "john.doe@example.com"},
+		{"QUOTEDSTRING", `"say \"hi\"" rest`, `"say \"hi\""`},
+		{"URI", "https://user@example.com:8080/path/to?q=1", "https://user@example.com:8080/path/to?q=1"},
+		{"UNIXPATH", "/var/log/messages", "/var/log/messages"},
+		{"TIMESTAMP_ISO8601", "2019-08-12T09:56:23.123+02:00", "2019-08-12T09:56:23.123+02:00"},
+		{"HTTPDATE", "10/Oct/2000:13:55:36 -0700", "10/Oct/2000:13:55:36 -0700"},
+		{"SYSLOGTIMESTAMP", "Aug  2 07:01:02", "Aug  2 07:01:02"},
+		{"LOGLEVEL", "WARNING", "WARNING"},
+	}
+
+	defs := loadDefaultPatterns(t)
+	for _, test := range cases {
+		m, err := compile("^%{"+test.pattern+":value}", defs)
+		require.NoError(t, err)
+
+		fields, matched, err := m.match(test.input)
+		require.NoError(t, err)
+		if assert.True(t, matched, "%v does not match %v", test.pattern, test.input) {
+			assert.Equal(t, test.match, fields["value"], test.pattern)
+		}
+	}
+}
+
+func TestCombinedApacheLog(t *testing.T) {
+	m, err := compile("%{COMBINEDAPACHELOG}", loadDefaultPatterns(t))
+	require.NoError(t, err)
+
+	fields, matched, err := m.match(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`)
+	require.NoError(t, err)
+	require.True(t, matched)
+	assert.Equal(t, map[string]interface{}{
+		"clientip":    "127.0.0.1",
+		"ident":       "-",
+		"auth":        "frank",
+		"timestamp":   "10/Oct/2000:13:55:36 -0700",
+		"verb":        "GET",
+		"request":     "/apache_pb.gif",
+		"httpversion": "1.0",
+		"response":    "200",
+		"bytes":       "2326",
+		"referrer":    `"http://www.example.com/start.html"`,
+		"agent":       `"Mozilla/4.08"`,
+	}, fields)
+}
+
+func TestTypeConversion(t *testing.T) {
+	m, err := compile(`%{NUMBER:a:int} %{NUMBER:b:long} %{NUMBER:c:float} %{WORD:d:boolean} %{NUMBER:e}`, loadDefaultPatterns(t))
+	require.NoError(t, err)
+
+	fields, matched, err := m.match("1 2 3.5 true 4")
+	require.NoError(t, err)
+	require.True(t, matched)
+	assert.Equal(t, map[string]interface{}{
+		"a": 1,
+		"b": int64(2),
+		"c": 3.5,
+		"d": true,
+		"e": "4",
+	}, fields)
+
+	_, _, err = m.match("1.5 2 3 true 4")
+	assert.Error(t, err)
+}
+
+func TestNamedGroupsAndNestedFields(t *testing.T) {
+	m, err := compile(`(?<queue_id>[0-9A-F]{10}): %{WORD:[log][level]} (?P<source.port>\d+)`, loadDefaultPatterns(t))
+	require.NoError(t, err)
+
+	fields, matched, err := m.match("BEF25A7296: info 8080")
+	require.NoError(t, err)
+	require.True(t, matched)
+	assert.Equal(t, map[string]interface{}{
+		"queue_id":    "BEF25A7296",
+		"log.level":   "info",
+		"source.port": "8080",
+	}, fields)
+}
+
+func TestUnmatchedOptionalCapture(t *testing.T) {
+	m, err := compile(`%{WORD:a}(?: %{WORD:b})?`, loadDefaultPatterns(t))
+	require.NoError(t, err)
+
+	fields, matched, err := m.match("hello")
+	require.NoError(t, err)
+	require.True(t, matched)
+	assert.Equal(t, map[string]interface{}{"a": "hello"}, fields)
+}
+
+func TestCompileErrors(t *testing.T) {
+	defs := map[string]string{
+		"A": "%{B}",
+		"B": "%{A}",
+	}
+
+	cases := map[string]string{
+		"undefined": "%{UNKNOWN}",
+		"recursive": "%{A}",
+		"bad type":  "%{B:field:date}",
+		"bad regex": "(",
+	}
+	for name, pattern := range cases {
+		_, err := compile(pattern, defs)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestReadPatterns(t *testing.T) {
+	defs := map[string]string{}
+	err := readPatterns(strings.NewReader("# comment\n\nPOSTFIX_QUEUEID [0-9A-F]{6,}\nMY_WORD\t\\w+ \n"), defs)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"POSTFIX_QUEUEID": "[0-9A-F]{6,}",
+		"MY_WORD":         `\w+`,
+	}, defs)
+
+	err = readPatterns(strings.NewReader("MISSING\n"), defs)
+	assert.Error(t, err)
+}
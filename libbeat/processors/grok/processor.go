@@ -0,0 +1,151 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/libbeat/processors/script/javascript/module/processor"
+)
+
+const (
+	procName         = "grok"
+	flagParsingError = "grok_parsing_error"
+)
+
+type processor struct {
+	config   config
+	matchers []*matcher
+}
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("Grok", New)
+}
+
+// New constructs a new grok processor.
+func New(c *common.Config) (processors.Processor, error) {
+	config := defaultConfig
+	if err := c.Unpack(&config); err != nil {
+		return nil, errors.Wrap(err, "fail to unpack the "+procName+" processor configuration")
+	}
+	return newGrok(config)
+}
+
+func newGrok(c config) (*processor, error) {
+	defs := map[string]string{}
+	if err := readPatterns(strings.NewReader(defaultPatterns), defs); err != nil {
+		return nil, err
+	}
+
+	for _, path := range c.PatternFiles {
+		if err := readPatternFile(path, defs); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, def := range c.PatternDefinitions {
+		defs[name] = def
+	}
+
+	p := &processor{config: c}
+	for _, pattern := range c.Patterns {
+		m, err := compile(pattern, defs)
+		if err != nil {
+			return nil, err
+		}
+		p.matchers = append(p.matchers, m)
+	}
+	return p, nil
+}
+
+func readPatternFile(path string, defs map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open grok pattern file")
+	}
+	defer f.Close()
+
+	if err := readPatterns(f, defs); err != nil {
+		return errors.Wrapf(err, "failed to read grok pattern file %v", path)
+	}
+	return nil
+}
+
+// Run applies the patterns in order to the configured field. The fields
+// captured by the first matching pattern are added to the event.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	v, err := event.GetValue(p.config.Field)
+	if err != nil {
+		if p.config.IgnoreMissing {
+			return event, nil
+		}
+		return event, errors.Wrapf(err, "could not fetch value for field %v", p.config.Field)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return p.fail(event, fmt.Errorf("field is not a string, value: `%v`, field: `%s`", v, p.config.Field))
+	}
+
+	for _, m := range p.matchers {
+		fields, matched, err := m.match(s)
+		if err != nil {
+			return p.fail(event, err)
+		}
+		if !matched {
+			continue
+		}
+
+		prefix := ""
+		if p.config.TargetPrefix != "" {
+			prefix = p.config.TargetPrefix + "."
+		}
+		for k, v := range fields {
+			if _, err := event.PutValue(prefix+k, v); err != nil {
+				return p.fail(event, errors.Wrapf(err, "failed to set field %v", prefix+k))
+			}
+		}
+		return event, nil
+	}
+
+	return p.fail(event, fmt.Errorf("field %v does not match any grok pattern", p.config.Field))
+}
+
+func (p *processor) fail(event *beat.Event, err error) (*beat.Event, error) {
+	if err := common.AddTagsWithKey(event.Fields, beat.FlagField, []string{flagParsingError}); err != nil {
+		return event, errors.Wrap(err, "cannot add new flag the event")
+	}
+
+	if p.config.IgnoreFailure {
+		return event, nil
+	}
+	return event, err
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("%v=[field=%v, patterns=%v, target_prefix=%v]",
+		procName, p.config.Field, p.matchers, p.config.TargetPrefix)
+}
@@ -0,0 +1,119 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) *processor {
+	p, err := New(common.MustNewConfigFrom(settings))
+	require.NoError(t, err)
+	return p.(*processor)
+}
+
+func TestProcessorFirstMatch(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{
+		"patterns": []string{
+			`^%{IP:source.ip} %{WORD:http.request.method} %{URIPATHPARAM:url.original} %{NUMBER:http.response.bytes:long}$`,
+			`^%{IP:source.ip} %{GREEDYDATA:error.message}$`,
+		},
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{"message": "10.0.0.1 GET /index.html 1024"}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{
+		"message": "10.0.0.1 GET /index.html 1024",
+		"source":  common.MapStr{"ip": "10.0.0.1"},
+		"http": common.MapStr{
+			"request":  common.MapStr{"method": "GET"},
+			"response": common.MapStr{"bytes": int64(1024)},
+		},
+		"url": common.MapStr{"original": "/index.html"},
+	}, event.Fields)
+
+	event, err = p.Run(&beat.Event{Fields: common.MapStr{"message": "10.0.0.1 connection reset"}})
+	require.NoError(t, err)
+	assert.Equal(t, "connection reset", event.Fields["error"].(common.MapStr)["message"])
+}
+
+func TestProcessorCustomPatterns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grok")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "postfix")
+	require.NoError(t, ioutil.WriteFile(path, []byte("POSTFIX_QUEUEID [0-9A-F]{6,}\n"), 0600))
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"field":         "line",
+		"target_prefix": "postfix",
+		"patterns":      []string{`%{POSTFIX_QUEUEID:queue_id}: %{ACTION:action}`},
+		"pattern_files": []string{path},
+		"pattern_definitions": map[string]string{
+			"ACTION": "(?:removed|expired)",
+		},
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{"line": "BEF25A72965: removed"}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{"queue_id": "BEF25A72965", "action": "removed"}, event.Fields["postfix"])
+}
+
+func TestProcessorNoMatch(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{"patterns": []string{"^%{INT:code}$"}})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{"message": "abc"}})
+	assert.Error(t, err)
+	flags, err := event.GetValue(beat.FlagField)
+	require.NoError(t, err)
+	assert.Equal(t, []string{flagParsingError}, flags)
+
+	p.config.IgnoreFailure = true
+	_, err = p.Run(&beat.Event{Fields: common.MapStr{"message": "abc"}})
+	assert.NoError(t, err)
+}
+
+func TestProcessorMissingField(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{"patterns": []string{"%{INT:code}"}})
+
+	_, err := p.Run(&beat.Event{Fields: common.MapStr{}})
+	assert.Error(t, err)
+
+	p.config.IgnoreMissing = true
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{}})
+	assert.NoError(t, err)
+	assert.Equal(t, common.MapStr{}, event.Fields)
+}
+
+func TestProcessorInvalidConfig(t *testing.T) {
+	_, err := New(common.MustNewConfigFrom(map[string]interface{}{"patterns": []string{"%{UNKNOWN}"}}))
+	assert.Error(t, err)
+
+	_, err = New(common.MustNewConfigFrom(map[string]interface{}{"field": "message"}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lookup
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+type config struct {
+	Path           string        `config:"path" validate:"required"`         // Path to the dictionary file.
+	Format         format        `config:"format"`                           // Format of the dictionary file.
+	Key            string        `config:"key"`                              // Column or field holding the key of each entry.
+	Field          string        `config:"field" validate:"required"`        // Source field joined against the dictionary.
+	TargetField    string        `config:"target_field"`                     // Field receiving the whole matched entry.
+	Fields         common.MapStr `config:"fields"`                           // Mapping of entry columns to target fields.
+	Default        interface{}   `config:"default"`                          // Entry used when the value is not found.
+	OverwriteKeys  bool          `config:"overwrite_keys"`                   // Overwrite existing target fields.
+	IgnoreMissing  bool          `config:"ignore_missing"`                   // Ignore events without the source field.
+	ReloadInterval time.Duration `config:"reload_interval" validate:"min=0"` // How often to check the file for changes.
+	fieldsFlat     map[string]string
+}
+
+var defaultConfig = config{
+	ReloadInterval: time.Minute,
+}
+
+type format uint8
+
+const (
+	formatAuto format = iota
+	formatCSV
+	formatJSON
+	formatYAML
+)
+
+var formatNames = map[format]string{
+	formatAuto: "auto",
+	formatCSV:  "csv",
+	formatJSON: "json",
+	formatYAML: "yaml",
+}
+
+func (f format) String() string {
+	return formatNames[f]
+}
+
+// Unpack creates the format from the given string.
+func (f *format) Unpack(s string) error {
+	for k, name := range formatNames {
+		if strings.EqualFold(name, s) {
+			*f = k
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid dictionary format [%s]", s)
+}
+
+// formatOf returns the configured format, or detects it from the file
+// extension.
+func (c *config) formatOf() (format, error) {
+	if c.Format != formatAuto {
+		return c.Format, nil
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(c.Path)); ext {
+	case ".csv":
+		return formatCSV, nil
+	case ".json":
+		return formatJSON, nil
+	case ".yml", ".yaml":
+		return formatYAML, nil
+	default:
+		return formatAuto, errors.Errorf("cannot detect the format of dictionary %v, set the format option", c.Path)
+	}
+}
+
+// Validate validates the data contained in the config.
+func (c *config) Validate() error {
+	if _, err := c.formatOf(); err != nil {
+		return err
+	}
+
+	if c.TargetField == "" && len(c.Fields) == 0 {
+		return errors.New("one of target_field or fields must be configured")
+	}
+
+	c.Default = normalize(c.Default)
+
+	c.fieldsFlat = map[string]string{}
+	for k, v := range c.Fields.Flatten() {
+		target, ok := v.(string)
+		if !ok {
+			return errors.Errorf("target field for lookup column %v "+
+				"must be a string but got %T", k, v)
+		}
+		c.fieldsFlat[k] = target
+	}
+	return nil
+}
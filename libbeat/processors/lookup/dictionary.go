@@ -0,0 +1,196 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lookup
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// dictionary maps keys to entries. Entries are objects, or scalar values for
+// dictionaries mapping keys to a single value.
+type dictionary map[string]interface{}
+
+// loadDictionary reads a dictionary file in the given format. CSV files must
+// have a header row. JSON and YAML files can contain an object mapping keys to
+// entries, or a list of objects.
+func loadDictionary(path string, f format, key string) (dictionary, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read lookup dictionary")
+	}
+
+	var dict dictionary
+	switch f {
+	case formatCSV:
+		dict, err = parseCSV(data, key)
+	case formatJSON:
+		dict, err = parseJSON(data, key)
+	case formatYAML:
+		dict, err = parseYAML(data, key)
+	default:
+		err = errors.Errorf("unsupported format %v", f)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse lookup dictionary %v", path)
+	}
+	return dict, nil
+}
+
+// parseCSV reads a CSV file using the first row as column names. The key
+// column defaults to the first column. Empty cells are not added to entries.
+func parseCSV(data []byte, key string) (dictionary, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read header")
+	}
+
+	keyIdx := 0
+	if key != "" {
+		keyIdx = -1
+		for i, name := range header {
+			if name == key {
+				keyIdx = i
+				break
+			}
+		}
+		if keyIdx < 0 {
+			return nil, errors.Errorf("key column %v not found in header", key)
+		}
+	}
+
+	dict := dictionary{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return dict, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := common.MapStr{}
+		for i, value := range record {
+			if i == keyIdx || i >= len(header) || value == "" {
+				continue
+			}
+			entry.Put(header[i], value)
+		}
+		dict[record[keyIdx]] = entry
+	}
+}
+
+func parseJSON(data []byte, key string) (dictionary, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return fromValue(normalize(v), key)
+}
+
+func parseYAML(data []byte, key string) (dictionary, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return fromValue(normalize(v), key)
+}
+
+// fromValue builds a dictionary from a decoded object or list of objects.
+// The key is removed from the entries of a list.
+func fromValue(v interface{}, key string) (dictionary, error) {
+	switch value := v.(type) {
+	case common.MapStr:
+		dict := make(dictionary, len(value))
+		for k, entry := range value {
+			dict[k] = entry
+		}
+		return dict, nil
+
+	case []interface{}:
+		if key == "" {
+			return nil, errors.New("key must be configured for dictionaries containing a list")
+		}
+
+		dict := make(dictionary, len(value))
+		for i, elem := range value {
+			entry, ok := elem.(common.MapStr)
+			if !ok {
+				return nil, errors.Errorf("entry %d is not an object", i)
+			}
+			k, err := entry.GetValue(key)
+			if err != nil {
+				return nil, errors.Errorf("entry %d has no key %v", i, key)
+			}
+			entry = entry.Clone()
+			entry.Delete(key)
+			dict[fmt.Sprint(k)] = entry
+		}
+		return dict, nil
+
+	default:
+		return nil, errors.Errorf("dictionary must be an object or a list, got %T", v)
+	}
+}
+
+// normalize converts decoded objects to common.MapStr, and JSON numbers to
+// int64 or float64.
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		m := make(common.MapStr, len(value))
+		for k, elem := range value {
+			m[k] = normalize(elem)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(common.MapStr, len(value))
+		for k, elem := range value {
+			m[fmt.Sprint(k)] = normalize(elem)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, elem := range value {
+			list[i] = normalize(elem)
+		}
+		return list
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	default:
+		return v
+	}
+}
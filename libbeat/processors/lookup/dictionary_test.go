@@ -0,0 +1,87 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lookup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestParseCSV(t *testing.T) {
+	data := "host, owner.team, env\nweb-1, web, prod\ndb-1, dba,\n"
+
+	dict, err := parseCSV([]byte(data), "")
+	require.NoError(t, err)
+	assert.Equal(t, dictionary{
+		"web-1": common.MapStr{"owner": common.MapStr{"team": "web"}, "env": "prod"},
+		"db-1":  common.MapStr{"owner": common.MapStr{"team": "dba"}},
+	}, dict)
+
+	dict, err = parseCSV([]byte(data), "env")
+	require.NoError(t, err)
+	assert.Contains(t, dict, "prod")
+
+	_, err = parseCSV([]byte(data), "missing")
+	assert.Error(t, err)
+}
+
+func TestParseJSON(t *testing.T) {
+	dict, err := parseJSON([]byte(`{"alice": "team-a", "bob": {"team": "team-b", "level": 3}}`), "")
+	require.NoError(t, err)
+	assert.Equal(t, dictionary{
+		"alice": "team-a",
+		"bob":   common.MapStr{"team": "team-b", "level": int64(3)},
+	}, dict)
+
+	dict, err = parseJSON([]byte(`[{"id": 1, "name": "one"}, {"id": 2, "name": "two"}]`), "id")
+	require.NoError(t, err)
+	assert.Equal(t, dictionary{
+		"1": common.MapStr{"name": "one"},
+		"2": common.MapStr{"name": "two"},
+	}, dict)
+
+	_, err = parseJSON([]byte(`[{"id": 1}]`), "")
+	assert.Error(t, err)
+
+	_, err = parseJSON([]byte(`"scalar"`), "")
+	assert.Error(t, err)
+}
+
+func TestParseYAML(t *testing.T) {
+	data := `
+- user: alice
+  team: team-a
+- user: bob
+  team: team-b
+  tags: [oncall]
+`
+	dict, err := parseYAML([]byte(data), "user")
+	require.NoError(t, err)
+	assert.Equal(t, dictionary{
+		"alice": common.MapStr{"team": "team-a"},
+		"bob":   common.MapStr{"team": "team-b", "tags": []interface{}{"oncall"}},
+	}, dict)
+
+	dict, err = parseYAML([]byte("10: ten\n20: twenty\n"), "")
+	require.NoError(t, err)
+	assert.Equal(t, dictionary{"10": "ten", "20": "twenty"}, dict)
+}
@@ -0,0 +1,232 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lookup
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/atomic"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/libbeat/processors/script/javascript/module/processor"
+	"github.com/elastic/beats/libbeat/processors/util"
+)
+
+const (
+	procName = "lookup"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID = atomic.MakeUint32(0)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("Lookup", New)
+}
+
+type processor struct {
+	config
+	format  format
+	log     *logp.Logger
+	watcher *cfgfile.GlobWatcher
+
+	mu       sync.RWMutex
+	dict     dictionary
+	reloader *util.Reloader
+
+	hits    *monitoring.Int
+	misses  *monitoring.Int
+	entries *monitoring.Int
+	now     func() time.Time
+
+	metricsName string // Name of the monitoring registry, removed on Close.
+}
+
+// New constructs a new lookup processor.
+func New(cfg *common.Config) (processors.Processor, error) {
+	c := defaultConfig
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, errors.Wrap(err, "fail to unpack the "+procName+" processor configuration")
+	}
+
+	id := int(instanceID.Inc())
+	name := logName + "." + strconv.Itoa(id)
+	metrics := monitoring.Default.NewRegistry(name, monitoring.DoNotReport)
+	p, err := newLookup(c, metrics, logp.NewLogger(logName).With("instance_id", id))
+	if err != nil {
+		monitoring.Default.Remove(name)
+		return nil, err
+	}
+	p.metricsName = name
+	return p, nil
+}
+
+func newLookup(c config, metrics *monitoring.Registry, log *logp.Logger) (*processor, error) {
+	f, err := c.formatOf()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &processor{
+		config:  c,
+		format:  f,
+		log:     log,
+		watcher: cfgfile.NewGlobWatcher(c.Path),
+		hits:    monitoring.NewInt(metrics, "hits"),
+		misses:  monitoring.NewInt(metrics, "misses"),
+		entries: monitoring.NewInt(metrics, "entries"),
+		now:     time.Now,
+	}
+
+	// The first scan always reports changes, use it to initialize the watcher.
+	if _, _, err := p.watcher.Scan(); err != nil {
+		return nil, err
+	}
+	if p.dict, err = loadDictionary(c.Path, f, c.Key); err != nil {
+		return nil, err
+	}
+	p.entries.Set(int64(len(p.dict)))
+	p.reloader = util.NewReloader(p.now())
+	return p, nil
+}
+
+// Close removes the monitoring registry of the processor.
+func (p *processor) Close() error {
+	if p.metricsName != "" {
+		monitoring.Default.Remove(p.metricsName)
+	}
+	return nil
+}
+
+// Run joins the value of the source field against the dictionary and adds
+// the matching entry to the event.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	p.reloader.Run(p.now(), p.ReloadInterval, p.reload)
+
+	v, err := event.GetValue(p.Field)
+	if err != nil {
+		if p.IgnoreMissing && errors.Cause(err) == common.ErrKeyNotFound {
+			return event, nil
+		}
+		return event, errors.Wrapf(err, "could not fetch value for field %v", p.Field)
+	}
+
+	p.mu.RLock()
+	entry, found := p.dict[fmt.Sprint(v)]
+	p.mu.RUnlock()
+
+	if found {
+		p.hits.Inc()
+	} else {
+		p.misses.Inc()
+		if p.Default == nil {
+			return event, nil
+		}
+		entry = p.Default
+	}
+
+	if err := p.write(event, entry); err != nil {
+		return event, err
+	}
+	return event, nil
+}
+
+func (p *processor) write(event *beat.Event, entry interface{}) error {
+	if p.TargetField != "" {
+		if m, ok := entry.(common.MapStr); ok {
+			entry = m.Clone()
+		}
+		if err := p.put(event, p.TargetField, entry); err != nil {
+			return err
+		}
+	}
+
+	if len(p.fieldsFlat) == 0 {
+		return nil
+	}
+	m, ok := entry.(common.MapStr)
+	if !ok {
+		return errors.Errorf("cannot map columns of lookup entry of type %T", entry)
+	}
+	for column, target := range p.fieldsFlat {
+		v, err := m.GetValue(column)
+		if err != nil {
+			continue
+		}
+		if nested, ok := v.(common.MapStr); ok {
+			v = nested.Clone()
+		}
+		if err := p.put(event, target, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *processor) put(event *beat.Event, key string, value interface{}) error {
+	if !p.OverwriteKeys {
+		if _, err := event.GetValue(key); err == nil {
+			return nil
+		}
+	}
+	if _, err := event.PutValue(key, value); err != nil {
+		return errors.Wrapf(err, "failed to set field %v", key)
+	}
+	return nil
+}
+
+// reload reloads the dictionary if the file changed. The dictionary is loaded
+// without holding the lock, concurrent lookups use the previous dictionary
+// until the new one is swapped in.
+func (p *processor) reload() {
+	files, changed, err := p.watcher.Scan()
+	if err != nil {
+		p.log.Warnf("Failed to check lookup dictionary for changes: %v", err)
+		return
+	}
+	if !changed || len(files) == 0 {
+		return
+	}
+
+	dict, err := loadDictionary(p.Path, p.format, p.Key)
+	if err != nil {
+		p.log.Warnf("Failed to reload lookup dictionary, keeping the previous version: %v", err)
+		return
+	}
+	p.log.Infof("Reloaded lookup dictionary %v with %d entries", p.Path, len(dict))
+
+	p.mu.Lock()
+	p.dict = dict
+	p.mu.Unlock()
+	p.entries.Set(int64(len(dict)))
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("%v=[path=%v, format=%v, field=%v, target_field=%v, fields=%v]",
+		procName, p.Path, p.format, p.Field, p.TargetField, p.fieldsFlat)
+}
@@ -0,0 +1,184 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lookup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+const inventoryCSV = `host,team,env
+web-1,web,prod
+db-1,dba,staging
+`
+
+func writeDictionary(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) *processor {
+	c := defaultConfig
+	require.NoError(t, common.MustNewConfigFrom(settings).Unpack(&c))
+
+	p, err := newLookup(c, monitoring.NewRegistry(), logp.NewLogger(logName))
+	require.NoError(t, err)
+	return p
+}
+
+func TestLookupTargetField(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"path":         writeDictionary(t, dir, "inventory.csv", inventoryCSV),
+		"field":        "host.name",
+		"target_field": "inventory",
+		"default":      map[string]interface{}{"team": "unknown"},
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{"host": common.MapStr{"name": "web-1"}}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{"team": "web", "env": "prod"}, event.Fields["inventory"])
+
+	event, err = p.Run(&beat.Event{Fields: common.MapStr{"host": common.MapStr{"name": "other"}}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{"team": "unknown"}, event.Fields["inventory"])
+
+	assert.Equal(t, int64(1), p.hits.Get())
+	assert.Equal(t, int64(1), p.misses.Get())
+	assert.Equal(t, int64(2), p.entries.Get())
+}
+
+func TestLookupFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"path":  writeDictionary(t, dir, "users.json", `[{"uid": 1000, "name": "alice", "team": "sre"}]`),
+		"key":   "uid",
+		"field": "user.id",
+		"fields": map[string]interface{}{
+			"name": "user.name",
+			"team": "user.team",
+		},
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{
+		"user": common.MapStr{"id": 1000, "name": "root"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{"id": 1000, "name": "root", "team": "sre"}, event.Fields["user"])
+
+	event, err = p.Run(&beat.Event{Fields: common.MapStr{"user": common.MapStr{"id": 0}}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{"id": 0}, event.Fields["user"])
+
+	_, err = p.Run(&beat.Event{Fields: common.MapStr{}})
+	assert.Error(t, err)
+}
+
+func TestLookupFieldsNestedValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	p := newTestProcessor(t, map[string]interface{}{
+		"path":   writeDictionary(t, dir, "hosts.json", `[{"host": "web-1", "owner": {"team": "web"}}]`),
+		"key":    "host",
+		"field":  "host.name",
+		"fields": map[string]interface{}{"owner": "host.owner"},
+	})
+
+	lookupOwner := func() common.MapStr {
+		event, err := p.Run(&beat.Event{Fields: common.MapStr{"host": common.MapStr{"name": "web-1"}}})
+		require.NoError(t, err)
+		owner, err := event.GetValue("host.owner")
+		require.NoError(t, err)
+		return owner.(common.MapStr)
+	}
+
+	// Modifying the event must not modify the dictionary.
+	lookupOwner()["team"] = "modified"
+	assert.Equal(t, common.MapStr{"team": "web"}, lookupOwner())
+}
+
+func TestLookupReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := writeDictionary(t, dir, "teams.yml", "alice: team-a\n")
+	p := newTestProcessor(t, map[string]interface{}{
+		"path":            path,
+		"field":           "user.name",
+		"target_field":    "user.team",
+		"reload_interval": "1s",
+		"overwrite_keys":  true,
+	})
+
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{"user": common.MapStr{"name": "alice"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", event.Fields["user"].(common.MapStr)["team"])
+
+	writeDictionary(t, dir, "teams.yml", "alice: team-b\n")
+	now = now.Add(2 * time.Second)
+
+	event, err = p.Run(&beat.Event{Fields: common.MapStr{"user": common.MapStr{"name": "alice"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "team-b", event.Fields["user"].(common.MapStr)["team"])
+
+	// Invalid files are ignored, the previous dictionary is kept.
+	writeDictionary(t, dir, "teams.yml", "[")
+	now = now.Add(2 * time.Second)
+
+	event, err = p.Run(&beat.Event{Fields: common.MapStr{"user": common.MapStr{"name": "alice"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "team-b", event.Fields["user"].(common.MapStr)["team"])
+}
+
+func TestLookupInvalidConfig(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"unknown extension": {"path": "dict.txt", "field": "a", "target_field": "b"},
+		"no target":         {"path": "dict.csv", "field": "a"},
+		"invalid format":    {"path": "dict.csv", "field": "a", "target_field": "b", "format": "xml"},
+		"missing file":      {"path": "/does/not/exist.csv", "field": "a", "target_field": "b"},
+	}
+
+	for name, settings := range tests {
+		_, err := New(common.MustNewConfigFrom(settings))
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,116 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rate_limit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// config defines the configuration options for the rate_limit processor.
+type config struct {
+	Limit  rate     `config:"limit" validate:"required"` // Maximum rate of events, like 100/s.
+	Burst  int      `config:"burst" validate:"min=0"`    // Number of events allowed above the rate. Defaults to the limit.
+	Fields []string `config:"fields"`                    // Fields used to rate limit events independently per value.
+	Action action   `config:"action"`                    // Drop events or wait until events can be published.
+}
+
+// rate is the number of events allowed per interval.
+type rate struct {
+	events   float64
+	interval time.Duration
+}
+
+var rateUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// Unpack parses a rate in the format <events>/<unit>, where unit is one of s,
+// m or h.
+func (r *rate) Unpack(v string) error {
+	parts := strings.Split(v, "/")
+	if len(parts) != 2 {
+		return errors.Errorf("invalid rate '%v', expected format <events>/<s|m|h>", v)
+	}
+
+	count, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || count <= 0 {
+		return errors.Errorf("invalid number of events in rate '%v'", v)
+	}
+
+	unit, found := rateUnits[strings.TrimSpace(parts[1])]
+	if !found {
+		return errors.Errorf("invalid unit in rate '%v', expected one of s, m or h", v)
+	}
+
+	*r = rate{events: count, interval: unit}
+	return nil
+}
+
+// perSecond returns the number of events allowed per second.
+func (r rate) perSecond() float64 {
+	return r.events / r.interval.Seconds()
+}
+
+func (r rate) String() string {
+	for name, unit := range rateUnits {
+		if unit == r.interval {
+			return strconv.FormatFloat(r.events, 'f', -1, 64) + "/" + name
+		}
+	}
+	return strconv.FormatFloat(r.perSecond(), 'f', -1, 64) + "/s"
+}
+
+// action defines what happens to events exceeding the rate limit.
+type action uint8
+
+const (
+	actionDrop action = iota
+	actionWait
+)
+
+var actionNames = map[action]string{
+	actionDrop: "drop",
+	actionWait: "wait",
+}
+
+// String returns the action name.
+func (a action) String() string {
+	if name, found := actionNames[a]; found {
+		return name
+	}
+	return "unknown (" + strconv.Itoa(int(a)) + ")"
+}
+
+// Unpack unpacks a string to an action.
+func (a *action) Unpack(v string) error {
+	switch strings.ToLower(v) {
+	case "", "drop":
+		*a = actionDrop
+	case "wait":
+		*a = actionWait
+	default:
+		return errors.Errorf("invalid rate_limit action value '%v'", v)
+	}
+	return nil
+}
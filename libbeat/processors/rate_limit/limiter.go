@@ -0,0 +1,112 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rate_limit
+
+import (
+	"sync"
+	"time"
+)
+
+// gcInterval is the interval at which buckets that have been refilled
+// completely are removed.
+const gcInterval = time.Minute
+
+// limiter implements a token bucket per key. Each bucket holds up to burst
+// tokens and is refilled at a constant rate. Publishing an event takes one
+// token from the bucket.
+type limiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[string]*bucket
+	lastGC  time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	return &limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: map[string]*bucket{},
+	}
+}
+
+// reserve takes a token from the bucket of key. If no token is available and
+// wait is false, reserve returns false. If wait is true, the token is taken in
+// advance and reserve returns the duration to wait until the token would have
+// been available.
+func (l *limiter) reserve(key string, now time.Time, wait bool) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.gc(now)
+
+	b := l.buckets[key]
+	if b == nil {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, l.rate, l.burst)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	if !wait {
+		return 0, false
+	}
+
+	b.tokens--
+	return time.Duration(-b.tokens / l.rate * float64(time.Second)), true
+}
+
+// size returns the number of buckets currently tracked.
+func (l *limiter) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// gc removes buckets that are full, as these behave like new buckets.
+func (l *limiter) gc(now time.Time) {
+	if now.Sub(l.lastGC) < gcInterval {
+		return
+	}
+	l.lastGC = now
+
+	for key, b := range l.buckets {
+		b.refill(now, l.rate, l.burst)
+		if b.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (b *bucket) refill(now time.Time, rate, burst float64) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+		b.last = now
+	}
+}
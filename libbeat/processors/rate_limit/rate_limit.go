@@ -0,0 +1,144 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rate_limit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/atomic"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+const (
+	procName = "rate_limit"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID = atomic.MakeUint32(0)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+}
+
+type processor struct {
+	config
+	limiter *limiter
+	log     *logp.Logger
+
+	dropped *monitoring.Int // Number of events dropped.
+	delayed *monitoring.Int // Number of events delayed by the wait action.
+	keys    *monitoring.Int // Number of keys currently tracked.
+
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	metricsName string // Name of the monitoring registry, removed on Close.
+}
+
+// New constructs a new rate_limit processor.
+func New(cfg *common.Config) (processors.Processor, error) {
+	var c config
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, errors.Wrap(err, "fail to unpack the "+procName+" processor configuration")
+	}
+
+	id := int(instanceID.Inc())
+	name := logName + "." + strconv.Itoa(id)
+	metrics := monitoring.Default.NewRegistry(name, monitoring.DoNotReport)
+	p := newRateLimit(c, metrics, logp.NewLogger(logName).With("instance_id", id))
+	p.metricsName = name
+	return p, nil
+}
+
+func newRateLimit(c config, metrics *monitoring.Registry, log *logp.Logger) *processor {
+	burst := c.Burst
+	if burst == 0 {
+		burst = int(math.Max(1, math.Ceil(c.Limit.events)))
+	}
+
+	return &processor{
+		config:  c,
+		limiter: newLimiter(c.Limit.perSecond(), burst),
+		log:     log,
+		dropped: monitoring.NewInt(metrics, "dropped"),
+		delayed: monitoring.NewInt(metrics, "delayed"),
+		keys:    monitoring.NewInt(metrics, "keys"),
+		now:     time.Now,
+		sleep:   time.Sleep,
+	}
+}
+
+// Close removes the monitoring registry of the processor.
+func (p *processor) Close() error {
+	if p.metricsName != "" {
+		monitoring.Default.Remove(p.metricsName)
+	}
+	return nil
+}
+
+// Run drops or delays the event if the rate limit of the key it belongs to
+// is exceeded.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	key := p.key(event)
+	delay, ok := p.limiter.reserve(key, p.now(), p.Action == actionWait)
+	p.keys.Set(int64(p.limiter.size()))
+
+	if !ok {
+		p.dropped.Inc()
+		p.log.Debugf("Rate limit exceeded for key '%v', dropping event", key)
+		return nil, nil
+	}
+
+	if delay > 0 {
+		p.delayed.Inc()
+		p.sleep(delay)
+	}
+	return event, nil
+}
+
+// key builds the bucket key from the values of the configured fields. Missing
+// fields are treated as empty values.
+func (p *processor) key(event *beat.Event) string {
+	if len(p.Fields) == 0 {
+		return ""
+	}
+
+	values := make([]string, len(p.Fields))
+	for i, field := range p.Fields {
+		if v, err := event.GetValue(field); err == nil {
+			values[i] = fmt.Sprint(v)
+		}
+	}
+	return strings.Join(values, "\x00")
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("%v=[limit=%v, burst=%v, fields=%v, action=%v]",
+		procName, p.Limit, p.limiter.burst, p.Fields, p.Action)
+}
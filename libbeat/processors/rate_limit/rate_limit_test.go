@@ -0,0 +1,164 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rate_limit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+type testClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Sleep(d time.Duration) {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+}
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) (*processor, *testClock) {
+	var c config
+	require.NoError(t, common.MustNewConfigFrom(settings).Unpack(&c))
+
+	p := newRateLimit(c, monitoring.NewRegistry(), logp.NewLogger(logName))
+	clock := &testClock{now: time.Unix(1000, 0)}
+	p.now, p.sleep = clock.Now, clock.Sleep
+	return p, clock
+}
+
+func run(t *testing.T, p *processor, fields common.MapStr) bool {
+	out, err := p.Run(&beat.Event{Fields: fields})
+	require.NoError(t, err)
+	return out != nil
+}
+
+func TestConfig(t *testing.T) {
+	cases := map[string]struct {
+		limit     string
+		perSecond float64
+		err       bool
+	}{
+		"per second": {limit: "10/s", perSecond: 10},
+		"per minute": {limit: "120/m", perSecond: 2},
+		"per hour":   {limit: "1.5 / h", perSecond: 1.5 / 3600},
+		"no unit":    {limit: "10", err: true},
+		"bad unit":   {limit: "10/d", err: true},
+		"zero":       {limit: "0/s", err: true},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			var c config
+			err := common.MustNewConfigFrom(map[string]interface{}{"limit": test.limit}).Unpack(&c)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, test.perSecond, c.Limit.perSecond(), 1e-9)
+		})
+	}
+
+	var c config
+	err := common.MustNewConfigFrom(map[string]interface{}{"limit": "1/s", "action": "block"}).Unpack(&c)
+	assert.Error(t, err)
+}
+
+func TestDrop(t *testing.T) {
+	p, clock := newTestProcessor(t, map[string]interface{}{"limit": "2/s"})
+
+	// The burst defaults to the limit.
+	assert.True(t, run(t, p, common.MapStr{}))
+	assert.True(t, run(t, p, common.MapStr{}))
+	assert.False(t, run(t, p, common.MapStr{}))
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	assert.True(t, run(t, p, common.MapStr{}))
+	assert.False(t, run(t, p, common.MapStr{}))
+
+	assert.Equal(t, int64(2), p.dropped.Get())
+	assert.Empty(t, clock.slept)
+}
+
+func TestBurst(t *testing.T) {
+	p, _ := newTestProcessor(t, map[string]interface{}{"limit": "1/s", "burst": 3})
+
+	published := 0
+	for i := 0; i < 5; i++ {
+		if run(t, p, common.MapStr{}) {
+			published++
+		}
+	}
+	assert.Equal(t, 3, published)
+}
+
+func TestKeyedByFields(t *testing.T) {
+	p, _ := newTestProcessor(t, map[string]interface{}{
+		"limit":  "1/m",
+		"fields": []string{"host.name"},
+	})
+
+	a := common.MapStr{"host": common.MapStr{"name": "a"}}
+	b := common.MapStr{"host": common.MapStr{"name": "b"}}
+
+	assert.True(t, run(t, p, a))
+	assert.True(t, run(t, p, b))
+	assert.True(t, run(t, p, common.MapStr{}))
+	assert.False(t, run(t, p, a))
+	assert.False(t, run(t, p, b))
+	assert.Equal(t, int64(3), p.keys.Get())
+}
+
+func TestWait(t *testing.T) {
+	p, clock := newTestProcessor(t, map[string]interface{}{"limit": "4/s", "action": "wait"})
+
+	for i := 0; i < 6; i++ {
+		assert.True(t, run(t, p, common.MapStr{}))
+	}
+
+	assert.Equal(t, []time.Duration{250 * time.Millisecond, 250 * time.Millisecond}, clock.slept)
+	assert.Equal(t, int64(2), p.delayed.Get())
+	assert.Equal(t, int64(0), p.dropped.Get())
+}
+
+func TestRemoveFullBuckets(t *testing.T) {
+	p, clock := newTestProcessor(t, map[string]interface{}{
+		"limit":  "10/s",
+		"fields": []string{"key"},
+	})
+
+	for _, key := range []string{"a", "b", "c"} {
+		run(t, p, common.MapStr{"key": key})
+	}
+	assert.Equal(t, int64(3), p.keys.Get())
+
+	clock.now = clock.now.Add(gcInterval)
+	run(t, p, common.MapStr{"key": "d"})
+	assert.Equal(t, int64(1), p.keys.Get())
+}
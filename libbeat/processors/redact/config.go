@@ -0,0 +1,131 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/processors/fingerprint"
+)
+
+type config struct {
+	Fields        []string        `config:"fields" validate:"required"`
+	Detectors     *[]string       `config:"detectors"`
+	Patterns      []patternConfig `config:"patterns"`
+	Mode          mode            `config:"mode"`
+	Mask          string          `config:"mask"`
+	Partial       partialConfig   `config:"partial"`
+	Hash          hashConfig      `config:"hash"`
+	IgnoreMissing bool            `config:"ignore_missing"`
+}
+
+// patternConfig defines a custom detector. If the pattern contains capture
+// groups only the text matched by the first group is redacted.
+type patternConfig struct {
+	Name    string `config:"name" validate:"required"`
+	Pattern string `config:"pattern" validate:"required"`
+}
+
+type partialConfig struct {
+	KeepFirst int    `config:"keep_first" validate:"min=0"`
+	KeepLast  int    `config:"keep_last" validate:"min=0"`
+	MaskChar  string `config:"mask_char"`
+}
+
+type hashConfig struct {
+	Method   fingerprint.HashMethod     `config:"method"`
+	Encoding fingerprint.EncodingMethod `config:"encoding"`
+	Salt     string                     `config:"salt"`
+}
+
+type mode uint8
+
+const (
+	modeMask mode = iota
+	modePartial
+	modeHash
+)
+
+var modeNames = map[mode]string{
+	modeMask:    "mask",
+	modePartial: "partial",
+	modeHash:    "hash",
+}
+
+func (m mode) String() string {
+	return modeNames[m]
+}
+
+// Unpack creates the mode from the given string.
+func (m *mode) Unpack(s string) error {
+	for k, name := range modeNames {
+		if strings.EqualFold(name, s) {
+			*m = k
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid redaction mode [%s]", s)
+}
+
+func defaultConfig() config {
+	return config{
+		Mode: modeMask,
+		Mask: "[REDACTED]",
+		Partial: partialConfig{
+			KeepLast: 4,
+			MaskChar: "*",
+		},
+		Hash: hashConfig{
+			Method:   sha256.New,
+			Encoding: hex.EncodeToString,
+		},
+	}
+}
+
+// detectors returns the names of the built-in detectors to use. All of them
+// are used if the setting is not present.
+func (c *config) detectors() []string {
+	if c.Detectors == nil {
+		return []string{detectorCreditCard, detectorEmail, detectorIP, detectorBearerToken}
+	}
+	return *c.Detectors
+}
+
+func (c *config) Validate() error {
+	for _, name := range c.detectors() {
+		if _, found := builtinDetectors[name]; !found {
+			return fmt.Errorf("unknown detector [%s]", name)
+		}
+	}
+	for _, p := range c.Patterns {
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern for %s: %v", p.Name, err)
+		}
+	}
+	if len(c.detectors()) == 0 && len(c.Patterns) == 0 {
+		return fmt.Errorf("no detectors or patterns configured")
+	}
+	if len([]rune(c.Partial.MaskChar)) != 1 {
+		return fmt.Errorf("partial.mask_char must be a single character")
+	}
+	return nil
+}
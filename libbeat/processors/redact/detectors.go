@@ -0,0 +1,130 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+const (
+	detectorCreditCard  = "credit_card"
+	detectorEmail       = "email"
+	detectorIP          = "ip"
+	detectorBearerToken = "bearer_token"
+)
+
+// detector finds sensitive values in a string. Candidates matched by the
+// regular expression are only redacted if they pass the validation function.
+type detector struct {
+	name     string
+	re       *regexp.Regexp
+	validate func(string) bool
+}
+
+var builtinDetectors = map[string]detector{
+	detectorCreditCard: {
+		re:       regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		validate: luhn,
+	},
+	detectorEmail: {
+		re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`),
+	},
+	detectorIP: {
+		re: regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b|[0-9A-Fa-f:]*:[0-9A-Fa-f:]+(?:\.\d{1,3}){0,3}`),
+		validate: func(s string) bool {
+			return net.ParseIP(s) != nil
+		},
+	},
+	detectorBearerToken: {
+		re: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`),
+	},
+}
+
+func newDetectors(c config) []detector {
+	var detectors []detector
+	for _, name := range c.detectors() {
+		d := builtinDetectors[name]
+		d.name = name
+		detectors = append(detectors, d)
+	}
+	for _, p := range c.Patterns {
+		detectors = append(detectors, detector{
+			name: p.Name,
+			re:   regexp.MustCompile(p.Pattern),
+		})
+	}
+	return detectors
+}
+
+// redact replaces all values found in s using replace and returns the
+// resulting string and the number of values replaced.
+func (d *detector) redact(s string, replace func(string) string) (string, int) {
+	matches := d.re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, 0
+	}
+
+	var b strings.Builder
+	count, last := 0, 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+
+		value := s[start:end]
+		if d.validate != nil && !d.validate(value) {
+			continue
+		}
+
+		b.WriteString(s[last:start])
+		b.WriteString(replace(value))
+		last = end
+		count++
+	}
+	if count == 0 {
+		return s, 0
+	}
+	b.WriteString(s[last:])
+	return b.String(), count
+}
+
+// luhn checks the digits in s using the Luhn algorithm. Separators are
+// ignored.
+func luhn(s string) bool {
+	sum, digits := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		n := int(c - '0')
+		if digits%2 == 1 {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		digits++
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
@@ -0,0 +1,168 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/atomic"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/libbeat/processors/script/javascript/module/processor"
+)
+
+const (
+	procName = "redact"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID = atomic.MakeUint32(0)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("Redact", New)
+}
+
+type processor struct {
+	config
+	detectors []detector
+	replace   func(string) string
+
+	redactions *monitoring.Int            // Total number of values redacted.
+	counters   map[string]*monitoring.Int // Number of values redacted per detector.
+
+	metricsName string // Name of the monitoring registry, removed on Close.
+}
+
+// New constructs a new redact processor.
+func New(cfg *common.Config) (processors.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, errors.Wrap(err, "fail to unpack the "+procName+" processor configuration")
+	}
+
+	id := int(instanceID.Inc())
+	name := logName + "." + strconv.Itoa(id)
+	metrics := monitoring.Default.NewRegistry(name, monitoring.DoNotReport)
+	p := newRedact(c, metrics)
+	p.metricsName = name
+	return p, nil
+}
+
+func newRedact(c config, metrics *monitoring.Registry) *processor {
+	p := &processor{
+		config:     c,
+		detectors:  newDetectors(c),
+		replace:    newReplacer(c),
+		redactions: monitoring.NewInt(metrics, "redactions"),
+		counters:   map[string]*monitoring.Int{},
+	}
+
+	detectorMetrics := metrics.NewRegistry("detectors")
+	for _, d := range p.detectors {
+		if _, found := p.counters[d.name]; !found {
+			p.counters[d.name] = monitoring.NewInt(detectorMetrics, d.name)
+		}
+	}
+	return p
+}
+
+// Close removes the monitoring registry of the processor.
+func (p *processor) Close() error {
+	if p.metricsName != "" {
+		monitoring.Default.Remove(p.metricsName)
+	}
+	return nil
+}
+
+// Run redacts the sensitive values found in the configured fields. Fields
+// that are not strings or arrays of strings are left unchanged.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	for _, field := range p.Fields {
+		v, err := event.GetValue(field)
+		if err != nil {
+			if p.IgnoreMissing && errors.Cause(err) == common.ErrKeyNotFound {
+				continue
+			}
+			return event, errors.Wrapf(err, "could not fetch value for field %v", field)
+		}
+
+		redacted, changed := p.redactValue(v)
+		if !changed {
+			continue
+		}
+		if _, err := event.PutValue(field, redacted); err != nil {
+			return event, errors.Wrapf(err, "failed to set field %v", field)
+		}
+	}
+	return event, nil
+}
+
+func (p *processor) redactValue(v interface{}) (interface{}, bool) {
+	switch value := v.(type) {
+	case string:
+		s := p.redactString(value)
+		return s, s != value
+	case []string:
+		var changed bool
+		redacted := make([]string, len(value))
+		for i, s := range value {
+			redacted[i] = p.redactString(s)
+			changed = changed || redacted[i] != s
+		}
+		return redacted, changed
+	case []interface{}:
+		var changed bool
+		redacted := make([]interface{}, len(value))
+		for i, elem := range value {
+			var elemChanged bool
+			redacted[i], elemChanged = p.redactValue(elem)
+			changed = changed || elemChanged
+		}
+		return redacted, changed
+	default:
+		return v, false
+	}
+}
+
+func (p *processor) redactString(s string) string {
+	for i := range p.detectors {
+		d := &p.detectors[i]
+		var n int
+		s, n = d.redact(s, p.replace)
+		if n > 0 {
+			p.redactions.Add(int64(n))
+			p.counters[d.name].Add(int64(n))
+		}
+	}
+	return s
+}
+
+func (p *processor) String() string {
+	names := make([]string, len(p.detectors))
+	for i, d := range p.detectors {
+		names[i] = d.name
+	}
+	return fmt.Sprintf("%v=[fields=%v, detectors=%v, mode=%v]", procName, p.Fields, names, p.Mode)
+}
@@ -0,0 +1,130 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) (*processor, *monitoring.Registry) {
+	c := defaultConfig()
+	require.NoError(t, common.MustNewConfigFrom(settings).Unpack(&c))
+
+	metrics := monitoring.NewRegistry()
+	return newRedact(c, metrics), metrics
+}
+
+func TestRedactMask(t *testing.T) {
+	p, metrics := newTestProcessor(t, map[string]interface{}{
+		"fields": []string{"message", "tags"},
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{
+		"message": "user jane@example.com paid with 4111 1111 1111 1111 from 10.1.2.3",
+		"tags":    []interface{}{"ok", "admin@example.com"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "user [REDACTED] paid with [REDACTED] from [REDACTED]", event.Fields["message"])
+	assert.Equal(t, []interface{}{"ok", "[REDACTED]"}, event.Fields["tags"])
+
+	snapshot := monitoring.CollectFlatSnapshot(metrics, monitoring.Full, false)
+	assert.Equal(t, int64(4), snapshot.Ints["redactions"])
+	assert.Equal(t, int64(2), snapshot.Ints["detectors.email"])
+	assert.Equal(t, int64(1), snapshot.Ints["detectors.credit_card"])
+	assert.Equal(t, int64(1), snapshot.Ints["detectors.ip"])
+}
+
+func TestRedactCustomPatterns(t *testing.T) {
+	p, metrics := newTestProcessor(t, map[string]interface{}{
+		"fields":    []string{"message"},
+		"detectors": []string{},
+		"patterns": []map[string]interface{}{
+			{"name": "password", "pattern": `password=(\S+)`},
+		},
+		"mode":              "partial",
+		"partial.keep_last": 2,
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{
+		"message": "login password=hunter2 from 10.1.2.3",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "login password=*****r2 from 10.1.2.3", event.Fields["message"])
+
+	snapshot := monitoring.CollectFlatSnapshot(metrics, monitoring.Full, false)
+	assert.Equal(t, int64(1), snapshot.Ints["detectors.password"])
+}
+
+func TestRedactHash(t *testing.T) {
+	p, _ := newTestProcessor(t, map[string]interface{}{
+		"fields":      []string{"user.email"},
+		"detectors":   []string{"email"},
+		"mode":        "hash",
+		"hash.method": "sha1",
+		"hash.salt":   "pepper",
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{
+		"user": common.MapStr{"email": "jane@example.com"},
+	}})
+	require.NoError(t, err)
+
+	sum := sha1.Sum([]byte("pepperjane@example.com"))
+	email, _ := event.GetValue("user.email")
+	assert.Equal(t, hex.EncodeToString(sum[:]), email)
+}
+
+func TestRedactMissingField(t *testing.T) {
+	p, _ := newTestProcessor(t, map[string]interface{}{
+		"fields": []string{"message"},
+	})
+	_, err := p.Run(&beat.Event{Fields: common.MapStr{}})
+	assert.Error(t, err)
+
+	p, _ = newTestProcessor(t, map[string]interface{}{
+		"fields":         []string{"message"},
+		"ignore_missing": true,
+	})
+	_, err = p.Run(&beat.Event{Fields: common.MapStr{}})
+	assert.NoError(t, err)
+}
+
+func TestRedactInvalidConfig(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"unknown detector": {"fields": []string{"message"}, "detectors": []string{"ssn"}},
+		"invalid pattern":  {"fields": []string{"message"}, "patterns": []map[string]interface{}{{"name": "x", "pattern": "("}}},
+		"invalid mode":     {"fields": []string{"message"}, "mode": "scramble"},
+		"invalid method":   {"fields": []string{"message"}, "hash.method": "crc32"},
+		"nothing to do":    {"fields": []string{"message"}, "detectors": []string{}},
+		"no fields":        {},
+	}
+
+	for name, settings := range tests {
+		_, err := New(common.MustNewConfigFrom(settings))
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,65 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"strings"
+)
+
+// newReplacer returns the function used to replace detected values according
+// to the configured mode.
+func newReplacer(c config) func(string) string {
+	switch c.Mode {
+	case modePartial:
+		return partialMask(c.Partial)
+	case modeHash:
+		return saltedHash(c.Hash)
+	default:
+		return func(string) string { return c.Mask }
+	}
+}
+
+// partialMask keeps the first and last characters of a value and replaces
+// the rest with the mask character. Values that are too short to keep any
+// character are masked completely.
+func partialMask(c partialConfig) func(string) string {
+	return func(s string) string {
+		runes := []rune(s)
+		keepFirst, keepLast := c.KeepFirst, c.KeepLast
+		if keepFirst+keepLast >= len(runes) {
+			keepFirst, keepLast = 0, 0
+		}
+
+		masked := len(runes) - keepFirst - keepLast
+		return string(runes[:keepFirst]) +
+			strings.Repeat(c.MaskChar, masked) +
+			string(runes[len(runes)-keepLast:])
+	}
+}
+
+// saltedHash replaces a value with the encoded hash of the salt followed by
+// the value.
+func saltedHash(c hashConfig) func(string) string {
+	salt := []byte(c.Salt)
+	return func(s string) string {
+		h := c.Method()
+		h.Write(salt)
+		h.Write([]byte(s))
+		return c.Encoding(h.Sum(nil))
+	}
+}
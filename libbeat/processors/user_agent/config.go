@@ -0,0 +1,32 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package user_agent
+
+type config struct {
+	Field         string `config:"field"`                       // Field holding the user agent string.
+	TargetField   string `config:"target_field"`                // Field the parsed user agent is written to.
+	Regexes       string `config:"regexes" validate:"required"` // Path to a uap-core regexes.yaml file.
+	CacheSize     int    `config:"cache.size" validate:"min=0"` // Number of parsed user agents to cache.
+	IgnoreMissing bool   `config:"ignore_missing"`              // Ignore events without the source field.
+}
+
+var defaultConfig = config{
+	Field:       "user_agent.original",
+	TargetField: "user_agent",
+	CacheSize:   10000,
+}
@@ -0,0 +1,210 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package user_agent
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// other is the name used by uap-core for unknown agents, operating systems
+// and devices.
+const other = "Other"
+
+// definitions is the content of a uap-core regexes.yaml file.
+type definitions struct {
+	UserAgentParsers []struct {
+		Regex             string `yaml:"regex"`
+		RegexFlag         string `yaml:"regex_flag"`
+		FamilyReplacement string `yaml:"family_replacement"`
+		V1Replacement     string `yaml:"v1_replacement"`
+		V2Replacement     string `yaml:"v2_replacement"`
+		V3Replacement     string `yaml:"v3_replacement"`
+	} `yaml:"user_agent_parsers"`
+
+	OSParsers []struct {
+		Regex           string `yaml:"regex"`
+		RegexFlag       string `yaml:"regex_flag"`
+		OSReplacement   string `yaml:"os_replacement"`
+		OSV1Replacement string `yaml:"os_v1_replacement"`
+		OSV2Replacement string `yaml:"os_v2_replacement"`
+		OSV3Replacement string `yaml:"os_v3_replacement"`
+		OSV4Replacement string `yaml:"os_v4_replacement"`
+	} `yaml:"os_parsers"`
+
+	DeviceParsers []struct {
+		Regex             string `yaml:"regex"`
+		RegexFlag         string `yaml:"regex_flag"`
+		DeviceReplacement string `yaml:"device_replacement"`
+	} `yaml:"device_parsers"`
+}
+
+// rule is a compiled uap-core parser. The replacements are applied in order,
+// each defaulting to the capture group following the previous one.
+type rule struct {
+	re           *regexp.Regexp
+	replacements []string
+}
+
+// parser extracts the agent, operating system and device from user agent
+// strings.
+type parser struct {
+	agents  []rule
+	os      []rule
+	devices []rule
+	skipped int // number of regexes not supported by RE2
+}
+
+type userAgent struct {
+	name    string
+	version []string
+	os      string
+	osVer   []string
+	device  string
+}
+
+func loadParser(path string) (*parser, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read user agent regexes")
+	}
+	return newParser(content)
+}
+
+func newParser(content []byte) (*parser, error) {
+	var defs definitions
+	if err := yaml.Unmarshal(content, &defs); err != nil {
+		return nil, errors.Wrap(err, "invalid user agent regexes")
+	}
+
+	p := &parser{}
+	for _, d := range defs.UserAgentParsers {
+		p.agents = p.add(p.agents, d.Regex, d.RegexFlag,
+			d.FamilyReplacement, d.V1Replacement, d.V2Replacement, d.V3Replacement)
+	}
+	for _, d := range defs.OSParsers {
+		p.os = p.add(p.os, d.Regex, d.RegexFlag,
+			d.OSReplacement, d.OSV1Replacement, d.OSV2Replacement, d.OSV3Replacement, d.OSV4Replacement)
+	}
+	for _, d := range defs.DeviceParsers {
+		p.devices = p.add(p.devices, d.Regex, d.RegexFlag, d.DeviceReplacement)
+	}
+
+	if len(p.agents) == 0 && len(p.os) == 0 && len(p.devices) == 0 {
+		return nil, errors.New("no user agent regexes defined")
+	}
+	return p, nil
+}
+
+// add compiles a rule and appends it to rules. Expressions using features
+// not supported by Go regular expressions are skipped.
+func (p *parser) add(rules []rule, expr, flag string, replacements ...string) []rule {
+	if flag == "i" {
+		expr = "(?i)" + expr
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		p.skipped++
+		return rules
+	}
+	return append(rules, rule{re: re, replacements: replacements})
+}
+
+// apply matches s against the rules in order. The values of the first
+// matching rule are returned.
+func apply(rules []rule, s string) []string {
+	for _, r := range rules {
+		loc := r.re.FindStringSubmatchIndex(s)
+		if loc == nil {
+			continue
+		}
+
+		values := make([]string, len(r.replacements))
+		for i, replacement := range r.replacements {
+			if replacement != "" {
+				values[i] = expand(replacement, s, loc)
+			} else {
+				values[i] = group(s, loc, i+1)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// expand substitutes the $1 to $9 references in a replacement.
+func expand(replacement, s string, loc []int) string {
+	if !strings.Contains(replacement, "$") {
+		return replacement
+	}
+
+	var buf strings.Builder
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+		if c == '$' && i+1 < len(replacement) && replacement[i+1] >= '1' && replacement[i+1] <= '9' {
+			n, _ := strconv.Atoi(replacement[i+1 : i+2])
+			buf.WriteString(group(s, loc, n))
+			i++
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func group(s string, loc []int, n int) string {
+	if 2*n+1 >= len(loc) || loc[2*n] < 0 {
+		return ""
+	}
+	return s[loc[2*n]:loc[2*n+1]]
+}
+
+// parse parses a user agent string. Unknown agents, operating systems and
+// devices are reported as Other.
+func (p *parser) parse(s string) userAgent {
+	ua := userAgent{name: other, os: other, device: other}
+
+	if values := apply(p.agents, s); values != nil && values[0] != "" {
+		ua.name = values[0]
+		ua.version = versionParts(values[1:])
+	}
+	if values := apply(p.os, s); values != nil && values[0] != "" {
+		ua.os = values[0]
+		ua.osVer = versionParts(values[1:])
+	}
+	if values := apply(p.devices, s); values != nil && values[0] != "" {
+		ua.device = values[0]
+	}
+	return ua
+}
+
+// versionParts returns the leading non-empty version components.
+func versionParts(parts []string) []string {
+	for i, part := range parts {
+		if part == "" {
+			return parts[:i]
+		}
+	}
+	return parts
+}
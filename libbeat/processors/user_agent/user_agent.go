@@ -0,0 +1,137 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package user_agent
+
+import (
+	"fmt"
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/libbeat/processors/script/javascript/module/processor"
+)
+
+const (
+	procName = "user_agent"
+	logName  = "processor." + procName
+)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("UserAgent", New)
+}
+
+type processor struct {
+	config
+	parser *parser
+	cache  *lru.Cache
+	log    *logp.Logger
+}
+
+// New constructs a new user_agent processor.
+func New(cfg *common.Config) (processors.Processor, error) {
+	c := defaultConfig
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, errors.Wrap(err, "fail to unpack the "+procName+" processor configuration")
+	}
+	return newUserAgent(c, logp.NewLogger(logName))
+}
+
+func newUserAgent(c config, log *logp.Logger) (*processor, error) {
+	parser, err := loadParser(c.Regexes)
+	if err != nil {
+		return nil, err
+	}
+	if parser.skipped > 0 {
+		log.Warnf("Skipped %v user agent regexes not supported by Go regular expressions", parser.skipped)
+	}
+
+	p := &processor{config: c, parser: parser, log: log}
+	if c.CacheSize > 0 {
+		if p.cache, err = lru.New(c.CacheSize); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Run parses the user agent string and adds the name, version, operating
+// system and device to the target field.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	v, err := event.GetValue(p.Field)
+	if err != nil {
+		if p.IgnoreMissing {
+			return event, nil
+		}
+		return event, errors.Wrapf(err, "could not fetch value for field %v", p.Field)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return event, fmt.Errorf("field is not a string, value: `%v`, field: `%s`", v, p.Field)
+	}
+
+	fields := p.parse(s)
+	for k, v := range fields {
+		if _, err := event.PutValue(p.TargetField+"."+k, v); err != nil {
+			return event, errors.Wrapf(err, "failed to set field %v", p.TargetField+"."+k)
+		}
+	}
+	return event, nil
+}
+
+// parse returns the ECS user_agent fields, without the original string.
+func (p *processor) parse(s string) common.MapStr {
+	if p.cache != nil {
+		if fields, found := p.cache.Get(s); found {
+			return fields.(common.MapStr).Clone()
+		}
+	}
+
+	ua := p.parser.parse(s)
+	fields := common.MapStr{
+		"name":   ua.name,
+		"device": common.MapStr{"name": ua.device},
+	}
+	if len(ua.version) > 0 {
+		fields["version"] = strings.Join(ua.version, ".")
+	}
+
+	os := common.MapStr{"name": ua.os}
+	if len(ua.osVer) > 0 {
+		version := strings.Join(ua.osVer, ".")
+		os["version"] = version
+		os["full"] = ua.os + " " + version
+	}
+	fields["os"] = os
+
+	if p.cache != nil {
+		p.cache.Add(s, fields.Clone())
+	}
+	return fields
+}
+
+func (p *processor) String() string {
+	return fmt.Sprintf("%v=[field=%v, target_field=%v, regexes=%v]",
+		procName, p.Field, p.TargetField, p.Regexes)
+}
@@ -0,0 +1,143 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package user_agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+func newTestProcessor(t *testing.T, settings map[string]interface{}) *processor {
+	c := defaultConfig
+	settings["regexes"] = "testdata/regexes.yaml"
+	require.NoError(t, common.MustNewConfigFrom(settings).Unpack(&c))
+
+	p, err := newUserAgent(c, logp.NewLogger(logName))
+	require.NoError(t, err)
+	return p
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]common.MapStr{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/70.0.3538.102 Safari/537.36 Edge/18.18362": {
+			"name":    "Edge",
+			"version": "18.18362",
+			"os":      common.MapStr{"name": "Windows", "version": "10", "full": "Windows 10"},
+			"device":  common.MapStr{"name": "Other"},
+		},
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/77.0.3865.90 Safari/537.36": {
+			"name":    "Chrome",
+			"version": "77.0.3865",
+			"os":      common.MapStr{"name": "Mac OS X", "version": "10.14.6", "full": "Mac OS X 10.14.6"},
+			"device":  common.MapStr{"name": "Mac"},
+		},
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 12_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1.2 Mobile/15E148 Safari/604.1": {
+			"name":    "Safari",
+			"version": "12.1.2",
+			"os":      common.MapStr{"name": "iOS", "version": "12.4", "full": "iOS 12.4"},
+			"device":  common.MapStr{"name": "iPhone"},
+		},
+		"Mozilla/5.0 (X11; Linux x86_64; rv:69.0) Gecko/20100101 Firefox/69.0": {
+			"name":    "Firefox",
+			"version": "69.0",
+			"os":      common.MapStr{"name": "Linux"},
+			"device":  common.MapStr{"name": "Other"},
+		},
+		"curl/7.54.0": {
+			"name":    "cURL",
+			"version": "7.54.0",
+			"os":      common.MapStr{"name": "Other"},
+			"device":  common.MapStr{"name": "Other"},
+		},
+		"something unknown": {
+			"name":   "Other",
+			"os":     common.MapStr{"name": "Other"},
+			"device": common.MapStr{"name": "Other"},
+		},
+	}
+
+	p := newTestProcessor(t, map[string]interface{}{})
+	assert.Equal(t, 1, p.parser.skipped)
+
+	for ua, expected := range cases {
+		event, err := p.Run(&beat.Event{Fields: common.MapStr{
+			"user_agent": common.MapStr{"original": ua},
+		}})
+		require.NoError(t, err)
+
+		expected["original"] = ua
+		assert.Equal(t, expected, event.Fields["user_agent"], ua)
+	}
+}
+
+func TestCustomFields(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{
+		"field":        "http.agent",
+		"target_field": "agent",
+	})
+
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{"http": common.MapStr{"agent": "curl/7.54.0"}}})
+	require.NoError(t, err)
+	v, err := event.GetValue("agent.name")
+	require.NoError(t, err)
+	assert.Equal(t, "cURL", v)
+}
+
+func TestCache(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{"cache.size": 10})
+
+	for i := 0; i < 2; i++ {
+		event, err := p.Run(&beat.Event{Fields: common.MapStr{"user_agent": common.MapStr{"original": "curl/7.54.0"}}})
+		require.NoError(t, err)
+		v, _ := event.GetValue("user_agent.name")
+		assert.Equal(t, "cURL", v)
+
+		// Modifying the event must not modify the cached value.
+		event.PutValue("user_agent.os.name", "modified")
+	}
+	assert.Equal(t, 1, p.cache.Len())
+}
+
+func TestMissingField(t *testing.T) {
+	p := newTestProcessor(t, map[string]interface{}{})
+
+	_, err := p.Run(&beat.Event{Fields: common.MapStr{}})
+	assert.Error(t, err)
+
+	p.IgnoreMissing = true
+	event, err := p.Run(&beat.Event{Fields: common.MapStr{}})
+	assert.NoError(t, err)
+	assert.Equal(t, common.MapStr{}, event.Fields)
+}
+
+func TestInvalidRegexes(t *testing.T) {
+	_, err := newParser([]byte("user_agent_parsers: [}"))
+	assert.Error(t, err)
+
+	_, err = newParser([]byte("other: []"))
+	assert.Error(t, err)
+
+	_, err = New(common.MustNewConfigFrom(map[string]interface{}{"regexes": "testdata/missing.yaml"}))
+	assert.Error(t, err)
+}
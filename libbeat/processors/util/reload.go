@@ -0,0 +1,68 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package util
+
+import (
+	"time"
+
+	"github.com/elastic/beats/libbeat/common/atomic"
+)
+
+// Reloader limits how often processors check the files they read, like
+// databases or dictionaries, for changes. Checks are run by the processor
+// calling Run, at most once per interval, and never concurrently. Callers
+// never wait for a check run by another caller, such that events are not
+// delayed while files are reloaded.
+type Reloader struct {
+	// lastCheck holds the time of the last check in nanoseconds since the
+	// epoch.
+	lastCheck atomic.Int64
+	running   atomic.Bool
+}
+
+// NewReloader creates a Reloader whose first check is run one interval after
+// now.
+func NewReloader(now time.Time) *Reloader {
+	r := &Reloader{}
+	r.lastCheck.Store(now.UnixNano())
+	return r
+}
+
+// Run calls check if interval has passed since the last check. Checks are
+// disabled if interval is not positive.
+func (r *Reloader) Run(now time.Time, interval time.Duration, check func()) {
+	if interval <= 0 {
+		return
+	}
+
+	last := r.lastCheck.Load()
+	if now.Sub(time.Unix(0, last)) < interval {
+		return
+	}
+	if !r.lastCheck.CAS(last, now.UnixNano()) {
+		// another caller is running the check
+		return
+	}
+	if r.running.Swap(true) {
+		// the previous check takes longer than the interval
+		return
+	}
+	defer r.running.Store(false)
+
+	check()
+}
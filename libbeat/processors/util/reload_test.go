@@ -0,0 +1,76 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package util
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloaderInterval(t *testing.T) {
+	now := time.Now()
+	r := NewReloader(now)
+
+	var checks int
+	check := func() { checks++ }
+
+	r.Run(now.Add(30*time.Second), time.Minute, check)
+	assert.Equal(t, 0, checks)
+
+	r.Run(now.Add(time.Minute), time.Minute, check)
+	assert.Equal(t, 1, checks)
+
+	// The interval starts at the last check.
+	r.Run(now.Add(90*time.Second), time.Minute, check)
+	assert.Equal(t, 1, checks)
+
+	// Checks are disabled without interval.
+	r.Run(now.Add(time.Hour), 0, check)
+	assert.Equal(t, 1, checks)
+}
+
+func TestReloaderSingleCheck(t *testing.T) {
+	now := time.Now()
+	r := NewReloader(now)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.Run(now.Add(time.Minute), time.Minute, func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	// Callers do not wait for nor overlap with a running check.
+	ran := false
+	r.Run(now.Add(time.Hour), time.Minute, func() { ran = true })
+	assert.False(t, ran)
+
+	close(release)
+	wg.Wait()
+	r.Run(now.Add(2*time.Hour), time.Minute, func() { ran = true })
+	assert.True(t, ran)
+}
@@ -0,0 +1,103 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/publisher"
+	"github.com/elastic/beats/libbeat/publisher/queue"
+)
+
+// asyncSupporter publishes an event with its own client when it is stopped.
+type asyncSupporter struct {
+	started   int
+	stopped   int
+	connector beat.PipelineConnector
+}
+
+func (s *asyncSupporter) Create(_ beat.ProcessingConfig, _ bool) (beat.Processor, error) {
+	return nil, nil
+}
+
+func (s *asyncSupporter) Start(connector beat.PipelineConnector) error {
+	s.started++
+	s.connector = connector
+	return nil
+}
+
+func (s *asyncSupporter) Stop() {
+	s.stopped++
+	client, err := s.connector.Connect()
+	if err != nil {
+		panic(err)
+	}
+	client.Publish(beat.Event{Fields: common.MapStr{"summary": true}})
+	client.Close()
+}
+
+func TestAsyncProcessing(t *testing.T) {
+	var mu sync.Mutex
+	var published []publisher.Event
+	recordingProducer := func(_ queue.ProducerConfig) queue.Producer {
+		return &testProducer{
+			publish: func(_ bool, event publisher.Event) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				published = append(published, event)
+				return true
+			},
+		}
+	}
+
+	supporter := &asyncSupporter{}
+	pipeline, err := New(beat.Info{},
+		Monitors{},
+		func(_ queue.Eventer) (queue.Queue, error) {
+			return makeTestQueue(emptyConsumer, recordingProducer), nil
+		},
+		outputs.Group{},
+		Settings{Processors: supporter},
+	)
+	require.NoError(t, err)
+
+	// Asynchronous processing is started when the first client connects.
+	assert.Equal(t, 0, supporter.started)
+	for i := 0; i < 2; i++ {
+		client, err := pipeline.Connect()
+		require.NoError(t, err)
+		client.Close()
+	}
+	assert.Equal(t, 1, supporter.started)
+
+	// Events emitted on stop are published before the pipeline is closed.
+	pipeline.Close()
+	assert.Equal(t, 1, supporter.stopped)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, published, 1)
+	assert.Equal(t, common.MapStr{"summary": true}, published[0].Content.Fields)
+}
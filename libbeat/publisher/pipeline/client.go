@@ -23,6 +23,7 @@ import (
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common/atomic"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/processors"
 	"github.com/elastic/beats/libbeat/publisher"
 	"github.com/elastic/beats/libbeat/publisher/queue"
 )
@@ -39,6 +40,10 @@ type client struct {
 	mutex      sync.Mutex
 	acker      acker
 
+	// clientProcessors are the processors passed in the client configuration.
+	// They are closed with the client.
+	clientProcessors beat.ProcessorList
+
 	eventFlags   publisher.EventFlags
 	canDrop      bool
 	reportEvents bool
@@ -182,9 +187,21 @@ func (c *client) unlink() {
 		}
 	}
 
+	c.closeProcessors()
 	c.onClosed()
 }
 
+// closeProcessors releases the resources held by the processors of the client,
+// like their monitoring registries and background goroutines.
+func (c *client) closeProcessors() {
+	if c.clientProcessors == nil {
+		return
+	}
+	if err := processors.Close(c.clientProcessors); err != nil {
+		c.logger().Errorf("Failed to close client processors: %v", err)
+	}
+}
+
 func (c *client) logger() *logp.Logger {
 	return c.pipeline.monitors.Logger
 }
@@ -113,3 +113,45 @@ func TestClient(t *testing.T) {
 		}
 	})
 }
+
+// closingProcessors records whether the processors were closed.
+type closingProcessors struct {
+	closed bool
+}
+
+func (p *closingProcessors) Run(event *beat.Event) (*beat.Event, error) { return event, nil }
+func (p *closingProcessors) All() []beat.Processor                      { return []beat.Processor{p} }
+func (p *closingProcessors) String() string                             { return "closing" }
+
+func (p *closingProcessors) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestClientClosesProcessors(t *testing.T) {
+	pipeline, err := New(beat.Info{},
+		Monitors{},
+		func(_ queue.Eventer) (queue.Queue, error) {
+			return makeBlockingQueue(), nil
+		},
+		outputs.Group{},
+		Settings{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pipeline.Close()
+
+	procs := &closingProcessors{}
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		Processing: beat.ProcessingConfig{Processor: procs},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.Close()
+	if !procs.closed {
+		t.Fatal("client processors not closed")
+	}
+}
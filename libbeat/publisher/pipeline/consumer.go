@@ -37,12 +37,18 @@ type eventConsumer struct {
 	wait  atomic.Bool
 	sig   chan consumerSignal
 
-	queue    queue.Queue
+	queue    consumerSource
 	consumer queue.Consumer
 
 	out *outputGroup
 }
 
+// consumerSource creates the queue consumers used by the eventConsumer. It is
+// implemented by queue.Queue and by the routes of an outputRouter.
+type consumerSource interface {
+	Consumer() queue.Consumer
+}
+
 type consumerSignal struct {
 	tag      consumerEventTag
 	consumer queue.Consumer
@@ -59,7 +65,7 @@ const (
 
 func newEventConsumer(
 	log *logp.Logger,
-	queue queue.Queue,
+	queue consumerSource,
 	ctx *batchContext,
 ) *eventConsumer {
 	c := &eventConsumer{
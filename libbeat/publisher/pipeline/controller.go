@@ -18,9 +18,12 @@
 package pipeline
 
 import (
+	"fmt"
+
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/reload"
+	"github.com/elastic/beats/libbeat/conditions"
 	"github.com/elastic/beats/libbeat/outputs"
 	"github.com/elastic/beats/libbeat/publisher/queue"
 )
@@ -34,11 +37,32 @@ type outputController struct {
 	monitors Monitors
 	observer outputObserver
 
-	queue queue.Queue
+	queue consumerSource
 
 	retryer  *retryer
 	consumer *eventConsumer
 	out      *outputGroup
+
+	// router and routes are set if the pipeline publishes to multiple
+	// outputs. Each route is managed by its own outputController.
+	router *outputRouter
+	routes []*outputController
+
+	// name and outStats are set on the controllers of the routes. The
+	// metrics of a route are kept when its output is reloaded.
+	name     string
+	outStats outputs.Observer
+}
+
+// OutputRoute configures one of the outputs of a pipeline publishing to
+// multiple outputs. If Condition is set, the output only receives the
+// events matching the condition.
+type OutputRoute struct {
+	Name      string
+	Group     outputs.Group
+	Condition conditions.Condition
+
+	stats outputs.Observer
 }
 
 // outputGroup configures a group of load balanced outputs with shared work queue.
@@ -62,7 +86,7 @@ func newOutputController(
 	beat beat.Info,
 	monitors Monitors,
 	observer outputObserver,
-	b queue.Queue,
+	b consumerSource,
 ) *outputController {
 	c := &outputController{
 		beat:     beat,
@@ -82,7 +106,51 @@ func newOutputController(
 	return c
 }
 
+// newRoutedOutputController creates an outputController publishing the events
+// of the queue to multiple outputs. Events are distributed to the outputs by
+// an outputRouter, with an independent consumer, retryer and work queue per
+// output.
+func newRoutedOutputController(
+	beat beat.Info,
+	monitors Monitors,
+	observer outputObserver,
+	b queue.Queue,
+	routes []OutputRoute,
+) *outputController {
+	conds := make([]conditions.Condition, len(routes))
+	for i, route := range routes {
+		conds[i] = route.Condition
+	}
+
+	c := &outputController{
+		beat:     beat,
+		monitors: monitors,
+		observer: observer,
+		queue:    b,
+		router:   newOutputRouter(monitors.Logger, b, conds),
+	}
+
+	c.routes = make([]*outputController, len(routes))
+	for i, route := range routes {
+		ctrl := newOutputController(beat, monitors, observer, c.router.routes[i])
+		ctrl.name = route.Name
+		ctrl.outStats = route.stats
+		ctrl.Set(route.Group)
+		c.routes[i] = ctrl
+	}
+
+	return c
+}
+
 func (c *outputController) Close() error {
+	if c.router != nil {
+		c.router.close()
+		for _, route := range c.routes {
+			route.Close()
+		}
+		return nil
+	}
+
 	c.consumer.sigPause()
 	c.consumer.close()
 	c.retryer.close()
@@ -144,11 +212,16 @@ func makeWorkQueue() workQueue {
 	return workQueue(make(chan *Batch, 0))
 }
 
-// Reload the output
+// Reload the output. If the pipeline publishes to multiple outputs, the
+// configuration must contain the `outputs` setting with the new outputs.
 func (c *outputController) Reload(
 	cfg *reload.ConfigWithMeta,
 	outFactory func(outputs.Observer, common.ConfigNamespace) (outputs.Group, error),
 ) error {
+	if c.router != nil {
+		return c.reloadRoutes(cfg, outFactory)
+	}
+
 	outCfg := common.ConfigNamespace{}
 	if cfg != nil {
 		if err := cfg.Config.Unpack(&outCfg); err != nil {
@@ -169,3 +242,91 @@ func (c *outputController) Reload(
 
 	return nil
 }
+
+// reloadRoutes replaces the outputs and conditions of the routes of a
+// pipeline publishing to multiple outputs. The routes are updated in place,
+// such that batches in flight are retried with the new outputs. Routes are
+// matched by name, adding, removing, renaming or reordering outputs requires
+// a restart of the pipeline.
+func (c *outputController) reloadRoutes(
+	cfg *reload.ConfigWithMeta,
+	outFactory func(outputs.Observer, common.ConfigNamespace) (outputs.Group, error),
+) error {
+	config := struct {
+		Outputs []common.ConfigNamespace `config:"outputs"`
+	}{}
+	if cfg != nil {
+		if err := cfg.Config.Unpack(&config); err != nil {
+			return err
+		}
+	}
+
+	var (
+		names   []string
+		configs []common.ConfigNamespace
+	)
+	for i, name := range outputRouteNames(config.Outputs) {
+		if name != "" {
+			names = append(names, name)
+			configs = append(configs, config.Outputs[i])
+		}
+	}
+
+	current := make([]string, len(c.routes))
+	for i, route := range c.routes {
+		current[i] = route.name
+	}
+	if !equalStrings(current, names) {
+		return fmt.Errorf("changing the outputs from %v to %v requires a restart",
+			current, names)
+	}
+
+	conds := make([]conditions.Condition, len(configs))
+	for i, outCfg := range configs {
+		cond, err := loadOutputCondition(outCfg.Config())
+		if err != nil {
+			return fmt.Errorf("invalid condition for output %v: %v", names[i], err)
+		}
+		conds[i] = cond
+	}
+
+	groups := make([]outputs.Group, 0, len(configs))
+	for i, outCfg := range configs {
+		if publishDisabled {
+			groups = append(groups, outputs.Group{})
+			continue
+		}
+
+		group, err := outFactory(c.routes[i].outStats, outCfg)
+		if err != nil {
+			// close the outputs already created, the routes keep publishing
+			// to the current outputs
+			for _, created := range groups {
+				for _, client := range created.Clients {
+					client.Close()
+				}
+			}
+			return fmt.Errorf("failed to reload output %v: %v", names[i], err)
+		}
+		groups = append(groups, group)
+	}
+
+	for i, route := range c.routes {
+		c.router.routes[i].setCondition(conds[i])
+		route.Set(groups[i])
+	}
+
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -18,11 +18,13 @@
 package pipeline
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/conditions"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/monitoring"
 	"github.com/elastic/beats/libbeat/outputs"
@@ -98,9 +100,151 @@ func Load(
 	return p, err
 }
 
+// LoadRouted uses a Config object to create a new complete Pipeline instance
+// publishing to multiple outputs. Each output is created from its configuration
+// using makeOutput. If the output configuration contains a `when` condition,
+// the output only receives the events matching the condition.
+func LoadRouted(
+	beatInfo beat.Info,
+	monitors Monitors,
+	config Config,
+	processors processing.Supporter,
+	outputConfigs []common.ConfigNamespace,
+	makeOutput func(outputs.Observer, common.ConfigNamespace) (outputs.Group, error),
+) (*Pipeline, error) {
+	log := monitors.Logger
+	if log == nil {
+		log = logp.L()
+	}
+
+	if publishDisabled {
+		log.Info("Dry run mode. All output types except the file based one are disabled.")
+	}
+
+	settings := Settings{
+		WaitClose:     0,
+		WaitCloseMode: NoWaitOnClose,
+		Processors:    processors,
+	}
+
+	queueBuilder, err := createQueueBuilder(config.Queue, monitors)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := loadOutputRoutes(monitors, outputConfigs, makeOutput)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := NewRouted(beatInfo, monitors, queueBuilder, routes, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Beat name: %s", beatInfo.Name)
+	return p, err
+}
+
+func loadOutputRoutes(
+	monitors Monitors,
+	outputConfigs []common.ConfigNamespace,
+	makeOutput func(outputs.Observer, common.ConfigNamespace) (outputs.Group, error),
+) ([]OutputRoute, error) {
+	names := outputRouteNames(outputConfigs)
+
+	routes := make([]OutputRoute, 0, len(outputConfigs))
+	for i, cfg := range outputConfigs {
+		if !cfg.IsSet() {
+			continue
+		}
+
+		name := names[i]
+		cond, err := loadOutputCondition(cfg.Config())
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition for output %v: %v", name, err)
+		}
+
+		// The metrics are reported below the pipeline namespace, outputs
+		// like Kafka own registries in 'outputs'.
+		var outStats outputs.Observer
+		group, err := loadNamedOutput(monitors, "pipeline.outputs."+name, func(stats outputs.Observer) (string, outputs.Group, error) {
+			outStats = stats
+			out, err := makeOutput(stats, cfg)
+			return cfg.Name(), out, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, OutputRoute{
+			Name:      name,
+			Group:     group,
+			Condition: cond,
+			stats:     outStats,
+		})
+	}
+
+	if len(routes) == 0 {
+		return nil, errors.New("no enabled outputs configured")
+	}
+	return routes, nil
+}
+
+// outputRouteNames names the routes after the output type, adding the
+// position of the output if the same type is configured multiple times. The
+// name of disabled outputs is empty.
+func outputRouteNames(outputConfigs []common.ConfigNamespace) []string {
+	counts := map[string]int{}
+	for _, cfg := range outputConfigs {
+		counts[cfg.Name()]++
+	}
+
+	names := make([]string, len(outputConfigs))
+	for i, cfg := range outputConfigs {
+		if !cfg.IsSet() {
+			continue
+		}
+
+		name := cfg.Name()
+		if counts[name] > 1 {
+			name = fmt.Sprintf("%v-%d", name, i)
+		}
+		names[i] = name
+	}
+	return names
+}
+
+func loadOutputCondition(cfg *common.Config) (conditions.Condition, error) {
+	if !cfg.HasField("when") {
+		return nil, nil
+	}
+
+	sub, err := cfg.Child("when", -1)
+	if err != nil {
+		return nil, err
+	}
+
+	condConfig := conditions.Config{}
+	if err := sub.Unpack(&condConfig); err != nil {
+		return nil, err
+	}
+	return conditions.NewCondition(&condConfig)
+}
+
 func loadOutput(
 	monitors Monitors,
 	makeOutput OutputFactory,
+) (outputs.Group, error) {
+	return loadNamedOutput(monitors, "output", makeOutput)
+}
+
+// loadNamedOutput creates an output, reporting its metrics in the registry
+// with the given name.
+func loadNamedOutput(
+	monitors Monitors,
+	registryName string,
+	makeOutput OutputFactory,
 ) (outputs.Group, error) {
 	log := monitors.Logger
 	if log == nil {
@@ -120,11 +264,11 @@ func loadOutput(
 		outStats outputs.Observer
 	)
 	if monitors.Metrics != nil {
-		metrics = monitors.Metrics.GetRegistry("output")
+		metrics = monitors.Metrics.GetRegistry(registryName)
 		if metrics != nil {
 			metrics.Clear()
 		} else {
-			metrics = monitors.Metrics.NewRegistry("output")
+			metrics = monitors.Metrics.NewRegistry(registryName)
 		}
 		outStats = outputs.NewStats(metrics)
 	}
@@ -138,11 +282,11 @@ func loadOutput(
 		monitoring.NewString(metrics, "type").Set(outName)
 	}
 	if monitors.Telemetry != nil {
-		telemetry := monitors.Telemetry.GetRegistry("output")
+		telemetry := monitors.Telemetry.GetRegistry(registryName)
 		if telemetry != nil {
 			telemetry.Clear()
 		} else {
-			telemetry = monitors.Telemetry.NewRegistry("output")
+			telemetry = monitors.Telemetry.NewRegistry(registryName)
 		}
 		monitoring.NewString(telemetry, "name").Set(outName)
 	}
@@ -82,6 +82,10 @@ type Pipeline struct {
 	sigNewClient             chan *client
 
 	processors processing.Supporter
+
+	// asynchronous processors support
+	guardStartAsync sync.Once
+	asyncStarted    bool
 }
 
 // Settings is used to pass additional settings to a newly created pipeline instance.
@@ -147,6 +151,23 @@ func New(
 	queueFactory queueFactory,
 	out outputs.Group,
 	settings Settings,
+) (*Pipeline, error) {
+	p, err := newPipeline(beat, monitors, queueFactory, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	p.output = newOutputController(beat, p.monitors, p.observer, p.queue)
+	p.output.Set(out)
+
+	return p, nil
+}
+
+func newPipeline(
+	beat beat.Info,
+	monitors Monitors,
+	queueFactory queueFactory,
+	settings Settings,
 ) (*Pipeline, error) {
 	var err error
 
@@ -195,9 +216,25 @@ func New(
 	}
 	p.eventSema = newSema(maxEvents)
 
-	p.output = newOutputController(beat, monitors, p.observer, p.queue)
-	p.output.Set(out)
+	return p, nil
+}
+
+// NewRouted creates a new Pipeline instance publishing to multiple outputs.
+// Every output receives the events matching the condition of its route. An
+// event is only ACKed once all outputs it has been routed to have ACKed it.
+func NewRouted(
+	beat beat.Info,
+	monitors Monitors,
+	queueFactory queueFactory,
+	routes []OutputRoute,
+	settings Settings,
+) (*Pipeline, error) {
+	p, err := newPipeline(beat, monitors, queueFactory, settings)
+	if err != nil {
+		return nil, err
+	}
 
+	p.output = newRoutedOutputController(beat, p.monitors, p.observer, p.queue, routes)
 	return p, nil
 }
 
@@ -249,6 +286,8 @@ func (p *Pipeline) Close() error {
 
 	log.Debug("close pipeline")
 
+	p.stopAsyncProcessing()
+
 	if p.waitCloser != nil {
 		ch := make(chan struct{})
 		go func() {
@@ -295,6 +334,11 @@ func (p *Pipeline) Connect() (beat.Client, error) {
 // the appropriate fields in the passed ClientConfig.
 // If not set otherwise the defaut publish mode is OutputChooses.
 func (p *Pipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
+	p.guardStartAsync.Do(p.startAsyncProcessing)
+	return p.connectWith(cfg)
+}
+
+func (p *Pipeline) connectWith(cfg beat.ClientConfig) (beat.Client, error) {
 	var (
 		canDrop      bool
 		dropOnCancel bool
@@ -345,6 +389,8 @@ func (p *Pipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
 		eventFlags:   eventFlags,
 		canDrop:      canDrop,
 		reportEvents: reportEvents,
+
+		clientProcessors: cfg.Processing.Processor,
 	}
 
 	acker := p.makeACKer(processors != nil, &cfg, waitClose, client.unlink)
@@ -383,6 +429,46 @@ func (p *Pipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
 	return client, nil
 }
 
+// startAsyncProcessing starts the processors generating events
+// asynchronously. It is run when the first client connects, so that the
+// pipeline ACK handler is already configured.
+func (p *Pipeline) startAsyncProcessing() {
+	s, ok := p.processors.(processing.AsyncSupporter)
+	if !ok {
+		return
+	}
+
+	if err := s.Start(asyncConnector{p}); err != nil {
+		p.monitors.Logger.Errorf("Failed to start asynchronous processors: %v", err)
+		return
+	}
+	p.asyncStarted = true
+}
+
+// stopAsyncProcessing stops the asynchronous processors, publishing their
+// pending events before the queue and outputs are closed.
+func (p *Pipeline) stopAsyncProcessing() {
+	// Ensure processors are not started by a client connecting after Close.
+	p.guardStartAsync.Do(func() {})
+
+	if p.asyncStarted {
+		p.processors.(processing.AsyncSupporter).Stop()
+	}
+}
+
+// asyncConnector connects the clients used by asynchronous processors.
+type asyncConnector struct {
+	p *Pipeline
+}
+
+func (c asyncConnector) Connect() (beat.Client, error) {
+	return c.p.connectWith(beat.ClientConfig{})
+}
+
+func (c asyncConnector) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
+	return c.p.connectWith(cfg)
+}
+
 func (p *Pipeline) registerSignalPropagation(c *client) {
 	p.guardStartSigPropagation.Do(func() {
 		p.sigNewClient = make(chan *client, 1)
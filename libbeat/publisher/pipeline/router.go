@@ -0,0 +1,291 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"io"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common/atomic"
+	"github.com/elastic/beats/libbeat/conditions"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/publisher"
+	"github.com/elastic/beats/libbeat/publisher/queue"
+)
+
+// outputRouter distributes the batches read by a single queue consumer to
+// multiple output routes. Each route only receives the events matching its
+// condition.
+//
+// Every route buffers the batches routed to it, so the outputs publish
+// independently of each other and a slow or unavailable output does not stop
+// the others. A batch read from the queue is ACKed once all routes have ACKed
+// the events they received, such that the producers only get an ACK after
+// every matching output has accepted the event. As the queue releases events
+// in order, the events not yet ACKed by a stalled output keep occupying the
+// queue. The other outputs make progress until the queue is full. The memory
+// used by the route buffers is bounded by the queue size, as the buffered
+// events are part of the events held by the queue.
+type outputRouter struct {
+	logger   *logp.Logger
+	consumer queue.Consumer
+	routes   []*routeQueue
+
+	done chan struct{}
+}
+
+// routeQueue provides the queue consumers for the event consumer of a single
+// route. Events are passed to the routeQueue by the outputRouter.
+type routeQueue struct {
+	condMutex sync.RWMutex
+	condition conditions.Condition
+
+	// pending holds the routed batches that have not been returned by a
+	// consumer yet, oldest first. signal is notified when a batch is added.
+	mutex   sync.Mutex
+	pending []*routeBatch
+	signal  chan struct{}
+	done    <-chan struct{}
+}
+
+// routeConsumer implements queue.Consumer for a routeQueue.
+type routeConsumer struct {
+	queue  *routeQueue
+	closed atomic.Bool
+	done   chan struct{}
+}
+
+// routeBatch holds events of a queue batch selected for one route.
+type routeBatch struct {
+	events []publisher.Event
+	parent *sharedBatch
+}
+
+// sharedBatch keeps track of the events of a queue batch still being
+// processed by the outputs.
+type sharedBatch struct {
+	original queue.Batch
+	active   atomic.Int
+}
+
+func newOutputRouter(
+	log *logp.Logger,
+	q queue.Queue,
+	conds []conditions.Condition,
+) *outputRouter {
+	r := &outputRouter{
+		logger:   log,
+		consumer: q.Consumer(),
+		done:     make(chan struct{}),
+	}
+
+	r.routes = make([]*routeQueue, len(conds))
+	for i, cond := range conds {
+		r.routes[i] = &routeQueue{
+			condition: cond,
+			signal:    make(chan struct{}, 1),
+			done:      r.done,
+		}
+	}
+
+	go r.run()
+	return r
+}
+
+func (r *outputRouter) close() {
+	r.consumer.Close()
+	close(r.done)
+}
+
+func (r *outputRouter) run() {
+	r.logger.Debug("start pipeline output router")
+	defer r.logger.Debug("stop pipeline output router")
+
+	for {
+		batch, err := r.consumer.Get(-1)
+		if err != nil {
+			return
+		}
+
+		r.dispatch(batch)
+	}
+}
+
+// dispatch passes the events of a queue batch to all routes. It does not wait
+// for the routes to consume the events.
+func (r *outputRouter) dispatch(batch queue.Batch) {
+	events := batch.Events()
+	shared := &sharedBatch{original: batch}
+
+	routed := make([]*routeBatch, len(r.routes))
+	used := make([]bool, len(events))
+	total := 0
+	for i, route := range r.routes {
+		selected := route.selectEvents(events, used)
+		if len(selected) == 0 {
+			continue
+		}
+
+		routed[i] = &routeBatch{events: selected, parent: shared}
+		total += len(selected)
+	}
+
+	if total == 0 {
+		// no output is interested in the events
+		batch.ACK()
+		return
+	}
+
+	shared.active.Store(total)
+	for i, route := range r.routes {
+		if routed[i] != nil {
+			route.push(routed[i])
+		}
+	}
+}
+
+// push adds a batch to the route, waking up a consumer waiting for events.
+func (q *routeQueue) push(b *routeBatch) {
+	q.mutex.Lock()
+	q.pending = append(q.pending, b)
+	q.mutex.Unlock()
+	q.notify()
+}
+
+// pop returns up to sz events of the oldest pending batch. It returns nil if
+// no batch is pending.
+func (q *routeQueue) pop(sz int) *routeBatch {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.pending) == 0 {
+		return nil
+	}
+
+	next := q.pending[0]
+	events := next.events
+	if sz > 0 && sz < len(events) {
+		next.events = events[sz:]
+		next = &routeBatch{events: events[:sz], parent: next.parent}
+	} else {
+		q.pending[0] = nil
+		q.pending = q.pending[1:]
+	}
+
+	if len(q.pending) > 0 {
+		// wake up another consumer waiting for the remaining events
+		q.notify()
+	}
+	return next
+}
+
+func (q *routeQueue) notify() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// setCondition replaces the condition of the route. The condition is applied
+// to the batches dispatched after the update.
+func (q *routeQueue) setCondition(cond conditions.Condition) {
+	q.condMutex.Lock()
+	defer q.condMutex.Unlock()
+	q.condition = cond
+}
+
+// selectEvents returns the events matching the condition of the route. Outputs
+// can modify the events of a batch in place, every route gets its own copy of
+// the events. used marks the events already passed to another route, these
+// are passed with a copy of their fields and metadata.
+func (q *routeQueue) selectEvents(events []publisher.Event, used []bool) []publisher.Event {
+	q.condMutex.RLock()
+	cond := q.condition
+	q.condMutex.RUnlock()
+
+	var selected []publisher.Event
+	for i := range events {
+		if cond != nil && !cond.Check(&events[i].Content) {
+			continue
+		}
+
+		event := events[i]
+		if used[i] {
+			event.Content = cloneContent(event.Content)
+		}
+		used[i] = true
+		selected = append(selected, event)
+	}
+	return selected
+}
+
+func cloneContent(e beat.Event) beat.Event {
+	if e.Fields != nil {
+		e.Fields = e.Fields.Clone()
+	}
+	if e.Meta != nil {
+		e.Meta = e.Meta.Clone()
+	}
+	return e
+}
+
+func (q *routeQueue) Consumer() queue.Consumer {
+	return &routeConsumer{
+		queue: q,
+		done:  make(chan struct{}),
+	}
+}
+
+func (c *routeConsumer) Get(sz int) (queue.Batch, error) {
+	q := c.queue
+	for {
+		if c.closed.Load() {
+			return nil, io.EOF
+		}
+
+		if batch := q.pop(sz); batch != nil {
+			return batch, nil
+		}
+
+		select {
+		case <-c.done:
+			return nil, io.EOF
+		case <-q.done:
+			return nil, io.EOF
+		case <-q.signal:
+		}
+	}
+}
+
+func (c *routeConsumer) Close() error {
+	if !c.closed.Swap(true) {
+		close(c.done)
+	}
+	return nil
+}
+
+func (b *routeBatch) Events() []publisher.Event {
+	return b.events
+}
+
+func (b *routeBatch) ACK() {
+	if b.parent.active.Sub(len(b.events)) == 0 {
+		b.parent.original.ACK()
+	}
+}
@@ -0,0 +1,463 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/atomic"
+	"github.com/elastic/beats/libbeat/common/reload"
+	"github.com/elastic/beats/libbeat/conditions"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/publisher"
+	"github.com/elastic/beats/libbeat/publisher/queue"
+	"github.com/elastic/beats/libbeat/publisher/queue/memqueue"
+)
+
+type testBatch struct {
+	events []publisher.Event
+	acked  atomic.Int
+}
+
+func (b *testBatch) Events() []publisher.Event { return b.events }
+func (b *testBatch) ACK()                      { b.acked.Inc() }
+
+func makeRouterQueue(batches chan queue.Batch) queue.Queue {
+	done := make(chan struct{})
+	return &testQueue{
+		consumer: func() queue.Consumer {
+			return &testConsumer{
+				get: func(sz int) (queue.Batch, error) {
+					select {
+					case <-done:
+						return nil, io.EOF
+					case b := <-batches:
+						return b, nil
+					}
+				},
+				close: func() error {
+					close(done)
+					return nil
+				},
+			}
+		},
+	}
+}
+
+func makeRouterEvents(values ...string) []publisher.Event {
+	events := make([]publisher.Event, len(values))
+	for i, v := range values {
+		events[i] = publisher.Event{
+			Content: beat.Event{Fields: common.MapStr{"type": v}},
+		}
+	}
+	return events
+}
+
+func makeCondition(t *testing.T, value string) conditions.Condition {
+	cfg := conditions.Config{}
+	require.NoError(t, common.MustNewConfigFrom(map[string]interface{}{
+		"equals.type": value,
+	}).Unpack(&cfg))
+	cond, err := conditions.NewCondition(&cfg)
+	require.NoError(t, err)
+	return cond
+}
+
+func TestOutputRouter(t *testing.T) {
+	batches := make(chan queue.Batch)
+	router := newOutputRouter(logp.NewLogger("test"), makeRouterQueue(batches),
+		[]conditions.Condition{nil, makeCondition(t, "b")})
+	defer router.close()
+
+	all := router.routes[0].Consumer()
+	onlyB := router.routes[1].Consumer()
+
+	original := &testBatch{events: makeRouterEvents("a", "b", "c")}
+	batches <- original
+
+	b1, err := all.Get(2)
+	require.NoError(t, err)
+	assert.Len(t, b1.Events(), 2)
+
+	b2, err := all.Get(2)
+	require.NoError(t, err)
+	assert.Len(t, b2.Events(), 1)
+
+	b3, err := onlyB.Get(10)
+	require.NoError(t, err)
+	require.Len(t, b3.Events(), 1)
+	assert.Equal(t, "b", b3.Events()[0].Content.Fields["type"])
+
+	b1.ACK()
+	b3.ACK()
+	assert.Equal(t, 0, original.acked.Load())
+
+	b2.ACK()
+	assert.Equal(t, 1, original.acked.Load())
+}
+
+func TestOutputRouterNoMatch(t *testing.T) {
+	batches := make(chan queue.Batch)
+	router := newOutputRouter(logp.NewLogger("test"), makeRouterQueue(batches),
+		[]conditions.Condition{makeCondition(t, "b")})
+	defer router.close()
+
+	unmatched := &testBatch{events: makeRouterEvents("a", "c")}
+	batches <- unmatched
+
+	matched := &testBatch{events: makeRouterEvents("b")}
+	batches <- matched
+
+	b, err := router.routes[0].Consumer().Get(-1)
+	require.NoError(t, err)
+	assert.Len(t, b.Events(), 1)
+	assert.Equal(t, 1, unmatched.acked.Load())
+
+	b.ACK()
+	assert.Equal(t, 1, matched.acked.Load())
+}
+
+func TestOutputRouterCopiesEvents(t *testing.T) {
+	batches := make(chan queue.Batch)
+	router := newOutputRouter(logp.NewLogger("test"), makeRouterQueue(batches),
+		[]conditions.Condition{nil, nil})
+	defer router.close()
+
+	batches <- &testBatch{events: makeRouterEvents("drop", "x", "y")}
+
+	// The first output compacts its batch in place, like the Elasticsearch
+	// output does when dropping events.
+	b1, err := router.routes[0].Consumer().Get(-1)
+	require.NoError(t, err)
+	events := b1.Events()
+	kept := events[:0]
+	for _, e := range events {
+		if e.Content.Fields["type"] != "drop" {
+			kept = append(kept, e)
+		}
+	}
+	require.Len(t, kept, 2)
+
+	b2, err := router.routes[1].Consumer().Get(-1)
+	require.NoError(t, err)
+	var types []string
+	for _, e := range b2.Events() {
+		types = append(types, e.Content.Fields["type"].(string))
+	}
+	assert.Equal(t, []string{"drop", "x", "y"}, types)
+}
+
+func TestOutputRouterCopiesFields(t *testing.T) {
+	batches := make(chan queue.Batch)
+	router := newOutputRouter(logp.NewLogger("test"), makeRouterQueue(batches),
+		[]conditions.Condition{nil, makeCondition(t, "a")})
+	defer router.close()
+
+	events := makeRouterEvents("a")
+	events[0].Content.Fields["log"] = common.MapStr{"offset": 1}
+	events[0].Content.Meta = common.MapStr{"pipeline": "p"}
+	batches <- &testBatch{events: events}
+
+	b1, err := router.routes[0].Consumer().Get(-1)
+	require.NoError(t, err)
+	b2, err := router.routes[1].Consumer().Get(-1)
+	require.NoError(t, err)
+
+	// The outputs publish concurrently, one of them modifying the event
+	// in place.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e := &b1.Events()[0].Content
+		e.PutValue("log.offset", 2)
+		delete(e.Meta, "pipeline")
+	}()
+
+	e := b2.Events()[0].Content
+	offset, err := e.GetValue("log.offset")
+	require.NoError(t, err)
+	assert.Equal(t, 1, offset)
+	assert.Equal(t, "p", e.Meta["pipeline"])
+	<-done
+}
+
+func TestOutputRouterStalledRoute(t *testing.T) {
+	batches := make(chan queue.Batch)
+	router := newOutputRouter(logp.NewLogger("test"), makeRouterQueue(batches),
+		[]conditions.Condition{nil, nil})
+	defer router.close()
+
+	// The first route is never consumed, the second one must still receive
+	// all batches.
+	originals := make([]*testBatch, 3)
+	for i := range originals {
+		originals[i] = &testBatch{events: makeRouterEvents("a")}
+		select {
+		case batches <- originals[i]:
+		case <-time.After(5 * time.Second):
+			t.Fatal("router blocked by stalled route")
+		}
+	}
+
+	consumer := router.routes[1].Consumer()
+	for range originals {
+		b, err := consumer.Get(-1)
+		require.NoError(t, err)
+		b.ACK()
+	}
+	for _, original := range originals {
+		assert.Equal(t, 0, original.acked.Load())
+	}
+
+	consumer = router.routes[0].Consumer()
+	for range originals {
+		b, err := consumer.Get(-1)
+		require.NoError(t, err)
+		b.ACK()
+	}
+	for _, original := range originals {
+		assert.Equal(t, 1, original.acked.Load())
+	}
+}
+
+func TestRouteConsumerClose(t *testing.T) {
+	batches := make(chan queue.Batch)
+	router := newOutputRouter(logp.NewLogger("test"), makeRouterQueue(batches),
+		[]conditions.Condition{nil})
+	defer router.close()
+
+	consumer := router.routes[0].Consumer()
+	done := make(chan error)
+	go func() {
+		_, err := consumer.Get(-1)
+		done <- err
+	}()
+
+	consumer.Close()
+	assert.Equal(t, io.EOF, <-done)
+}
+
+type routeTestClient struct {
+	published chan []string
+	closed    atomic.Bool
+}
+
+func (c *routeTestClient) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func (c *routeTestClient) String() string { return "test" }
+
+func (c *routeTestClient) Publish(batch publisher.Batch) error {
+	var types []string
+	for _, e := range batch.Events() {
+		types = append(types, e.Content.Fields["type"].(string))
+	}
+	c.published <- types
+	batch.ACK()
+	return nil
+}
+
+func (c *routeTestClient) received(t *testing.T, n int) []string {
+	var types []string
+	for len(types) < n {
+		select {
+		case published := <-c.published:
+			types = append(types, published...)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for events")
+		}
+	}
+	return types
+}
+
+func makeRouterTestQueue(e queue.Eventer) (queue.Queue, error) {
+	return memqueue.NewBroker(nil, memqueue.Settings{
+		Eventer:        e,
+		Events:         64,
+		FlushMinEvents: 1,
+	}), nil
+}
+
+func TestRoutedPipelineACK(t *testing.T) {
+	all := &routeTestClient{published: make(chan []string, 10)}
+	onlyB := &routeTestClient{published: make(chan []string, 10)}
+
+	routes := []OutputRoute{
+		{Name: "all", Group: outputs.Group{Clients: []outputs.Client{all}, BatchSize: 10}},
+		{Name: "b", Group: outputs.Group{Clients: []outputs.Client{onlyB}, BatchSize: 10}, Condition: makeCondition(t, "b")},
+	}
+
+	p, err := NewRouted(beat.Info{}, Monitors{}, makeRouterTestQueue, routes, Settings{})
+	require.NoError(t, err)
+	defer p.Close()
+
+	acked := make(chan int, 10)
+	client, err := p.ConnectWith(beat.ClientConfig{
+		ACKCount: func(n int) { acked <- n },
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.Publish(beat.Event{Fields: common.MapStr{"type": "a"}})
+	client.Publish(beat.Event{Fields: common.MapStr{"type": "b"}})
+
+	assert.Equal(t, []string{"a", "b"}, all.received(t, 2))
+	assert.Equal(t, []string{"b"}, onlyB.received(t, 1))
+
+	total := 0
+	for total < 2 {
+		select {
+		case n := <-acked:
+			total += n
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for ACK")
+		}
+	}
+	assert.Equal(t, 2, total)
+}
+
+func TestRoutedPipelineReload(t *testing.T) {
+	all := &routeTestClient{published: make(chan []string, 10)}
+	onlyB := &routeTestClient{published: make(chan []string, 10)}
+
+	stats := outputs.NewStats(monitoring.NewRegistry())
+	routes := []OutputRoute{
+		{Name: "first", Group: outputs.Group{Clients: []outputs.Client{all}, BatchSize: 10}, stats: stats},
+		{Name: "second", Group: outputs.Group{Clients: []outputs.Client{onlyB}, BatchSize: 10}, Condition: makeCondition(t, "b")},
+	}
+
+	p, err := NewRouted(beat.Info{}, Monitors{}, makeRouterTestQueue, routes, Settings{})
+	require.NoError(t, err)
+	defer p.Close()
+
+	client, err := p.Connect()
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.Publish(beat.Event{Fields: common.MapStr{"type": "b"}})
+	assert.Equal(t, []string{"b"}, all.received(t, 1))
+	assert.Equal(t, []string{"b"}, onlyB.received(t, 1))
+
+	replacements := map[string]*routeTestClient{
+		"first":  {published: make(chan []string, 10)},
+		"second": {published: make(chan []string, 10)},
+	}
+	factory := func(observer outputs.Observer, cfg common.ConfigNamespace) (outputs.Group, error) {
+		if cfg.Name() == "first" {
+			assert.Equal(t, stats, observer)
+		}
+		return outputs.Group{Clients: []outputs.Client{replacements[cfg.Name()]}, BatchSize: 10}, nil
+	}
+
+	reloadConfig := func(outputs ...map[string]interface{}) *reload.ConfigWithMeta {
+		return &reload.ConfigWithMeta{Config: common.MustNewConfigFrom(map[string]interface{}{
+			"outputs": outputs,
+		})}
+	}
+
+	err = p.OutputReloader().Reload(reloadConfig(
+		map[string]interface{}{"first": map[string]interface{}{}},
+	), factory)
+	assert.Error(t, err, "the number of outputs can not change")
+
+	err = p.OutputReloader().Reload(reloadConfig(
+		map[string]interface{}{"second": map[string]interface{}{}},
+		map[string]interface{}{"first": map[string]interface{}{}},
+	), factory)
+	assert.Error(t, err, "the outputs can not be reordered")
+
+	err = p.OutputReloader().Reload(reloadConfig(
+		map[string]interface{}{"first": map[string]interface{}{}},
+		map[string]interface{}{"third": map[string]interface{}{}},
+	), factory)
+	assert.Error(t, err, "the outputs can not be renamed")
+
+	failing := func(observer outputs.Observer, cfg common.ConfigNamespace) (outputs.Group, error) {
+		if cfg.Name() == "second" {
+			return outputs.Fail(errors.New("oops"))
+		}
+		return factory(observer, cfg)
+	}
+	err = p.OutputReloader().Reload(reloadConfig(
+		map[string]interface{}{"first": map[string]interface{}{}},
+		map[string]interface{}{"second": map[string]interface{}{}},
+	), failing)
+	assert.Error(t, err)
+	assert.True(t, replacements["first"].closed.Load(), "created outputs must be closed")
+	assert.False(t, all.closed.Load())
+
+	client.Publish(beat.Event{Fields: common.MapStr{"type": "b"}})
+	assert.Equal(t, []string{"b"}, all.received(t, 1))
+	assert.Equal(t, []string{"b"}, onlyB.received(t, 1))
+
+	replacements["first"] = &routeTestClient{published: make(chan []string, 10)}
+	err = p.OutputReloader().Reload(reloadConfig(
+		map[string]interface{}{"first": map[string]interface{}{}},
+		map[string]interface{}{"second": map[string]interface{}{"when.equals.type": "a"}},
+	), factory)
+	require.NoError(t, err)
+
+	client.Publish(beat.Event{Fields: common.MapStr{"type": "a"}})
+	client.Publish(beat.Event{Fields: common.MapStr{"type": "b"}})
+	assert.Equal(t, []string{"a", "b"}, replacements["first"].received(t, 2))
+	assert.Equal(t, []string{"a"}, replacements["second"].received(t, 1))
+	assert.Len(t, all.published, 0)
+	assert.Len(t, onlyB.published, 0)
+}
+
+func TestLoadOutputRoutesRegistry(t *testing.T) {
+	metrics := monitoring.NewRegistry()
+
+	// registry owned by the Kafka output
+	kafkaMetrics := monitoring.NewUint(metrics, "outputs.kafka.bytes_read")
+	kafkaMetrics.Set(42)
+
+	config := struct {
+		Outputs []common.ConfigNamespace `config:"outputs"`
+	}{}
+	require.NoError(t, common.MustNewConfigFrom(map[string]interface{}{
+		"outputs": []map[string]interface{}{
+			{"kafka": map[string]interface{}{}},
+		},
+	}).Unpack(&config))
+
+	factory := func(_ outputs.Observer, cfg common.ConfigNamespace) (outputs.Group, error) {
+		return outputs.Group{BatchSize: 10}, nil
+	}
+	routes, err := loadOutputRoutes(Monitors{Metrics: metrics}, config.Outputs, factory)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "kafka", routes[0].Name)
+
+	snapshot := monitoring.CollectFlatSnapshot(metrics, monitoring.Full, false)
+	assert.Equal(t, int64(42), snapshot.Ints["outputs.kafka.bytes_read"])
+	assert.Equal(t, "kafka", snapshot.Strings["pipeline.outputs.kafka.type"])
+}
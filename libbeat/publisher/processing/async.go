@@ -0,0 +1,124 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package processing
+
+import (
+	"sync"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// asyncEmitterMarker is set as private data of the client used to publish
+// events emitted by global processors, so they are not processed again by
+// the global processors.
+type asyncEmitterMarker struct{}
+
+// asyncEmitter publishes the events emitted by asynchronous global
+// processors. The pipeline client is only connected once the first event is
+// emitted.
+type asyncEmitter struct {
+	log      *logp.Logger
+	pipeline beat.PipelineConnector
+
+	mutex  sync.Mutex
+	client beat.Client
+	err    error
+	closed bool
+}
+
+// Start starts the asynchronous global processors. Processors configured by
+// a later Reload are started with the same emitter.
+func (b *builder) Start(pipeline beat.PipelineConnector) error {
+	b.reloadMutex.Lock()
+	defer b.reloadMutex.Unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.emitter = &asyncEmitter{log: b.log, pipeline: pipeline}
+	if b.global != nil {
+		b.global.Start(b.emitter.emit)
+	}
+	return nil
+}
+
+// Stop stops the asynchronous global processors, publishing their pending
+// events, and closes the client used to publish them. The global processors
+// are closed afterwards.
+func (b *builder) Stop() {
+	b.reloadMutex.Lock()
+	defer b.reloadMutex.Unlock()
+
+	b.mutex.Lock()
+	global, emitter := b.global, b.emitter
+	b.emitter = nil
+	b.mutex.Unlock()
+
+	if emitter == nil {
+		return
+	}
+
+	if global != nil {
+		global.Stop()
+	}
+	emitter.close()
+
+	if global != nil {
+		if err := global.Close(); err != nil {
+			b.log.Errorf("Failed to close global processors: %v", err)
+		}
+	}
+}
+
+func (e *asyncEmitter) emit(event *beat.Event) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		e.log.Debug("Dropping event emitted by processors after shutdown")
+		return
+	}
+
+	if e.client == nil && e.err == nil {
+		e.client, e.err = e.pipeline.ConnectWith(beat.ClientConfig{
+			Processing: beat.ProcessingConfig{
+				Private: asyncEmitterMarker{},
+			},
+		})
+		if e.err != nil {
+			e.log.Errorf("Failed to connect client for events emitted by processors: %v", e.err)
+		}
+	}
+	if e.client == nil {
+		return
+	}
+
+	e.client.Publish(*event)
+}
+
+func (e *asyncEmitter) close() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.closed = true
+	if e.client != nil {
+		e.client.Close()
+		e.client = nil
+	}
+}
@@ -0,0 +1,238 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package processing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/processors"
+	"github.com/elastic/beats/libbeat/processors/actions"
+)
+
+// emittingProcessor emits a summary event when it is stopped.
+type emittingProcessor struct {
+	emit  processors.Emitter
+	count int
+}
+
+func (p *emittingProcessor) Run(event *beat.Event) (*beat.Event, error) {
+	p.count++
+	return event, nil
+}
+
+func (p *emittingProcessor) Start(emit processors.Emitter) { p.emit = emit }
+
+func (p *emittingProcessor) Stop() {
+	p.emit(&beat.Event{Fields: common.MapStr{"count": p.count}})
+}
+
+func (p *emittingProcessor) String() string { return "emitting" }
+
+// closingProcessor records whether it was closed.
+type closingProcessor struct {
+	closed bool
+}
+
+func (p *closingProcessor) Run(event *beat.Event) (*beat.Event, error) { return event, nil }
+
+func (p *closingProcessor) Close() error {
+	p.closed = true
+	return nil
+}
+
+func (p *closingProcessor) String() string { return "closing" }
+
+// testConnector builds the processing of clients with the builder and stores
+// the published events.
+type testConnector struct {
+	builder   *builder
+	connected int
+	events    []common.MapStr
+	closed    bool
+}
+
+type testClient struct {
+	connector *testConnector
+	prog      beat.Processor
+}
+
+func (c *testConnector) Connect() (beat.Client, error) {
+	return c.ConnectWith(beat.ClientConfig{})
+}
+
+func (c *testConnector) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
+	prog, err := c.builder.Create(cfg.Processing, false)
+	if err != nil {
+		return nil, err
+	}
+	c.connected++
+	return &testClient{connector: c, prog: prog}, nil
+}
+
+func (c *testClient) Publish(event beat.Event) {
+	if e, _ := c.prog.Run(&event); e != nil {
+		c.connector.events = append(c.connector.events, e.Fields)
+	}
+}
+
+func (c *testClient) PublishAll(events []beat.Event) {
+	for _, e := range events {
+		c.Publish(e)
+	}
+}
+
+func (c *testClient) Close() error {
+	c.connector.closed = true
+	return nil
+}
+
+func TestAsyncGlobalProcessors(t *testing.T) {
+	emitting := &emittingProcessor{}
+	global := processors.NewList(nil)
+	global.List = append(global.List,
+		emitting,
+		actions.NewAddTags("tags", []string{"after"}),
+	)
+
+	b, err := newBuilder(beat.Info{}, logp.L(), global, common.EventMetadata{}, nil, false, false)
+	require.NoError(t, err)
+
+	connector := &testConnector{builder: b}
+	require.NoError(t, b.Start(connector))
+
+	prog, err := b.Create(beat.ProcessingConfig{}, false)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := prog.Run(&beat.Event{Fields: common.MapStr{"message": "hello"}})
+		require.NoError(t, err)
+	}
+
+	// The client is only connected once an event is emitted.
+	assert.Equal(t, 0, connector.connected)
+
+	b.Stop()
+	assert.Equal(t, 1, connector.connected)
+	assert.True(t, connector.closed)
+
+	// Emitted events are not processed again by the global processors.
+	assert.Equal(t, 3, emitting.count)
+	assert.Equal(t, []common.MapStr{
+		{"count": 3, "tags": []string{"after"}},
+	}, connector.events)
+}
+
+func TestReloadGlobalProcessors(t *testing.T) {
+	b, err := newBuilder(beat.Info{}, logp.L(), nil, common.EventMetadata{}, nil, false, false)
+	require.NoError(t, err)
+
+	shared := common.MapStr{"a": "b"}
+	prog, err := b.Create(beat.ProcessingConfig{Fields: shared}, false)
+	require.NoError(t, err)
+
+	event, err := prog.Run(&beat.Event{Fields: common.MapStr{"message": "before"}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{"message": "before", "a": "b"}, event.Fields)
+
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"processors": []map[string]interface{}{
+			{"add_tags": map[string]interface{}{"tags": []string{"reloaded"}}},
+			{"drop_fields": map[string]interface{}{"fields": []string{"a"}}},
+		},
+	})
+	require.NoError(t, b.Reload(cfg))
+
+	// Connected clients use the new processors, without modifying the fields
+	// shared with the client configuration.
+	event, err = prog.Run(&beat.Event{Fields: common.MapStr{"message": "after"}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{"message": "after", "tags": []string{"reloaded"}}, event.Fields)
+	assert.Equal(t, common.MapStr{"a": "b"}, shared)
+
+	require.NoError(t, b.Reload(common.NewConfig()))
+	event, err = prog.Run(&beat.Event{Fields: common.MapStr{"message": "removed"}})
+	require.NoError(t, err)
+	assert.Equal(t, common.MapStr{"message": "removed", "a": "b"}, event.Fields)
+
+	assert.Error(t, b.Reload(common.MustNewConfigFrom(map[string]interface{}{
+		"processors": []map[string]interface{}{{"unknown": nil}},
+	})))
+}
+
+func TestReloadAsyncGlobalProcessors(t *testing.T) {
+	old := &emittingProcessor{}
+	global := processors.NewList(nil)
+	global.List = append(global.List, old)
+
+	b, err := newBuilder(beat.Info{}, logp.L(), global, common.EventMetadata{}, nil, false, false)
+	require.NoError(t, err)
+
+	connector := &testConnector{builder: b}
+	require.NoError(t, b.Start(connector))
+
+	prog, err := b.Create(beat.ProcessingConfig{}, false)
+	require.NoError(t, err)
+	_, err = prog.Run(&beat.Event{Fields: common.MapStr{"message": "hello"}})
+	require.NoError(t, err)
+
+	// Replacing the processors stops the old ones, publishing their pending
+	// events, and starts the new ones.
+	replacement := &emittingProcessor{}
+	procs := processors.NewList(nil)
+	procs.List = append(procs.List, replacement)
+	b.setProcessors(procs)
+	assert.Equal(t, []common.MapStr{{"count": 1}}, connector.events)
+	assert.False(t, connector.closed)
+
+	for i := 0; i < 2; i++ {
+		_, err = prog.Run(&beat.Event{Fields: common.MapStr{"message": "hello"}})
+		require.NoError(t, err)
+	}
+
+	b.Stop()
+	assert.True(t, connector.closed)
+	assert.Equal(t, 1, old.count)
+	assert.Equal(t, []common.MapStr{{"count": 1}, {"count": 2}}, connector.events)
+}
+
+func TestReloadClosesGlobalProcessors(t *testing.T) {
+	old := &closingProcessor{}
+	global := processors.NewList(nil)
+	global.List = append(global.List, old)
+
+	b, err := newBuilder(beat.Info{}, logp.L(), global, common.EventMetadata{}, nil, false, false)
+	require.NoError(t, err)
+
+	// Replaced processors are closed, releasing their resources.
+	replacement := &closingProcessor{}
+	procs := processors.NewList(nil)
+	procs.List = append(procs.List, replacement)
+	b.setProcessors(procs)
+	assert.True(t, old.closed)
+	assert.False(t, replacement.closed)
+
+	// The processors in use are closed when the builder is stopped.
+	require.NoError(t, b.Start(&testConnector{builder: b}))
+	b.Stop()
+	assert.True(t, replacement.closed)
+}
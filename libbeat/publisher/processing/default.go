@@ -19,6 +19,7 @@ package processing
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/elastic/ecs/code/go/ecs"
 
@@ -54,8 +55,15 @@ type builder struct {
 	timeSeries       bool
 	timeseriesFields mapping.Fields
 
-	// global pipeline processors
+	// global pipeline processors. The processors can be replaced by Reload
+	// while clients are connected, and are protected by mutex.
+	mutex      sync.RWMutex
 	processors *group
+	global     *processors.Processors
+	emitter    *asyncEmitter
+
+	// reloadMutex serializes calls to Reload, Start and Stop
+	reloadMutex sync.Mutex
 
 	drop       bool // disabled is set if outputs have been disabled via CLI
 	alwaysCopy bool
@@ -174,14 +182,7 @@ func newBuilder(
 		timeSeries:    timeSeries,
 	}
 
-	hasProcessors := processors != nil && len(processors.List) > 0
-	if hasProcessors {
-		tmp := newGroup("global", log)
-		for _, p := range processors.List {
-			tmp.add(p)
-		}
-		b.processors = tmp
-	}
+	b.processors, b.global = makeGlobalGroup(log, processors)
 
 	builtin := common.MapStr{}
 	for _, mod := range modifiers {
@@ -245,7 +246,7 @@ func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor,
 		localProcessors = makeClientProcessors(b.log, cfg)
 	)
 
-	needsCopy := b.alwaysCopy || localProcessors != nil || b.processors != nil
+	needsCopy := b.alwaysCopy || localProcessors != nil || b.hasGlobalProcessors()
 
 	builtin := b.builtinMeta
 	var clientFields common.MapStr
@@ -313,8 +314,10 @@ func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor,
 		processors.add(actions.NewAddFields(meta, needsCopy))
 	}
 
-	// setup 8: pipeline processors list
-	processors.add(b.processors)
+	// setup 8: pipeline processors list, skipped for events emitted by them
+	if _, emitted := cfg.Private.(asyncEmitterMarker); !emitted {
+		processors.add(&globalProcessors{builder: b, copied: needsCopy})
+	}
 
 	// setup 9: time series metadata
 	if b.timeSeries {
@@ -334,6 +337,90 @@ func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor,
 	return processors, nil
 }
 
+// Reload replaces the global processors with the processors configured in the
+// `processors` setting of cfg. Clients already connected to the pipeline
+// apply the new processors to the events published after the reload.
+// If the pipeline has been started, the new asynchronous processors are
+// started and the old ones are stopped, publishing their pending events.
+func (b *builder) Reload(cfg *common.Config) error {
+	config := struct {
+		Processors processors.PluginConfig `config:"processors"`
+	}{}
+	if cfg != nil {
+		if err := cfg.Unpack(&config); err != nil {
+			return err
+		}
+	}
+
+	procs, err := processors.New(config.Processors)
+	if err != nil {
+		return fmt.Errorf("error initializing processors: %v", err)
+	}
+
+	b.setProcessors(procs)
+	b.log.Infof("Global processors reloaded: %v", procs)
+	return nil
+}
+
+func (b *builder) setProcessors(procs *processors.Processors) {
+	b.reloadMutex.Lock()
+	defer b.reloadMutex.Unlock()
+
+	grp, global := makeGlobalGroup(b.log, procs)
+
+	b.mutex.RLock()
+	old, emitter := b.global, b.emitter
+	b.mutex.RUnlock()
+
+	// The old processors are stopped and closed before the new ones are
+	// started, as they can use the same resources, like the store of the
+	// deduplicate processor. They are stopped without holding the lock, as the
+	// events emitted while stopping are published through the pipeline,
+	// requiring the builder to create the processing of the emitter client.
+	if old != nil {
+		if emitter != nil {
+			old.Stop()
+		}
+		if err := old.Close(); err != nil {
+			b.log.Errorf("Failed to close replaced global processors: %v", err)
+		}
+	}
+
+	b.mutex.Lock()
+	b.processors, b.global = grp, global
+	if emitter != nil && global != nil {
+		global.Start(emitter.emit)
+	}
+	b.mutex.Unlock()
+}
+
+func (b *builder) hasGlobalProcessors() bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.processors != nil
+}
+
+func (b *builder) globalGroup() *group {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.processors
+}
+
+func makeGlobalGroup(
+	log *logp.Logger,
+	procs *processors.Processors,
+) (*group, *processors.Processors) {
+	if procs == nil || len(procs.List) == 0 {
+		return nil, nil
+	}
+
+	grp := newGroup("global", log)
+	for _, p := range procs.List {
+		grp.add(p)
+	}
+	return grp, procs
+}
+
 func makeClientProcessors(
 	log *logp.Logger,
 	cfg beat.ProcessingConfig,
@@ -36,3 +36,20 @@ type SupportFactory func(info beat.Info, log *logp.Logger, cfg *common.Config) (
 type Supporter interface {
 	Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor, error)
 }
+
+// AsyncSupporter is implemented by Supporters whose processors can generate
+// events asynchronously. The publisher pipeline starts them with a connector
+// used to publish the generated events, and stops them when it is closed.
+type AsyncSupporter interface {
+	Supporter
+	Start(pipeline beat.PipelineConnector) error
+	Stop()
+}
+
+// ReloadableSupporter is implemented by Supporters whose global processors
+// can be replaced while the pipeline is running. Reload gets the beat
+// configuration passed, in order to read the new global processors.
+type ReloadableSupporter interface {
+	Supporter
+	Reload(cfg *common.Config) error
+}
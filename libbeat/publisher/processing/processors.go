@@ -35,6 +35,17 @@ type group struct {
 	list  []beat.Processor
 }
 
+// globalProcessors runs the current global processors of a builder. The
+// global processors are looked up for every event, so that clients use the
+// new processors once they have been reloaded.
+type globalProcessors struct {
+	builder *builder
+
+	// copied is set if the event fields are copied before being processed,
+	// so they can be modified by the global processors.
+	copied bool
+}
+
 type processorFn struct {
 	name string
 	fn   func(event *beat.Event) (*beat.Event, error)
@@ -130,6 +141,30 @@ func newAnnotateProcessor(name string, fn func(*beat.Event)) *processorFn {
 	})
 }
 
+func (p *globalProcessors) String() string {
+	if grp := p.builder.globalGroup(); grp != nil {
+		return grp.String()
+	}
+	return "global{}"
+}
+
+func (p *globalProcessors) Run(event *beat.Event) (*beat.Event, error) {
+	grp := p.builder.globalGroup()
+	if grp == nil {
+		return event, nil
+	}
+
+	if !p.copied {
+		// The global processors have been added after the client connected,
+		// copy the event to not modify structures shared with the client.
+		event.Fields = event.Fields.Clone()
+		if event.Meta != nil {
+			event.Meta = event.Meta.Clone()
+		}
+	}
+	return grp.Run(event)
+}
+
 func (p *processorFn) String() string                         { return p.name }
 func (p *processorFn) Run(e *beat.Event) (*beat.Event, error) { return p.fn(e) }
 
@@ -0,0 +1,239 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package spool
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/paths"
+	"github.com/elastic/beats/libbeat/publisher"
+	"github.com/elastic/go-txfile"
+	"github.com/elastic/go-txfile/pq"
+)
+
+// Inspector gives access to the events stored in the spool file of a stopped
+// beat. The spool file is locked while the Inspector is open, so it can not be
+// opened while the beat is running.
+type Inspector struct {
+	path     string
+	readonly bool
+	file     *txfile.File
+	delegate pq.Delegate
+	observer *fileObserver
+}
+
+// Stats reports the state of a spool file.
+type Stats struct {
+	Path     string
+	Size     uint64 // file size on disk in bytes
+	MaxSize  uint64 // maximum file size in bytes
+	PageSize uint32
+	Pages    uint // number of data pages in use
+	Events   uint // number of events not yet ACKed by the outputs
+
+	// Oldest and Newest are the timestamps of the oldest and newest event in
+	// the spool. Both are zero if the spool is empty.
+	Oldest time.Time
+	Newest time.Time
+}
+
+// fileObserver records the most recent file stats reported by txfile.
+type fileObserver struct {
+	stats txfile.FileStats
+}
+
+// OpenInspector opens the spool file configured by cfg, holding the settings
+// of the spool queue. If readonly is set, the events in the spool can not be
+// removed.
+func OpenInspector(cfg *common.Config, readonly bool) (*Inspector, error) {
+	config := defaultConfig()
+	if cfg != nil {
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	path := config.File.Path
+	if path == "" {
+		path = paths.Resolve(paths.Data, "spool.dat")
+	}
+
+	// Do not create a new spool file if it does not exist yet.
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.Wrapf(err, "spool queue: failed to access file at path '%s'", path)
+	}
+
+	observer := &fileObserver{}
+	f, err := txfile.Open(path, config.File.Permissions, txfile.Options{
+		MaxSize:  uint64(config.File.MaxSize),
+		PageSize: uint32(config.File.PageSize),
+		Prealloc: config.File.Prealloc,
+		Readonly: readonly,
+		Observer: observer,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "spool queue: failed to open file at path '%s', make sure the beat is not running", path)
+	}
+
+	delegate, err := pq.NewStandaloneDelegate(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Inspector{
+		path:     path,
+		readonly: readonly,
+		file:     f,
+		delegate: delegate,
+		observer: observer,
+	}, nil
+}
+
+// Close closes the spool file.
+func (i *Inspector) Close() error {
+	return i.file.Close()
+}
+
+// Path returns the path of the spool file.
+func (i *Inspector) Path() string {
+	return i.path
+}
+
+// Stats reads all events in the spool to report the state of the spool.
+func (i *Inspector) Stats() (Stats, error) {
+	stats := Stats{Path: i.path}
+
+	err := i.withQueue(func(queue *pq.Queue) error {
+		active, err := queue.Active()
+		if err != nil {
+			return err
+		}
+		stats.Events = active
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	_, err = i.Read(0, func(event publisher.Event) error {
+		ts := event.Content.Timestamp
+		if stats.Oldest.IsZero() || ts.Before(stats.Oldest) {
+			stats.Oldest = ts
+		}
+		if stats.Newest.IsZero() || ts.After(stats.Newest) {
+			stats.Newest = ts
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	info, err := os.Stat(i.path)
+	if err != nil {
+		return stats, err
+	}
+	stats.Size = uint64(info.Size())
+
+	// file stats are updated by the transactions run to read the events
+	fileStats := i.observer.stats
+	stats.MaxSize = fileStats.MaxSize
+	stats.PageSize = fileStats.PageSize
+	stats.Pages = fileStats.DataAllocated
+	return stats, nil
+}
+
+// Read decodes the first n events of the spool, in the order they will be
+// published, and passes them to fn. All events are read if n is 0.
+// Read stops at the first event that can not be decoded or if fn returns an
+// error. The number of events passed to fn is returned.
+func (i *Inspector) Read(n uint, fn func(publisher.Event) error) (uint, error) {
+	var count uint
+	err := i.withQueue(func(queue *pq.Queue) error {
+		reader := queue.Reader()
+		if err := reader.Begin(); err != nil {
+			return err
+		}
+		defer reader.Done()
+
+		dec := newDecoder()
+		for n == 0 || count < n {
+			sz, err := reader.Next()
+			if sz <= 0 || err != nil {
+				return err
+			}
+
+			buf := dec.Buffer(sz)
+			if _, err := reader.Read(buf); err != nil {
+				return err
+			}
+
+			event, err := dec.Decode()
+			if err != nil {
+				return errors.Wrapf(err, "failed to decode event %d", count)
+			}
+
+			if err := fn(event); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Remove removes the first n events from the spool. All events are removed if
+// n is 0.
+func (i *Inspector) Remove(n uint) error {
+	if i.readonly {
+		return errors.New("spool queue: can not remove events, the file is opened read-only")
+	}
+
+	return i.withQueue(func(queue *pq.Queue) error {
+		if n == 0 {
+			active, err := queue.Active()
+			if err != nil {
+				return err
+			}
+			n = active
+		}
+		return queue.ACK(n)
+	})
+}
+
+// withQueue runs fn with a new queue instance, such that events are always
+// read from the start of the spool.
+func (i *Inspector) withQueue(fn func(*pq.Queue) error) error {
+	queue, err := pq.New(i.delegate, pq.Settings{})
+	if err != nil {
+		return err
+	}
+	defer queue.Close()
+
+	return fn(queue)
+}
+
+func (o *fileObserver) OnOpen(stats txfile.FileStats)                      { o.stats = stats }
+func (o *fileObserver) OnTxBegin(readonly bool)                            {}
+func (o *fileObserver) OnTxClose(stats txfile.FileStats, _ txfile.TxStats) { o.stats = stats }
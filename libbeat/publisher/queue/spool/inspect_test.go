@@ -0,0 +1,140 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package spool
+
+import (
+	"testing"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/publisher"
+	"github.com/elastic/beats/libbeat/publisher/queue"
+	"github.com/elastic/go-txfile"
+	"github.com/elastic/go-txfile/txfiletest"
+)
+
+func TestInspector(t *testing.T) {
+	path, cleanPath := txfiletest.SetupPath(t, "")
+	defer cleanPath()
+
+	start := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	writeTestSpool(t, path, start, "a", "b", "c")
+
+	cfg := common.MustNewConfigFrom(map[string]interface{}{
+		"file.path":      path,
+		"file.size":      "1MiB",
+		"file.page_size": "4KiB",
+	})
+
+	readMessages := func(inspector *Inspector, n uint) []string {
+		var messages []string
+		count, err := inspector.Read(n, func(event publisher.Event) error {
+			messages = append(messages, event.Content.Fields["message"].(string))
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, uint(len(messages)), count)
+		return messages
+	}
+
+	inspector, err := OpenInspector(cfg, true)
+	require.NoError(t, err)
+
+	stats, err := inspector.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, path, stats.Path)
+	assert.Equal(t, uint(3), stats.Events)
+	assert.Equal(t, uint32(4*humanize.KiByte), stats.PageSize)
+	assert.NotZero(t, stats.Pages)
+	assert.NotZero(t, stats.Size)
+	assert.True(t, start.Equal(stats.Oldest))
+	assert.True(t, start.Add(2*time.Minute).Equal(stats.Newest))
+
+	assert.Equal(t, []string{"a", "b"}, readMessages(inspector, 2))
+	assert.Equal(t, []string{"a", "b", "c"}, readMessages(inspector, 0))
+	assert.Error(t, inspector.Remove(1), "readonly inspector must not remove events")
+	require.NoError(t, inspector.Close())
+
+	inspector, err = OpenInspector(cfg, false)
+	require.NoError(t, err)
+	require.NoError(t, inspector.Remove(1))
+	assert.Equal(t, []string{"b", "c"}, readMessages(inspector, 0))
+	require.NoError(t, inspector.Close())
+
+	// removed events are not read again after reopening the spool
+	inspector, err = OpenInspector(cfg, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, readMessages(inspector, 0))
+	require.NoError(t, inspector.Remove(0))
+
+	stats, err = inspector.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), stats.Events)
+	assert.True(t, stats.Oldest.IsZero())
+	assert.Len(t, readMessages(inspector, 0), 0)
+	require.NoError(t, inspector.Close())
+}
+
+func TestInspectorMissingFile(t *testing.T) {
+	path, cleanPath := txfiletest.SetupPath(t, "")
+	defer cleanPath()
+
+	_, err := OpenInspector(common.MustNewConfigFrom(map[string]interface{}{
+		"file.path": path,
+	}), true)
+	assert.Error(t, err)
+}
+
+// writeTestSpool creates a spool file holding events with the given messages,
+// one minute apart. The events are left in the spool without being ACKed.
+func writeTestSpool(t *testing.T, path string, start time.Time, messages ...string) {
+	spool, err := NewSpool(&testLogger{t}, path, Settings{
+		Mode:             0600,
+		WriteBuffer:      16 * humanize.KiByte,
+		WriteFlushEvents: 1,
+		Codec:            codecCBORL,
+		File: txfile.Options{
+			MaxSize:  humanize.MiByte,
+			PageSize: 4 * humanize.KiByte,
+			Prealloc: true,
+		},
+	})
+	require.NoError(t, err)
+
+	producer := spool.Producer(queue.ProducerConfig{})
+	for i, msg := range messages {
+		producer.Publish(publisher.Event{
+			Content: beat.Event{
+				Timestamp: start.Add(time.Duration(i) * time.Minute),
+				Fields:    common.MapStr{"message": msg},
+			},
+		})
+	}
+
+	// wait for the events to be written to the file
+	batch, err := spool.Consumer().Get(len(messages))
+	require.NoError(t, err)
+	require.Len(t, batch.Events(), len(messages))
+
+	require.NoError(t, spool.Close())
+}
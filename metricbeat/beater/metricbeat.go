@@ -32,6 +32,7 @@ import (
 	"github.com/elastic/beats/libbeat/cfgfile"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/management/status"
 	"github.com/elastic/beats/metricbeat/mb"
 	"github.com/elastic/beats/metricbeat/mb/module"
 
@@ -48,12 +49,14 @@ type Metricbeat struct {
 	modules      []staticModule // Active list of modules.
 	config       Config
 	autodiscover *autodiscover.Autodiscover
+	status       status.Reporter
 
 	// Options
 	moduleOptions []module.Option
 }
 
 type staticModule struct {
+	config    *common.Config
 	connector *module.Connector
 	module    *module.Wrapper
 }
@@ -187,6 +190,7 @@ func newMetricbeat(b *beat.Beat, c *common.Config, options ...Option) (*Metricbe
 		}
 
 		metricbeat.modules = append(metricbeat.modules, staticModule{
+			config:    moduleCfg,
 			connector: connector,
 			module:    module,
 		})
@@ -222,18 +226,24 @@ func (bt *Metricbeat) Run(b *beat.Beat) error {
 
 	// Static modules (metricbeat.modules)
 	for _, m := range bt.modules {
+		st, err := cfgfile.RegisterStatus(m.config)
+		if err != nil {
+			return err
+		}
+
 		client, err := m.connector.Connect()
 		if err != nil {
+			st.Unregister()
 			return err
 		}
 
-		r := module.NewRunner(client, m.module)
-		r.Start()
+		r := module.NewRunner(st.Client(client), m.module)
+		cfgfile.StartRunner(r, st)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			<-bt.done
-			r.Stop()
+			cfgfile.StopRunner(r, st)
 		}()
 	}
 
@@ -276,10 +286,23 @@ func (bt *Metricbeat) Run(b *beat.Beat) error {
 		}()
 	}
 
+	// The modules are started, the status of each module is reported by its
+	// own runner.
+	if bt.status != nil {
+		bt.status.UpdateStatus(status.Running, "")
+	}
+
 	wg.Wait()
 	return nil
 }
 
+// SetStatusReporter sets the reporter used to report the status of
+// Metricbeat. Metricbeat is reported as running once the configured modules
+// have been started.
+func (bt *Metricbeat) SetStatusReporter(reporter status.Reporter) {
+	bt.status = reporter
+}
+
 // Stop signals to Metricbeat that it should stop. It closes the "done" channel
 // and closes the publisher client associated with each Module.
 //
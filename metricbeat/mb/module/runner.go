@@ -22,6 +22,7 @@ import (
 	"sync"
 
 	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/management/status"
 	"github.com/elastic/beats/libbeat/monitoring"
 )
 
@@ -70,10 +71,23 @@ type runner struct {
 	stopOnce  sync.Once
 	mod       *Wrapper
 	client    beat.Client
+	status    status.Reporter
+}
+
+// SetStatusReporter sets the reporter used to report the status of the
+// Module.
+func (mr *runner) SetStatusReporter(reporter status.Reporter) {
+	mr.status = reporter
+	mr.mod.SetStatusReporter(reporter)
 }
 
 func (mr *runner) Start() {
 	mr.startOnce.Do(func() {
+		// report running before starting the MetricSets, which might
+		// report the module as degraded right away
+		if mr.status != nil {
+			mr.status.UpdateStatus(status.Running, "")
+		}
 		output := mr.mod.Start(mr.done)
 		mr.wg.Add(1)
 		moduleList.Add(mr.mod.Name())
@@ -27,6 +27,7 @@ import (
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/management/status"
 	"github.com/elastic/beats/libbeat/monitoring"
 	"github.com/elastic/beats/libbeat/testing"
 	"github.com/elastic/beats/metricbeat/mb"
@@ -57,6 +58,11 @@ type Wrapper struct {
 	// Options
 	maxStartDelay  time.Duration
 	eventModifiers []mb.EventModifier
+
+	// Status reporting
+	statusMu sync.Mutex
+	status   status.Reporter
+	failing  map[*metricSetWrapper]struct{} // MetricSets whose last fetch failed.
 }
 
 // metricSetWrapper contains the MetricSet and the private data associated with
@@ -157,6 +163,41 @@ func (mw *Wrapper) String() string {
 		mw.Name(), len(mw.metricSets))
 }
 
+// SetStatusReporter sets the reporter used to report the status of the
+// module. The module is reported as degraded while the last fetch of any of
+// its MetricSets failed.
+func (mw *Wrapper) SetStatusReporter(reporter status.Reporter) {
+	mw.statusMu.Lock()
+	defer mw.statusMu.Unlock()
+	mw.status = reporter
+	mw.failing = map[*metricSetWrapper]struct{}{}
+}
+
+// updateStatus updates the module status with the result of the last fetch
+// of a MetricSet.
+func (mw *Wrapper) updateStatus(msw *metricSetWrapper, err error) {
+	mw.statusMu.Lock()
+	defer mw.statusMu.Unlock()
+
+	if mw.status == nil {
+		return
+	}
+
+	if err != nil {
+		mw.failing[msw] = struct{}{}
+		mw.status.UpdateStatus(status.Degraded, fmt.Sprintf("%s/%s: %v", mw.Name(), msw.Name(), err))
+		return
+	}
+
+	if _, failed := mw.failing[msw]; !failed {
+		return
+	}
+	delete(mw.failing, msw)
+	if len(mw.failing) == 0 {
+		mw.status.UpdateStatus(status.Running, "")
+	}
+}
+
 // MetricSets return the list of metricsets of the module
 func (mw *Wrapper) MetricSets() []*metricSetWrapper {
 	return mw.metricSets
@@ -393,6 +434,7 @@ func (r reporterV2) Event(event mb.Event) bool {
 	} else {
 		r.msw.stats.failures.Add(1)
 	}
+	r.msw.module.updateStatus(r.msw, event.Error)
 
 	if event.Namespace == "" {
 		event.Namespace = r.msw.Registration().Namespace
@@ -20,10 +20,13 @@
 package module_test
 
 import (
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/management/status"
 	"github.com/elastic/beats/metricbeat/mb"
 	"github.com/elastic/beats/metricbeat/mb/module"
 
@@ -35,6 +38,7 @@ const (
 	eventFetcherName     = "EventFetcher"
 	reportingFetcherName = "ReportingFetcher"
 	pushMetricSetName    = "PushMetricSet"
+	failingFetcherName   = "FailingFetcher"
 )
 
 // fakeMetricSet
@@ -102,8 +106,42 @@ func newFakePushMetricSet(base mb.BaseMetricSet) (mb.MetricSet, error) {
 	return &fakePushMetricSet{BaseMetricSet: base}, nil
 }
 
+// FailingFetcher
+
+type fakeFailingFetcher struct {
+	mb.BaseMetricSet
+	fetches int
+}
+
+// Fetch fails on the first fetch and succeeds afterwards.
+func (ms *fakeFailingFetcher) Fetch(r mb.ReporterV2) error {
+	ms.fetches++
+	if ms.fetches == 1 {
+		return errors.New("connection refused")
+	}
+	r.Event(mb.Event{MetricSetFields: common.MapStr{"metric": 1}})
+	return nil
+}
+
+func newFakeFailingFetcher(base mb.BaseMetricSet) (mb.MetricSet, error) {
+	return &fakeFailingFetcher{BaseMetricSet: base}, nil
+}
+
 // test utilities
 
+type statusRecorder struct {
+	mu       sync.Mutex
+	statuses []status.Status
+	messages []string
+}
+
+func (r *statusRecorder) UpdateStatus(s status.Status, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, s)
+	r.messages = append(r.messages, msg)
+}
+
 func newTestRegistry(t testing.TB) *mb.Register {
 	r := mb.NewRegister()
 
@@ -116,6 +154,9 @@ func newTestRegistry(t testing.TB) *mb.Register {
 	if err := r.AddMetricSet(moduleName, pushMetricSetName, newFakePushMetricSet); err != nil {
 		t.Fatal(err)
 	}
+	if err := r.AddMetricSet(moduleName, failingFetcherName, newFakeFailingFetcher); err != nil {
+		t.Fatal(err)
+	}
 
 	return r
 }
@@ -227,6 +268,37 @@ func TestWrapperOfPushMetricSet(t *testing.T) {
 	}
 }
 
+func TestWrapperStatus(t *testing.T) {
+	c := newConfig(t, map[string]interface{}{
+		"module":     moduleName,
+		"metricsets": []string{failingFetcherName},
+		"period":     "10ms",
+	})
+
+	m, err := module.NewWrapper(c, newTestRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &statusRecorder{}
+	m.SetStatusReporter(recorder)
+
+	done := make(chan struct{})
+	output := m.Start(done)
+
+	// failed fetch, followed by a successful one
+	<-output
+	<-output
+	close(done)
+	for range output {
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	assert.Equal(t, []status.Status{status.Degraded, status.Running}, recorder.statuses[:2])
+	assert.Equal(t, "fake/failingfetcher: connection refused", recorder.messages[0])
+}
+
 func TestPeriodIsAddedToEvent(t *testing.T) {
 	cases := map[string]struct {
 		metricset string